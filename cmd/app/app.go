@@ -3,41 +3,41 @@
 // found in the LICENSE file.
 
 // Package app sets up the AppEngine routing and h.
+//
+// The rotations, members and shifts are kept in the bolt database ROTA_DB and the
+// calendars in the directory ROTA_CALENDARS, both must be on a persistent volume.
+// The rota and member iCalendar feeds are only served with ROTA_ICS_KEY set. Rotations can
+// only use holiday files from the ROTA_HOLIDAYS directory.
+//
+// The Google Calendar and datastore backends, the legacy sheriff OAuth credentials
+// and the Legacy generator are not available, the legacy calendar uses the ics
+// calendar as well.
 package app
 
 import (
 	"context"
-	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
 
-	"chromium.googlesource.com/infra/rotang"
-	"chromium.googlesource.com/infra/rotang/cmd/handlers"
-	"chromium.googlesource.com/infra/rotang/pkg/algo"
-	"chromium.googlesource.com/infra/rotang/pkg/calendar"
-	"chromium.googlesource.com/infra/rotang/pkg/datastore"
+	rotang "github.com/miekg/rota"
+	"github.com/miekg/rota/cmd/handlers"
+	"github.com/miekg/rota/pkg/algo"
+	"github.com/miekg/rota/pkg/calendar/ics"
+	"github.com/miekg/rota/pkg/storage/bolt"
 	"go.chromium.org/gae/service/mail"
 	"go.chromium.org/luci/appengine/gaeauth/server"
 	"go.chromium.org/luci/appengine/gaemiddleware/standard"
-	"go.chromium.org/luci/common/logging"
 	"go.chromium.org/luci/server/auth"
 	"go.chromium.org/luci/server/router"
 	"go.chromium.org/luci/server/templates"
-	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 	"google.golang.org/appengine"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
-
-	gcal "google.golang.org/api/calendar/v3"
 )
 
 const (
 	datastoreScope = "https://www.googleapis.com/auth/datastore"
 	authGroup      = "sheriff-o-matic-access"
-	sheriffConfig  = "token/sheriff_secret.json"
-	sheriffToken   = "token/sheriff_token.json"
 )
 
 type appengineMailer struct{}
@@ -46,81 +46,21 @@ func (a *appengineMailer) Send(ctx context.Context, msg *mail.Message) error {
 	return mail.Send(ctx, msg)
 }
 
-var errStatus = func(c context.Context, w http.ResponseWriter, status int, msg string) {
-	logging.Errorf(c, "Status %d msg %s", status, msg)
-	w.WriteHeader(status)
-	w.Write([]byte(msg))
-}
-
-func requireGoogler(ctx *router.Context, next router.Handler) {
-	isGoogler, err := auth.IsMember(ctx.Context, authGroup)
-	switch {
-	case err != nil:
-		errStatus(ctx.Context, ctx.Writer, http.StatusInternalServerError, err.Error())
-	case !isGoogler:
-		url, err := auth.LoginURL(ctx.Context, ctx.Params.ByName("path"))
-		if err != nil {
-			errStatus(ctx.Context, ctx.Writer, http.StatusForbidden, "Access denied err:"+err.Error())
-			return
-		}
-		http.Redirect(ctx.Writer, ctx.Request, url, http.StatusFound)
-		return
-	default:
-		next(ctx)
-	}
-}
-
-const legacyTokenID = "LegacyToken"
-
-func dsLegacyCred(ts func(context.Context) rotang.TokenStorer) func(*router.Context) (*http.Client, error) {
-	return func(ctx *router.Context) (*http.Client, error) {
-		clt, err := ts(ctx.Context).Client(ctx, legacyTokenID)
-		if err != nil {
-			if status.Code(err) != codes.NotFound {
-				return nil, err
-			}
-			logging.Warningf(ctx.Context, "token: %q not found in datastore, trying to fetch from token files")
-			b, err := ioutil.ReadFile(sheriffConfig)
-			if err != nil {
-				return nil, err
-			}
-			config, err := google.ConfigFromJSON(b, gcal.CalendarScope)
-			if err != nil {
-				return nil, err
-			}
-
-			t, err := ioutil.ReadFile(sheriffToken)
-			if err != nil {
-				return nil, err
-			}
-			token := &oauth2.Token{
-				RefreshToken: string(t),
-				TokenType:    "Bearer",
-			}
-			if err := ts(ctx.Context).CreateToken(ctx.Context, legacyTokenID, string(b), token); err != nil {
-				return nil, err
-			}
-			clt = config.Client(appengine.NewContext(ctx.Request), token)
-		}
-		return clt, nil
-	}
-}
-
 func serviceDefaultCred(scope string) func(*router.Context) (*http.Client, error) {
 	return func(ctx *router.Context) (*http.Client, error) {
 		return google.DefaultClient(appengine.NewContext(ctx.Request), scope)
 	}
 }
 
-func setupStoreHandlers(o *handlers.Options, sf func(context.Context) *datastore.Store) {
+func setupStoreHandlers(o *handlers.Options, st *bolt.Store) {
 	o.MemberStore = func(ctx context.Context) rotang.MemberStorer {
-		return sf(ctx)
+		return st
 	}
 	o.ShiftStore = func(ctx context.Context) rotang.ShiftStorer {
-		return sf(ctx)
+		return st
 	}
 	o.ConfigStore = func(ctx context.Context) rotang.ConfigStorer {
-		return sf(ctx)
+		return st
 	}
 }
 
@@ -132,13 +72,21 @@ func init() {
 		log.Fatal("env PROD_ENV must be set to one of `production`, `local` or `staging`")
 	}
 
-	lcred := dsLegacyCred(func(ctx context.Context) rotang.TokenStorer {
-		return datastore.New(ctx)
-	})
-
-	cred := serviceDefaultCred(gcal.CalendarScope)
-	if prodENV == "local" {
-		cred = lcred
+	db := os.Getenv("ROTA_DB")
+	if db == "" {
+		log.Fatal("env ROTA_DB must be set to the path of the rota database")
+	}
+	st, err := bolt.New(db)
+	if err != nil {
+		log.Fatal(err)
+	}
+	calDir := os.Getenv("ROTA_CALENDARS")
+	if calDir == "" {
+		log.Fatal("env ROTA_CALENDARS must be set to the calendar directory")
+	}
+	cal, err := ics.New(calDir)
+	if err != nil {
+		log.Fatal(err)
 	}
 
 	r := router.New()
@@ -149,34 +97,42 @@ func init() {
 		Loader: templates.FileSystemLoader("templates"),
 	}), auth.Authenticate(server.UsersAPIAuthMethod{}))
 
-	protected := tmw.Extend(requireGoogler)
+	algo.HolidayDir = os.Getenv("ROTA_HOLIDAYS")
 
 	// Sort out the generators.
 	gs := algo.New()
-	gs.Register(algo.NewLegacy())
 	gs.Register(algo.NewFair())
 	gs.Register(algo.NewRandomGen())
+	gs.Register(algo.NewOptimal())
 	gs.Register(algo.NewTZFair())
+	gs.Register(algo.NewShadow(algo.NewFair()))
+	gs.Register(algo.NewFollowTheSun())
+	gs.Register(algo.NewFairHours())
 
 	// And the modifiers.
 	gs.RegisterModifier(algo.NewWeekendSkip())
 	gs.RegisterModifier(algo.NewSplitShift())
+	gs.RegisterModifier(algo.NewHolidaySkip())
 
 	opts := handlers.Options{
 		ProjectID:      appengine.AppID,
 		BackupCred:     serviceDefaultCred(datastoreScope),
-		LegacyCalendar: calendar.New(lcred),
-		Calendar:       calendar.New(cred),
+		LegacyCalendar: cal,
+		Calendar:       cal,
 		Generators:     gs,
 		MailSender:     &appengineMailer{},
 		ProdENV:        prodENV,
+		AccessGroup:    authGroup,
+		ICSKey:         []byte(os.Getenv("ROTA_ICS_KEY")),
 	}
-	setupStoreHandlers(&opts, datastore.New)
+	setupStoreHandlers(&opts, st)
 	h, err := handlers.New(&opts)
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	protected := tmw.Extend(h.RequireAccess)
+
 	r.GET("/", protected, h.HandleIndex)
 	r.GET("/upload", protected, h.HandleUpload)
 	r.GET("/list", protected, h.HandleList)
@@ -186,6 +142,8 @@ func init() {
 	r.GET("/importshiftsjson", protected, h.HandleShiftImportJSON)
 	r.GET("/manageshifts", protected, h.HandleManageShifts)
 	r.GET("/legacy/:name", tmw, h.HandleLegacy)
+	r.GET("/ics/rota/:name/:token", tmw, h.HandleRotaICS)
+	r.GET("/ics/member/:email/:token", tmw, h.HandleMemberICS)
 	r.GET("/oncall", protected, h.HandleOncall)
 	r.GET("/oncall/:name", protected, h.HandleOncall)
 	r.GET("/memberjson", protected, h.HandleMember)
@@ -195,6 +153,7 @@ func init() {
 	r.GET("/emailtest", protected, h.HandleEmailTest)
 	r.GET("/emailjsontest", protected, h.HandleEmailTestJSON)
 	r.GET("/emailsendtest", protected, h.HandleEmailTestSend)
+	r.GET("/stats", protected, h.HandleStats)
 
 	r.POST("/oncalljson", protected, h.HandleOncallJSON)
 	r.POST("/shiftsupdate", protected, h.HandleShiftUpdate)
@@ -214,6 +173,7 @@ func init() {
 	r.GET("/cron/email", tmw, h.JobEmail)
 	r.GET("/cron/schedule", tmw, h.JobSchedule)
 	r.GET("/cron/eventupdate", tmw, h.JobEventUpdate)
+	r.GET("/cron/oooimport", tmw, h.JobOOOImport)
 
 	http.DefaultServeMux.Handle("/", r)
 }
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	rotang "github.com/miekg/rota"
+	"go.chromium.org/luci/common/logging"
+	"go.chromium.org/luci/server/auth"
+	"go.chromium.org/luci/server/router"
+	"google.golang.org/appengine"
+	aeuser "google.golang.org/appengine/user"
+)
+
+// luciAuth authenticates users with LUCI auth and uses AppEngine admins and
+// LUCI groups for authorization. It's used when no Authenticator or Authorizer
+// is set in the Options.
+type luciAuth struct{}
+
+var (
+	_ rotang.Authenticator = &luciAuth{}
+	_ rotang.Authorizer    = &luciAuth{}
+)
+
+func (l *luciAuth) User(ctx *router.Context) (*rotang.User, error) {
+	usr := auth.CurrentUser(ctx.Context)
+	if usr == nil || usr.Email == "" {
+		return nil, nil
+	}
+	return &rotang.User{
+		Email: usr.Email,
+		Name:  usr.Name,
+	}, nil
+}
+
+func (l *luciAuth) Login(ctx *router.Context) {
+	url, err := auth.LoginURL(ctx.Context, ctx.Params.ByName("path"))
+	if err != nil {
+		http.Error(ctx.Writer, "not logged in", http.StatusForbidden)
+		return
+	}
+	http.Redirect(ctx.Writer, ctx.Request, url, http.StatusFound)
+}
+
+func (l *luciAuth) IsAdmin(ctx *router.Context, _ *rotang.User) (bool, error) {
+	return aeuser.IsAdmin(appengine.NewContext(ctx.Request)), nil
+}
+
+// IsMember checks the group membership of the current LUCI identity.
+func (l *luciAuth) IsMember(ctx *router.Context, _ *rotang.User, group string) (bool, error) {
+	return auth.IsMember(ctx.Context, group)
+}
+
+// currentUser returns the user making the request, nil if not authenticated.
+func (h *State) currentUser(ctx *router.Context) *rotang.User {
+	usr, err := h.authenticator.User(ctx)
+	if err != nil {
+		logging.Warningf(ctx.Context, "authentication failed: %v", err)
+		return nil
+	}
+	if usr == nil || usr.Email == "" {
+		return nil
+	}
+	return usr
+}
+
+// isAdmin checks if the user is an admin, errors are logged and treated as not an admin.
+func (h *State) isAdmin(ctx *router.Context, usr *rotang.User) bool {
+	admin, err := h.authorizer.IsAdmin(ctx, usr)
+	if err != nil {
+		logging.Warningf(ctx.Context, "admin check for: %q failed: %v", usr.Email, err)
+		return false
+	}
+	return admin
+}
+
+// adminOrOwner is true if the current user is an admin or owner of the rotation.
+func (h *State) adminOrOwner(ctx *router.Context, cfg *rotang.Configuration) bool {
+	usr := h.currentUser(ctx)
+	if usr == nil {
+		return false
+	}
+	if h.isAdmin(ctx, usr) {
+		return true
+	}
+	for _, m := range cfg.Config.Owners {
+		if strings.EqualFold(usr.Email, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// RequireAccess is a router middleware that only lets through authenticated users.
+// If an AccessGroup is set in the Options the user must be a member of that group as well.
+func (h *State) RequireAccess(ctx *router.Context, next router.Handler) {
+	usr := h.currentUser(ctx)
+	if usr == nil {
+		h.authenticator.Login(ctx)
+		return
+	}
+	if h.accessGroup != "" {
+		ok, err := h.authorizer.IsMember(ctx, usr, h.accessGroup)
+		switch {
+		case err != nil:
+			http.Error(ctx.Writer, err.Error(), http.StatusInternalServerError)
+			return
+		case !ok:
+			http.Error(ctx.Writer, "access denied", http.StatusForbidden)
+			return
+		}
+	}
+	next(ctx)
+}
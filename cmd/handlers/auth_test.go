@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	rotang "github.com/miekg/rota"
+	"go.chromium.org/luci/server/router"
+)
+
+type fakeAuth struct {
+	usr    *rotang.User
+	admins []string
+}
+
+func (f *fakeAuth) User(_ *router.Context) (*rotang.User, error) {
+	return f.usr, nil
+}
+
+func (f *fakeAuth) Login(ctx *router.Context) {
+	http.Error(ctx.Writer, "login", http.StatusUnauthorized)
+}
+
+func (f *fakeAuth) IsAdmin(_ *router.Context, usr *rotang.User) (bool, error) {
+	for _, a := range f.admins {
+		if a == usr.Email {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *fakeAuth) IsMember(_ *router.Context, usr *rotang.User, group string) (bool, error) {
+	for _, g := range usr.Groups {
+		if g == group {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func TestRequireAccess(t *testing.T) {
+	tests := []struct {
+		name        string
+		usr         *rotang.User
+		accessGroup string
+		want        int
+	}{{
+		name: "Not logged in",
+		want: http.StatusUnauthorized,
+	}, {
+		name: "Logged in",
+		usr:  &rotang.User{Email: "test@user.com"},
+		want: http.StatusOK,
+	}, {
+		name:        "In access group",
+		usr:         &rotang.User{Email: "test@user.com", Groups: []string{"rota-access"}},
+		accessGroup: "rota-access",
+		want:        http.StatusOK,
+	}, {
+		name:        "Not in access group",
+		usr:         &rotang.User{Email: "test@user.com", Groups: []string{"other"}},
+		accessGroup: "rota-access",
+		want:        http.StatusForbidden,
+	},
+	}
+
+	h := testSetup(t)
+	for _, tst := range tests {
+		fake := &fakeAuth{usr: tst.usr}
+		h.authenticator, h.authorizer, h.accessGroup = fake, fake, tst.accessGroup
+		recorder := httptest.NewRecorder()
+		ctx := &router.Context{
+			Context: newTestContext(),
+			Writer:  recorder,
+			Request: getRequest("/"),
+		}
+		h.RequireAccess(ctx, func(ctx *router.Context) {
+			ctx.Writer.WriteHeader(http.StatusOK)
+		})
+		if got, want := recorder.Code, tst.want; got != want {
+			t.Errorf("%s: RequireAccess(ctx, _) = %d want: %d", tst.name, got, want)
+		}
+	}
+}
+
+func TestAdminOrOwner(t *testing.T) {
+	tests := []struct {
+		name string
+		usr  *rotang.User
+		want bool
+	}{{
+		name: "Not logged in",
+	}, {
+		name: "Owner",
+		usr:  &rotang.User{Email: "owner@user.com"},
+		want: true,
+	}, {
+		name: "Owner different case",
+		usr:  &rotang.User{Email: "Owner@User.com"},
+		want: true,
+	}, {
+		name: "Admin",
+		usr:  &rotang.User{Email: "admin@user.com"},
+		want: true,
+	}, {
+		name: "Neither",
+		usr:  &rotang.User{Email: "test@user.com"},
+	},
+	}
+
+	h := testSetup(t)
+	cfg := &rotang.Configuration{
+		Config: rotang.Config{
+			Name:   "Test Rota",
+			Owners: []string{"owner@user.com"},
+		},
+	}
+	for _, tst := range tests {
+		fake := &fakeAuth{usr: tst.usr, admins: []string{"admin@user.com"}}
+		h.authenticator, h.authorizer = fake, fake
+		ctx := &router.Context{
+			Context: newTestContext(),
+			Writer:  httptest.NewRecorder(),
+			Request: getRequest("/"),
+		}
+		if got, want := h.adminOrOwner(ctx, cfg), tst.want; got != want {
+			t.Errorf("%s: adminOrOwner(ctx, _) = %t want: %t", tst.name, got, want)
+		}
+	}
+}
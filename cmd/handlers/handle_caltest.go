@@ -7,7 +7,7 @@ import (
 	"net/http"
 	"time"
 
-	"chromium.googlesource.com/infra/rotang"
+	rotang "github.com/miekg/rota"
 	"go.chromium.org/luci/common/clock"
 	"go.chromium.org/luci/server/router"
 )
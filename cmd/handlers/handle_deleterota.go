@@ -40,7 +40,7 @@ func (h *State) HandleDeleteRota(ctx *router.Context) {
 	}
 	rota := rotas[0]
 
-	if !adminOrOwner(ctx, rota) {
+	if !h.adminOrOwner(ctx, rota) {
 		http.Error(ctx.Writer, "not in the rotation owners", http.StatusForbidden)
 		return
 	}
@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"chromium.googlesource.com/infra/rotang"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -11,6 +10,7 @@ import (
 	"context"
 
 	"github.com/kylelemons/godebug/pretty"
+	rotang "github.com/miekg/rota"
 	"go.chromium.org/luci/auth/identity"
 	"go.chromium.org/luci/server/auth"
 	"go.chromium.org/luci/server/auth/authtest"
@@ -4,16 +4,15 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"chromium.googlesource.com/infra/rotang"
 	"io"
 	"net/http"
 	"sort"
 	"time"
 
+	rotang "github.com/miekg/rota"
 	"go.chromium.org/gae/service/mail"
 	"go.chromium.org/luci/common/clock"
 	"go.chromium.org/luci/common/logging"
-	"go.chromium.org/luci/server/auth"
 	"go.chromium.org/luci/server/router"
 	"go.chromium.org/luci/server/templates"
 	"google.golang.org/grpc/codes"
@@ -27,7 +26,7 @@ type testEmail struct {
 
 // HandleEmailTest lists the members rotations.
 func (h *State) HandleEmailTest(ctx *router.Context) {
-	usr := auth.CurrentUser(ctx.Context)
+	usr := h.currentUser(ctx)
 	if usr == nil || usr.Email == "" {
 		http.Error(ctx.Writer, "not logged in", http.StatusForbidden)
 		return
@@ -106,7 +105,7 @@ func (h *State) HandleEmailTestSend(ctx *router.Context) {
 	}
 	fmt.Println(subject, body)
 
-	to, sender := h.setSender(ctx, auth.CurrentUser(ctx.Context).Email)
+	to, sender := h.setSender(ctx, h.currentUser(ctx).Email)
 
 	if err := h.mailSender.Send(ctx.Context, &mail.Message{
 		Sender:  sender,
@@ -148,12 +147,16 @@ func (h *State) fillEmail(ctx *router.Context, rota *rotang.Configuration, t tim
 		}
 	}
 
-	m, err := h.memberStore(ctx.Context).Member(ctx.Context, auth.CurrentUser(ctx.Context).Email)
+	var email string
+	if usr := h.currentUser(ctx); usr != nil {
+		email = usr.Email
+	}
+	m, err := h.memberStore(ctx.Context).Member(ctx.Context, email)
 	if err != nil {
 		if status.Code(err) != codes.NotFound {
 			return "", "", err
 		}
-		logging.Infof(ctx.Context, "User %q not in any rotations, using a dummy member", auth.CurrentUser(ctx.Context).Email)
+		logging.Infof(ctx.Context, "User %q not in any rotations, using a dummy member", email)
 		m = &rotang.Member{
 			Name:  "Dummy Member",
 			Email: "dummy@dummy.com",
@@ -165,6 +168,7 @@ func (h *State) fillEmail(ctx *router.Context, rota *rotang.Configuration, t tim
 		ShiftConfig: rota.Config.Shifts,
 		ShiftEntry:  ss[0],
 		Member:      *m,
+		Role:        ss[0].MemberRole(m.Email),
 	})
 	if err != nil {
 		return "", "", err
@@ -3,13 +3,13 @@ package handlers
 import (
 	"context"
 	"encoding/json"
-	"chromium.googlesource.com/infra/rotang"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"testing"
 
 	"github.com/kylelemons/godebug/pretty"
+	rotang "github.com/miekg/rota"
 
 	"go.chromium.org/luci/auth/identity"
 	"go.chromium.org/luci/common/clock"
@@ -9,8 +9,8 @@ import (
 	"strings"
 	"time"
 
-	"chromium.googlesource.com/infra/rotang"
-
+	rotang "github.com/miekg/rota"
+	"github.com/miekg/rota/pkg/algo"
 	"go.chromium.org/luci/common/clock"
 	"go.chromium.org/luci/common/logging"
 	"go.chromium.org/luci/server/router"
@@ -43,7 +43,7 @@ func (h *State) HandleGenerate(ctx *router.Context) {
 		}
 	default:
 		var err error
-		if start, err = time.ParseInLocation(elementTimeFormat, ctx.Request.FormValue("startTime"), mtvTime); err != nil {
+		if start, err = time.ParseInLocation(elementTimeFormat, ctx.Request.FormValue("startTime"), rota.Config.Shifts.Location()); err != nil {
 			http.Error(ctx.Writer, err.Error(), http.StatusBadRequest)
 			return
 		}
@@ -80,15 +80,23 @@ func (h *State) HandleGenerate(ctx *router.Context) {
 		http.Error(ctx.Writer, err.Error(), http.StatusInternalServerError)
 		return
 	}
+
+	// A seed recorded in earlier generated shifts regenerates those shifts.
+	if seedStr := ctx.Request.FormValue("seed"); seedStr != "" {
+		seed, err := strconv.ParseInt(seedStr, 10, 64)
+		if err != nil {
+			http.Error(ctx.Writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		rota.Config.Shifts.Seed = seed
+	}
+	rota.Config.Shifts.Trace = true
 	ss, err := g.Generate(rota, start, shifts, members, nrSched)
 	if err != nil {
 		http.Error(ctx.Writer, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// TODO(olakar): Remove this when rotation TZ is implemented.
-	rota.Config.Shifts.TZ = *mtvTime
-
 	modifiers := strings.Split(ctx.Request.FormValue("modifiers"), ",")
 	for _, m := range modifiers {
 		if m == "" {
@@ -106,9 +114,25 @@ func (h *State) HandleGenerate(ctx *router.Context) {
 		logging.Infof(ctx.Context, "modifier: %q applied to generated shifts for rota: %q", m, rota.Config.Name)
 	}
 
+	// The traces are returned separately to keep them out of the shifts stored.
+	var traces []ShiftTrace
+	for i := range ss {
+		if len(ss[i].Trace) == 0 {
+			continue
+		}
+		traces = append(traces, ShiftTrace{
+			Name:      ss[i].Name,
+			StartTime: ss[i].StartTime,
+			Decisions: ss[i].Trace,
+		})
+		ss[i].Trace = nil
+	}
+
 	res := RotaShifts{
 		Rota:        rota.Config.Name,
 		SplitShifts: makeSplitShifts(ss, rota.Members),
+		Traces:      traces,
+		Warnings:    append(understaffedWarnings(rota, algo.Understaffed(&rota.Config.Shifts, ss)), coverageWarnings(rota, members, ss)...),
 	}
 
 	var resBuf bytes.Buffer
@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+
+	rotang "github.com/miekg/rota"
+	"github.com/miekg/rota/pkg/calendar/ics"
+	"github.com/miekg/rota/pkg/ical"
+	"go.chromium.org/luci/common/clock"
+	"go.chromium.org/luci/server/router"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const icsProdID = "-//miekg//rota//EN"
+
+// HandleRotaICS serves the shifts of the rotation `name` as an iCalendar feed.
+// Descriptions are filled in from the rota Description, used as a template run against rotang.Info.
+// Like the member feeds the feed is served without authentication and needs the `token`, see rotaICSPath.
+func (h *State) HandleRotaICS(ctx *router.Context) {
+	if err := ctx.Context.Err(); err != nil {
+		http.Error(ctx.Writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	name := ctx.Params.ByName("name")
+	if !h.validICSToken(rotaICSID(name), ctx.Params.ByName("token")) {
+		http.Error(ctx.Writer, "feed not found", http.StatusNotFound)
+		return
+	}
+	rotas, err := h.configStore(ctx.Context).RotaConfig(ctx.Context, name)
+	if err != nil {
+		icsError(ctx, err)
+		return
+	}
+	if len(rotas) != 1 {
+		http.Error(ctx.Writer, "unexpected number of rotations returned", http.StatusInternalServerError)
+		return
+	}
+	rota := rotas[0]
+
+	shifts, err := h.shiftStore(ctx.Context).AllShifts(ctx.Context, rota.Config.Name)
+	if err != nil && status.Code(err) != codes.NotFound {
+		icsError(ctx, err)
+		return
+	}
+	cal := &ical.Calendar{
+		ProdID:   icsProdID,
+		Name:     rota.Config.Name,
+		Location: rota.Config.Shifts.Location(),
+		Stamp:    clock.Now(ctx.Context),
+	}
+	for _, s := range shifts {
+		var oncall []string
+		for _, o := range s.OnCall {
+			oncall = append(oncall, o.String())
+		}
+		summary := rota.Config.Name + " " + s.Name
+		if len(oncall) > 0 {
+			summary += ": " + strings.Join(oncall, ", ")
+		}
+		evt, err := shiftEvent(rota, s, summary, &rotang.Info{
+			RotaName:    rota.Config.Name,
+			ShiftConfig: rota.Config.Shifts,
+			ShiftEntry:  s,
+		})
+		if err != nil {
+			icsError(ctx, err)
+			return
+		}
+		cal.Events = append(cal.Events, evt)
+	}
+	writeICS(ctx, cal)
+}
+
+// HandleMemberICS serves the shifts of the member `email` in all rotations as an iCalendar feed.
+// The feed is served without authentication, so calendar clients can subscribe to it; the `token`
+// in the URL keeps the feeds of other members from being guessed, see memberICSPath.
+func (h *State) HandleMemberICS(ctx *router.Context) {
+	if err := ctx.Context.Err(); err != nil {
+		http.Error(ctx.Writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	email := ctx.Params.ByName("email")
+	if !h.validICSToken(email, ctx.Params.ByName("token")) {
+		http.Error(ctx.Writer, "feed not found", http.StatusNotFound)
+		return
+	}
+	member, err := h.memberStore(ctx.Context).Member(ctx.Context, email)
+	if err != nil {
+		icsError(ctx, err)
+		return
+	}
+	rotas, err := h.configStore(ctx.Context).MemberOf(ctx.Context, member.Email)
+	if err != nil {
+		icsError(ctx, err)
+		return
+	}
+
+	loc := time.UTC
+	if member.TZ.String() != "" {
+		loc = &member.TZ
+	}
+	cal := &ical.Calendar{
+		ProdID:   icsProdID,
+		Name:     member.Name,
+		Location: loc,
+		Stamp:    clock.Now(ctx.Context),
+	}
+	for _, r := range rotas {
+		cfgs, err := h.configStore(ctx.Context).RotaConfig(ctx.Context, r)
+		if err != nil {
+			icsError(ctx, err)
+			return
+		}
+		if len(cfgs) != 1 {
+			http.Error(ctx.Writer, "unexpected number of rotations returned", http.StatusInternalServerError)
+			return
+		}
+		rota := cfgs[0]
+		shifts, err := h.shiftStore(ctx.Context).AllShifts(ctx.Context, r)
+		if err != nil && status.Code(err) != codes.NotFound {
+			icsError(ctx, err)
+			return
+		}
+		for _, s := range shifts {
+			oncall := false
+			for _, o := range s.OnCall {
+				oncall = oncall || o.Email == member.Email
+			}
+			if !oncall {
+				continue
+			}
+			summary := rota.Config.Name + " " + s.Name
+			role := s.MemberRole(member.Email)
+			if role != "" {
+				summary += " (" + string(role) + ")"
+			}
+			evt, err := shiftEvent(rota, s, summary, &rotang.Info{
+				RotaName:    rota.Config.Name,
+				ShiftConfig: rota.Config.Shifts,
+				ShiftEntry:  s,
+				Member:      *member,
+				Role:        role,
+			})
+			if err != nil {
+				icsError(ctx, err)
+				return
+			}
+			cal.Events = append(cal.Events, evt)
+		}
+	}
+	writeICS(ctx, cal)
+}
+
+// memberICSPath returns the path of the iCalendar feed of the member, empty if the member feeds are not served.
+func (h *State) memberICSPath(email string) string {
+	if len(h.icsKey) == 0 {
+		return ""
+	}
+	return "/ics/member/" + url.PathEscape(email) + "/" + h.icsToken(email)
+}
+
+// rotaICSPath returns the path of the iCalendar feed of the rotation, empty if the feeds are not served.
+func (h *State) rotaICSPath(name string) string {
+	if len(h.icsKey) == 0 {
+		return ""
+	}
+	return "/ics/rota/" + url.PathEscape(name) + "/" + h.icsToken(rotaICSID(name))
+}
+
+// rotaICSID is what's signed for the feed of a rotation, it can't be a member e-mail.
+func rotaICSID(name string) string {
+	return "rota\x00" + name
+}
+
+// icsToken signs the id, the feeds of other members and rotations can't be found without the key.
+func (h *State) icsToken(id string) string {
+	mac := hmac.New(sha256.New, h.icsKey)
+	mac.Write([]byte(id))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (h *State) validICSToken(id, token string) bool {
+	if len(h.icsKey) == 0 {
+		return false
+	}
+	return hmac.Equal([]byte(token), []byte(h.icsToken(id)))
+}
+
+// shiftEvent returns the event for a shift. The UID is the same for every feed the shift is in,
+// the EvtID of the shift when it has one or the UID the ics calendar would give it.
+func shiftEvent(cfg *rotang.Configuration, shift rotang.ShiftEntry, summary string, info *rotang.Info) (ical.Event, error) {
+	description, err := descriptionFromTemplate(cfg, info)
+	if err != nil {
+		return ical.Event{}, err
+	}
+	uid := shift.EvtID
+	if uid == "" {
+		uid = ics.UID(cfg, &shift)
+	}
+	return ical.Event{
+		UID:         uid,
+		Summary:     summary,
+		Description: description,
+		Start:       shift.StartTime,
+		End:         shift.EndTime,
+	}, nil
+}
+
+func descriptionFromTemplate(cfg *rotang.Configuration, info *rotang.Info) (string, error) {
+	tmpl, err := template.New("Description").Parse(cfg.Config.Description)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, info); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func writeICS(ctx *router.Context, cal *ical.Calendar) {
+	var buf bytes.Buffer
+	if err := ical.Write(&buf, cal); err != nil {
+		http.Error(ctx.Writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	ctx.Writer.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	ctx.Writer.Write(buf.Bytes())
+}
+
+func icsError(ctx *router.Context, err error) {
+	if status.Code(err) == codes.NotFound {
+		http.Error(ctx.Writer, err.Error(), http.StatusNotFound)
+		return
+	}
+	http.Error(ctx.Writer, err.Error(), http.StatusInternalServerError)
+}
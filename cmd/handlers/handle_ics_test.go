@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/kylelemons/godebug/pretty"
+	rotang "github.com/miekg/rota"
+	"github.com/miekg/rota/pkg/ical"
+	"go.chromium.org/luci/server/router"
+)
+
+func TestHandleICS(t *testing.T) {
+	ctx := newTestContext()
+	ctxCancel, cancel := context.WithCancel(ctx)
+	cancel()
+
+	h := testSetup(t)
+	h.icsKey = []byte("secret")
+
+	rotaParams := httprouter.Params{
+		{Key: "name", Value: "Test Rota"},
+		{Key: "token", Value: h.icsToken(rotaICSID("Test Rota"))},
+	}
+	memberParams := httprouter.Params{
+		{Key: "email", Value: "oncaller1@oncall.com"},
+		{Key: "token", Value: h.icsToken("oncaller1@oncall.com")},
+	}
+
+	tests := []struct {
+		name    string
+		status  int
+		handler func(*State, *router.Context)
+		ctx     *router.Context
+		want    []ical.Event
+	}{{
+		name:    "Canceled context",
+		status:  http.StatusInternalServerError,
+		handler: (*State).HandleRotaICS,
+		ctx: &router.Context{
+			Context: ctxCancel,
+			Writer:  httptest.NewRecorder(),
+			Params:  rotaParams,
+		},
+	}, {
+		name:    "Rota feed",
+		status:  http.StatusOK,
+		handler: (*State).HandleRotaICS,
+		ctx: &router.Context{
+			Context: ctx,
+			Writer:  httptest.NewRecorder(),
+			Params:  rotaParams,
+		},
+		want: []ical.Event{
+			{
+				UID:         "20060402T000000Z-Test%20Rota-MTV%20All%20Day@rota",
+				Summary:     "Test Rota MTV All Day: oncaller1@oncall.com, oncaller2@oncall.com",
+				Description: "Test Rota MTV All Day, contact: ",
+				Start:       midnight,
+				End:         midnight.Add(fullDay),
+			}, {
+				UID:         "evt-2",
+				Summary:     "Test Rota MTV All Day: oncaller2@oncall.com",
+				Description: "Test Rota MTV All Day, contact: ",
+				Start:       midnight.Add(fullDay),
+				End:         midnight.Add(2 * fullDay),
+			},
+		},
+	}, {
+		name:    "Unknown rota",
+		status:  http.StatusNotFound,
+		handler: (*State).HandleRotaICS,
+		ctx: &router.Context{
+			Context: ctx,
+			Writer:  httptest.NewRecorder(),
+			Params: httprouter.Params{
+				{Key: "name", Value: "Unknown Rota"},
+				{Key: "token", Value: h.icsToken(rotaICSID("Unknown Rota"))},
+			},
+		},
+	}, {
+		name:    "Rota feed wrong token",
+		status:  http.StatusNotFound,
+		handler: (*State).HandleRotaICS,
+		ctx: &router.Context{
+			Context: ctx,
+			Writer:  httptest.NewRecorder(),
+			Params: httprouter.Params{
+				{Key: "name", Value: "Test Rota"},
+				{Key: "token", Value: h.icsToken("Test Rota")},
+			},
+		},
+	}, {
+		name:    "Member feed",
+		status:  http.StatusOK,
+		handler: (*State).HandleMemberICS,
+		ctx: &router.Context{
+			Context: ctx,
+			Writer:  httptest.NewRecorder(),
+			Params:  memberParams,
+		},
+		want: []ical.Event{
+			{
+				UID:         "20060402T000000Z-Test%20Rota-MTV%20All%20Day@rota",
+				Summary:     "Test Rota MTV All Day",
+				Description: "Test Rota MTV All Day, contact: oncaller1@oncall.com",
+				Start:       midnight,
+				End:         midnight.Add(fullDay),
+			},
+		},
+	}, {
+		name:    "Unknown member",
+		status:  http.StatusNotFound,
+		handler: (*State).HandleMemberICS,
+		ctx: &router.Context{
+			Context: ctx,
+			Writer:  httptest.NewRecorder(),
+			Params: httprouter.Params{
+				{Key: "email", Value: "unknown@oncall.com"},
+				{Key: "token", Value: h.icsToken("unknown@oncall.com")},
+			},
+		},
+	}, {
+		name:    "Member feed wrong token",
+		status:  http.StatusNotFound,
+		handler: (*State).HandleMemberICS,
+		ctx: &router.Context{
+			Context: ctx,
+			Writer:  httptest.NewRecorder(),
+			Params: httprouter.Params{
+				{Key: "email", Value: "oncaller1@oncall.com"},
+				{Key: "token", Value: h.icsToken("oncaller2@oncall.com")},
+			},
+		},
+	},
+	}
+
+	cfg := &rotang.Configuration{
+		Config: rotang.Config{
+			Name:        "Test Rota",
+			Description: "{{.RotaName}} {{.ShiftEntry.Name}}, contact: {{.Member.Email}}",
+			Shifts: rotang.ShiftConfig{
+				Shifts: []rotang.Shift{
+					{
+						Name:     "MTV All Day",
+						Duration: fullDay,
+					},
+				},
+			},
+		},
+		Members: []rotang.ShiftMember{
+			{
+				Email:     "oncaller1@oncall.com",
+				ShiftName: "MTV All Day",
+			}, {
+				Email:     "oncaller2@oncall.com",
+				ShiftName: "MTV All Day",
+			},
+		},
+	}
+	for _, m := range cfg.Members {
+		if err := h.memberStore(ctx).CreateMember(ctx, &rotang.Member{Email: m.Email}); err != nil {
+			t.Fatalf("CreateMember(ctx, _) failed: %v", err)
+		}
+		defer h.memberStore(ctx).DeleteMember(ctx, m.Email)
+	}
+	if err := h.configStore(ctx).CreateRotaConfig(ctx, cfg); err != nil {
+		t.Fatalf("CreateRotaConfig(ctx, _) failed: %v", err)
+	}
+	defer h.configStore(ctx).DeleteRotaConfig(ctx, cfg.Config.Name)
+	if err := h.shiftStore(ctx).AddShifts(ctx, cfg.Config.Name, []rotang.ShiftEntry{
+		{
+			Name:      "MTV All Day",
+			OnCall:    []rotang.ShiftMember{cfg.Members[0], cfg.Members[1]},
+			StartTime: midnight,
+			EndTime:   midnight.Add(fullDay),
+		}, {
+			Name:      "MTV All Day",
+			OnCall:    []rotang.ShiftMember{cfg.Members[1]},
+			StartTime: midnight.Add(fullDay),
+			EndTime:   midnight.Add(2 * fullDay),
+			EvtID:     "evt-2",
+		},
+	}); err != nil {
+		t.Fatalf("AddShifts(ctx, _) failed: %v", err)
+	}
+	defer h.shiftStore(ctx).DeleteAllShifts(ctx, cfg.Config.Name)
+
+	for _, tst := range tests {
+		t.Run(tst.name, func(t *testing.T) {
+			tst.handler(h, tst.ctx)
+
+			recorder := tst.ctx.Writer.(*httptest.ResponseRecorder)
+			if recorder.Code != tst.status {
+				t.Fatalf("%s: handler(ctx) = %d want: %d", tst.name, recorder.Code, tst.status)
+			}
+			if recorder.Code != http.StatusOK {
+				return
+			}
+			got, err := ical.Parse(recorder.Body, time.UTC)
+			if err != nil {
+				t.Fatalf("%s: ical.Parse(_) failed: %v", tst.name, err)
+			}
+			for i := range got {
+				got[i].Start, got[i].End = got[i].Start.UTC(), got[i].End.UTC()
+			}
+			if diff := pretty.Compare(tst.want, got); diff != "" {
+				t.Fatalf("%s: handler(ctx) differ -want +got, %s", tst.name, diff)
+			}
+		})
+	}
+}
@@ -7,10 +7,9 @@ package handlers
 import (
 	"bytes"
 	"encoding/json"
-	"chromium.googlesource.com/infra/rotang"
 	"net/http"
 
-	"go.chromium.org/luci/server/auth"
+	rotang "github.com/miekg/rota"
 	"go.chromium.org/luci/server/router"
 	"go.chromium.org/luci/server/templates"
 	"google.golang.org/grpc/codes"
@@ -29,7 +28,7 @@ func (h *State) HandleIndex(ctx *router.Context) {
 		Oncallers []rotang.ShiftMember
 	}{}
 
-	usr := auth.CurrentUser(ctx.Context)
+	usr := h.currentUser(ctx)
 	if usr == nil || usr.Email == "" {
 		templates.MustRender(ctx.Context, ctx.Writer, "pages/index.html", templates.Args{"Rotas": res})
 		return
@@ -10,8 +10,8 @@ import (
 	"net/http/httptest"
 	"testing"
 
-	"chromium.googlesource.com/infra/rotang"
-	"chromium.googlesource.com/infra/rotang/pkg/datastore"
+	rotang "github.com/miekg/rota"
+	"github.com/miekg/rota/pkg/storage/memory"
 	"go.chromium.org/luci/auth/identity"
 	"go.chromium.org/luci/server/auth"
 	"go.chromium.org/luci/server/auth/authtest"
@@ -19,15 +19,15 @@ import (
 	"go.chromium.org/luci/server/templates"
 )
 
-func setupStoreHandlers(o *Options, sf func(context.Context) *datastore.Store) {
+func setupStoreHandlers(o *Options, s *memory.Store) {
 	o.MemberStore = func(ctx context.Context) rotang.MemberStorer {
-		return sf(ctx)
+		return s
 	}
 	o.ShiftStore = func(ctx context.Context) rotang.ShiftStorer {
-		return sf(ctx)
+		return s
 	}
 	o.ConfigStore = func(ctx context.Context) rotang.ConfigStorer {
-		return sf(ctx)
+		return s
 	}
 }
 
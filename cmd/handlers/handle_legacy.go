@@ -4,13 +4,13 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"chromium.googlesource.com/infra/rotang"
 	"net/http"
 	"regexp"
 	"sort"
 	"strings"
 	"time"
 
+	rotang "github.com/miekg/rota"
 	"go.chromium.org/gae/service/memcache"
 	"go.chromium.org/luci/common/clock"
 	"go.chromium.org/luci/common/logging"
@@ -95,15 +95,10 @@ func (h *State) legacyTrooperByRotation(ctx *router.Context, file string) (strin
 		return "", err
 	}
 	for _, shift := range shifts {
-		members := shift.OnCall
-		if len(members) > 0 {
-			var secondaries []string
-			for _, secondary := range members[1:] {
-				secondaries = append(secondaries, secondary.Email)
-			}
-
+		if len(shift.OnCall) > 0 {
+			primary, secondaries := primarySecondaries(shift.OnCall)
 			if err := enc.Encode(&trooperJSON{
-				Primary:   members[0].Email,
+				Primary:   primary,
 				Secondary: secondaries,
 				UnixTS:    shift.StartTime.Unix(),
 			}); err != nil {
@@ -122,6 +117,26 @@ func (h *State) legacyTrooperByRotation(ctx *router.Context, file string) (strin
 	return buf.String(), nil
 }
 
+// primarySecondaries returns the primary and secondaries of a shift. Members are in role order
+// if the shift has no primary, shadows are not counted as secondaries.
+func primarySecondaries(members []rotang.ShiftMember) (string, []string) {
+	primary := 0
+	for i, m := range members {
+		if m.Role == rotang.Primary {
+			primary = i
+			break
+		}
+	}
+	var secondaries []string
+	for i, m := range members {
+		if i == primary || m.Role == rotang.Shadow {
+			continue
+		}
+		secondaries = append(secondaries, m.Email)
+	}
+	return members[primary].Email, secondaries
+}
+
 func (h *State) legacyTrooper(ctx *router.Context, file string) (string, error) {
 	updated := clock.Now(ctx.Context)
 	oc, err := h.legacyCalendar.TrooperOncall(ctx, trooperCal, matchSummary, updated)
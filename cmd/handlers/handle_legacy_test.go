@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"chromium.googlesource.com/infra/rotang"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -11,6 +10,7 @@ import (
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/kylelemons/godebug/pretty"
+	rotang "github.com/miekg/rota"
 	"go.chromium.org/luci/common/clock"
 	"go.chromium.org/luci/common/clock/testclock"
 	"go.chromium.org/luci/server/router"
@@ -470,6 +470,26 @@ func TestLegacyTroopersByRotation(t *testing.T) {
 		},
 		file: "chrome-ops-sre.json",
 		want: `{"primary":"primary@oncall.com","secondaries":["secondary1@oncall.com","secondary2@oncall.com"],"updated_unix_timestamp":1143936000}` + "\n",
+	}, {
+		name: "Success with roles",
+		calShifts: []rotang.ShiftEntry{
+			{
+				StartTime: midnight,
+				EndTime:   midnight.Add(5 * fullDay),
+				OnCall: []rotang.ShiftMember{
+					{Email: "shadow@oncall.com", Role: rotang.Shadow},
+					{Email: "secondary1@oncall.com", Role: rotang.Secondary},
+					{Email: "primary@oncall.com", Role: rotang.Primary},
+				},
+			},
+		},
+		ctx: &router.Context{
+			Context: ctx,
+			Writer:  httptest.NewRecorder(),
+			Request: httptest.NewRequest("GET", "/legacy", nil),
+		},
+		file: "chrome-ops-sre.json",
+		want: `{"primary":"primary@oncall.com","secondaries":["secondary1@oncall.com"],"updated_unix_timestamp":1143936000}` + "\n",
 	}, {
 		name:    "Calendar fail",
 		fail:    true,
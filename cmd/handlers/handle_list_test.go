@@ -6,11 +6,11 @@ package handlers
 
 import (
 	"context"
-	"chromium.googlesource.com/infra/rotang"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	rotang "github.com/miekg/rota"
 	"go.chromium.org/luci/server/router"
 	"go.chromium.org/luci/server/templates"
 )
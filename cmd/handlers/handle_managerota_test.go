@@ -1,12 +1,12 @@
 package handlers
 
 import (
-	"chromium.googlesource.com/infra/rotang"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"context"
+	rotang "github.com/miekg/rota"
 	"go.chromium.org/luci/auth/identity"
 	"go.chromium.org/luci/server/auth"
 	"go.chromium.org/luci/server/auth/authtest"
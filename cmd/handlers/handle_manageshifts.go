@@ -3,10 +3,10 @@ package handlers
 import (
 	"bytes"
 	"encoding/json"
-	"chromium.googlesource.com/infra/rotang"
 	"net/http"
 	"time"
 
+	rotang "github.com/miekg/rota"
 	"go.chromium.org/luci/common/clock"
 	"go.chromium.org/luci/common/logging"
 	"go.chromium.org/luci/server/router"
@@ -19,6 +19,17 @@ import (
 type RotaShifts struct {
 	Rota        string
 	SplitShifts []SplitShifts
+	// Traces explain the members picked for generated shifts.
+	Traces []ShiftTrace `json:",omitempty"`
+	// Warnings lists problems with generated shifts, eg. understaffed shifts.
+	Warnings []string `json:",omitempty"`
+}
+
+// ShiftTrace holds the decisions the Generator made for one shift.
+type ShiftTrace struct {
+	Name      string
+	StartTime time.Time
+	Decisions []rotang.Decision
 }
 
 // SplitShifts mirrors the `shiftcurrent-element` SplitShifts structure.
@@ -57,7 +68,7 @@ func (h *State) manageShiftsGET(ctx *router.Context, t time.Time) (templates.Arg
 		return nil, err
 	}
 
-	if !adminOrOwner(ctx, cfg) {
+	if !h.adminOrOwner(ctx, cfg) {
 		return nil, status.Errorf(codes.Unauthenticated, "not admin or owner")
 	}
 
@@ -207,7 +218,7 @@ func (h *State) shiftSetup(ctx *router.Context) (*rotang.Configuration, *RotaShi
 
 	rota := rotas[0]
 
-	if !adminOrOwner(ctx, rota) {
+	if !h.adminOrOwner(ctx, rota) {
 		http.Error(ctx.Writer, "not owner or admin of rotation", http.StatusForbidden)
 		return nil, nil, status.Errorf(codes.Unauthenticated, "not owner of rotation or admin")
 	}
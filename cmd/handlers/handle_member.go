@@ -4,12 +4,12 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"chromium.googlesource.com/infra/rotang"
 	"net/http"
+	"net/url"
 
+	rotang "github.com/miekg/rota"
 	"go.chromium.org/luci/common/clock"
 	"go.chromium.org/luci/common/logging"
-	"go.chromium.org/luci/server/auth"
 	"go.chromium.org/luci/server/router"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -20,6 +20,8 @@ import (
 type MemberInfo struct {
 	Member rotang.Member
 	Shifts []RotaShift
+	// ICS is the path of the iCalendar feed of the member.
+	ICS string `json:",omitempty"`
 }
 
 // RotaShift contains a rota name and relevant shift
@@ -36,14 +38,9 @@ func (h *State) HandleMember(ctx *router.Context) {
 		return
 	}
 
-	usr := auth.CurrentUser(ctx.Context)
+	usr := h.currentUser(ctx)
 	if usr == nil || usr.Email == "" {
-		url, err := auth.LoginURL(ctx.Context, ctx.Params.ByName("path"))
-		if err != nil {
-			http.Error(ctx.Writer, "not logged in", http.StatusForbidden)
-			return
-		}
-		http.Redirect(ctx.Writer, ctx.Request, url, http.StatusFound)
+		h.authenticator.Login(ctx)
 		return
 	}
 
@@ -80,12 +77,45 @@ func (h *State) memberPOST(ctx *router.Context, member *rotang.Member) error {
 		return status.Errorf(codes.PermissionDenied, "only changes to your own user allowed")
 	}
 
+	// Synced entries are owned by JobOOOImport, keep the stored ones.
+	var ooo []rotang.OOO
+	for _, o := range res.OOO {
+		if !o.Synced {
+			ooo = append(ooo, o)
+		}
+	}
+	for _, o := range member.OOO {
+		if o.Synced {
+			ooo = append(ooo, o)
+		}
+	}
+	res.OOO = ooo
+
 	for i := range res.OOO {
+		if res.OOO[i].Synced {
+			continue
+		}
 		if res.OOO[i].Comment == "" {
 			return status.Errorf(codes.InvalidArgument, "comment needs to be set")
 		}
+		switch res.OOO[i].Type {
+		case "", rotang.Vacation, rotang.Sick, rotang.Conference:
+		default:
+			return status.Errorf(codes.InvalidArgument, "unknown OOO type: %q", res.OOO[i].Type)
+		}
 		logging.Infof(ctx.Context, "res.OOO[i}", res.OOO[i])
 	}
+	for _, u := range res.Unavailable {
+		if u.From < 0 || u.From >= fullDay || u.To < 0 || u.To > fullDay || (u.To > 0 && u.To <= u.From) {
+			return status.Errorf(codes.InvalidArgument, "invalid unavailable period: %v - %v", u.From, u.To)
+		}
+	}
+	if res.Calendar != "" {
+		u, err := url.Parse(res.Calendar)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return status.Errorf(codes.InvalidArgument, "calendar needs to be a http(s) URL: %q", res.Calendar)
+		}
+	}
 	return h.memberStore(ctx.Context).UpdateMember(ctx.Context, &res)
 }
 
@@ -103,6 +133,7 @@ func (h *State) memberGET(ctx *router.Context, member *rotang.Member) error {
 	shiftStore := h.shiftStore(ctx.Context)
 	res := MemberInfo{
 		Member: *member,
+		ICS:    h.memberICSPath(member.Email),
 	}
 	for _, r := range rotas {
 		shifts, err := shiftStore.AllShifts(ctx.Context, r)
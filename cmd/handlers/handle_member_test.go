@@ -4,13 +4,13 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"chromium.googlesource.com/infra/rotang"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/kylelemons/godebug/pretty"
+	rotang "github.com/miekg/rota"
 	"go.chromium.org/luci/auth/identity"
 	"go.chromium.org/luci/common/clock"
 	"go.chromium.org/luci/common/clock/testclock"
@@ -433,6 +433,85 @@ func TestMemberPOST(t *testing.T) {
 				Email: "test@user.com",
 			},
 		},
+	}, {
+		name: "Synced entries kept",
+		ctx: &router.Context{
+			Context: ctx,
+			Request: httptest.NewRequest("POST", "/memberjson", bytes.NewBufferString(`
+				{ "full_name":"Test Testson",
+					"email_address":"test@user.com",
+					"TZ":{},
+					"OOO":[{
+						"Start":"2018-11-06T08:00:00Z",
+						"Duration":259200000000000,
+						"Comment":"Off to the circus"
+					}, {
+						"Start":"2018-11-06T08:00:00Z",
+						"Duration":3600000000000,
+						"Synced":true
+					}],
+					"Preferences":null,
+					"Calendar":"https://calendar.example.com/test.ics"
+				}`)),
+		},
+		member: &rotang.Member{
+			Name:  "Test Testson",
+			Email: "test@user.com",
+			OOO: []rotang.OOO{{
+				Start:    midnight,
+				Duration: time.Hour * 72,
+				Comment:  "Vacation",
+				Synced:   true,
+			},
+			},
+		},
+		want: &rotang.Member{
+			Name:  "Test Testson",
+			Email: "test@user.com",
+			TZ:    *time.UTC,
+			OOO: []rotang.OOO{{
+				Start:    testTime,
+				Duration: time.Hour * 72,
+				Comment:  "Off to the circus",
+			}, {
+				Start:    midnight,
+				Duration: time.Hour * 72,
+				Comment:  "Vacation",
+				Synced:   true,
+			},
+			},
+			Calendar: "https://calendar.example.com/test.ics",
+		},
+		memberPool: []rotang.Member{
+			{
+				Name:  "Test Testson",
+				Email: "test@user.com",
+			},
+		},
+	}, {
+		name: "Calendar not a URL",
+		fail: true,
+		ctx: &router.Context{
+			Context: ctx,
+			Request: httptest.NewRequest("POST", "/memberjson", bytes.NewBufferString(`
+				{ "full_name":"Test Testson",
+					"email_address":"test@user.com",
+					"TZ":{},
+					"OOO":null,
+					"Preferences":null,
+					"Calendar":"/etc/passwd"
+				}`)),
+		},
+		member: &rotang.Member{
+			Name:  "Test Testson",
+			Email: "test@user.com",
+		},
+		memberPool: []rotang.Member{
+			{
+				Name:  "Test Testson",
+				Email: "test@user.com",
+			},
+		},
 	}, {
 		name: "Changing other member",
 		fail: true,
@@ -503,6 +582,62 @@ func TestMemberPOST(t *testing.T) {
 				Email: "test@user.com",
 			},
 		},
+	}, {
+		name: "Unknown OOO type",
+		fail: true,
+		ctx: &router.Context{
+			Context: ctx,
+			Request: httptest.NewRequest("POST", "/memberjson", bytes.NewBufferString(`
+				{ "full_name":"Test Testson",
+					"email_address":"test@user.com",
+					"TZ":{},
+					"OOO":[{
+						"Start":"2018-11-06T08:00:00Z",
+						"Duration":259200000000000,
+						"Comment":"Off to the circus",
+						"Type":"circus"
+					}],
+					"Preferences":null
+				}`)),
+		},
+		member: &rotang.Member{
+			Name:  "Test Testson",
+			Email: "test@user.com",
+		},
+		memberPool: []rotang.Member{
+			{
+				Name:  "Test Testson",
+				Email: "test@user.com",
+			},
+		},
+	}, {
+		name: "Unavailable period ends before it starts",
+		fail: true,
+		ctx: &router.Context{
+			Context: ctx,
+			Request: httptest.NewRequest("POST", "/memberjson", bytes.NewBufferString(`
+				{ "full_name":"Test Testson",
+					"email_address":"test@user.com",
+					"TZ":{},
+					"OOO":[{
+						"Start":"2018-11-06T08:00:00Z",
+						"Duration":259200000000000,
+						"Comment":"Off to the circus"
+					}],
+					"Unavailable":[{"From":50400000000000,"To":3600000000000}],
+					"Preferences":null
+				}`)),
+		},
+		member: &rotang.Member{
+			Name:  "Test Testson",
+			Email: "test@user.com",
+		},
+		memberPool: []rotang.Member{
+			{
+				Name:  "Test Testson",
+				Email: "test@user.com",
+			},
+		},
 	}, {
 		name: "Comment missing",
 		fail: true,
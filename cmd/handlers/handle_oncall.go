@@ -4,13 +4,12 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"chromium.googlesource.com/infra/rotang"
 	"net/http"
 	"time"
 
+	rotang "github.com/miekg/rota"
 	"go.chromium.org/luci/common/clock"
 	"go.chromium.org/luci/common/logging"
-	"go.chromium.org/luci/server/auth"
 	"go.chromium.org/luci/server/router"
 	"go.chromium.org/luci/server/templates"
 	"google.golang.org/grpc/codes"
@@ -19,7 +18,7 @@ import (
 
 // HandleOncall handles the current oncall page.
 func (h *State) HandleOncall(ctx *router.Context) {
-	usr := auth.CurrentUser(ctx.Context)
+	usr := h.currentUser(ctx)
 	if usr == nil || usr.Email == "" {
 		http.Error(ctx.Writer, "not logged in", http.StatusForbidden)
 		return
@@ -3,10 +3,11 @@ package handlers
 import (
 	"bytes"
 	"encoding/json"
-	"chromium.googlesource.com/infra/rotang"
 	"net/http"
+	"net/url"
 	"time"
 
+	rotang "github.com/miekg/rota"
 	"go.chromium.org/luci/server/router"
 	"go.chromium.org/luci/server/templates"
 	"golang.org/x/net/context"
@@ -20,6 +21,7 @@ type jsonMember struct {
 	Name  string
 	Email string
 	TZ    string
+	Tags  []string
 }
 
 type jsonRota struct {
@@ -78,7 +80,7 @@ func (h *State) validateConfig(ctx *router.Context, jr *jsonRota) error {
 		return err
 	}
 
-	if !adminOrOwner(ctx, &jr.Cfg) {
+	if !h.adminOrOwner(ctx, &jr.Cfg) {
 		return status.Errorf(codes.PermissionDenied, "not admin or owner")
 	}
 
@@ -95,6 +97,19 @@ func (h *State) validateConfig(ctx *router.Context, jr *jsonRota) error {
 	if dur, ok := checkShiftDuration(&jr.Cfg); !ok {
 		return status.Errorf(codes.InvalidArgument, "shift durations does not add up to 24h,got %v", dur)
 	}
+	for _, r := range jr.Cfg.Config.Shifts.Roles {
+		switch r {
+		case rotang.Primary, rotang.Secondary, rotang.Shadow:
+		default:
+			return status.Errorf(codes.InvalidArgument, "unknown role: %q", r)
+		}
+	}
+	if jr.Cfg.Config.CalDAV != "" {
+		u, err := url.Parse(jr.Cfg.Config.CalDAV)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return status.Errorf(codes.InvalidArgument, "CalDAV needs to be a http(s) URL: %q", jr.Cfg.Config.CalDAV)
+		}
+	}
 	return nil
 }
 
@@ -141,6 +156,7 @@ func convertMembers(jm []jsonMember) ([]rotang.Member, error) {
 			Name:  m.Name,
 			Email: m.Email,
 			TZ:    *tz,
+			Tags:  m.Tags,
 		})
 	}
 	return res, nil
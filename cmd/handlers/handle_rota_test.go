@@ -3,7 +3,6 @@ package handlers
 import (
 	"bytes"
 	"encoding/json"
-	"chromium.googlesource.com/infra/rotang"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -12,6 +11,7 @@ import (
 	"context"
 
 	"github.com/kylelemons/godebug/pretty"
+	rotang "github.com/miekg/rota"
 	"go.chromium.org/luci/auth/identity"
 	"go.chromium.org/luci/server/auth"
 	"go.chromium.org/luci/server/auth/authtest"
@@ -186,6 +186,7 @@ func TestHandleRotaCreatePOST(t *testing.T) {
 					},
 					Shifts: rotang.ShiftConfig{
 						Generator: "Fair",
+						TZ:        *mtvTime,
 						Shifts: []rotang.Shift{
 							{
 								Name:     "MTV All Day",
@@ -228,6 +229,7 @@ func TestHandleRotaCreatePOST(t *testing.T) {
 				},
 				Shifts: rotang.ShiftConfig{
 					Generator: "Fair",
+					TZ:        *mtvTime,
 					Shifts: []rotang.Shift{
 						{
 							Name:     "MTV All Day",
@@ -458,6 +460,50 @@ func TestHandleRotaCreatePOST(t *testing.T) {
 				},
 			},
 		},
+	}, {
+		name: "Invalid CalDAV URL",
+		fail: true,
+		user: "test@user.com",
+		ctx: &router.Context{
+			Context: ctx,
+			Writer:  httptest.NewRecorder(),
+		},
+		rota: jsonRota{
+			Cfg: rotang.Configuration{
+				Config: rotang.Config{
+					Name:        "Test Rotation",
+					Owners:      []string{"test@user.com"},
+					Description: "Describe the rotation",
+					CalDAV:      "cal@cal",
+					Email: rotang.Email{
+						Subject: "You're on call!",
+						Body:    "Darn",
+					},
+					Shifts: rotang.ShiftConfig{
+						Generator: "Fair",
+						Shifts: []rotang.Shift{
+							{
+								Name:     "MTV All Day",
+								Duration: fullDay,
+							},
+						},
+					},
+				},
+				Members: []rotang.ShiftMember{
+					{
+						Email:     "test1@test.com",
+						ShiftName: "MTV All Day",
+					},
+				},
+			},
+			Members: []jsonMember{
+				{
+					Name:  "First Test",
+					Email: "test1@test.com",
+					TZ:    "America/Los_Angeles",
+				},
+			},
+		},
 	},
 	}
 
@@ -560,6 +606,7 @@ func TestHandleRotaModify(t *testing.T) {
 					},
 					Shifts: rotang.ShiftConfig{
 						Generator: "Fair",
+						TZ:        *mtvTime,
 						Shifts: []rotang.Shift{
 							{
 								Name:     "MTV All Day",
@@ -602,6 +649,7 @@ func TestHandleRotaModify(t *testing.T) {
 				},
 				Shifts: rotang.ShiftConfig{
 					Generator: "Fair",
+					TZ:        *mtvTime,
 					Shifts: []rotang.Shift{
 						{
 							Name:     "MTV All Day",
@@ -670,6 +718,7 @@ func TestHandleRotaModify(t *testing.T) {
 					},
 					Shifts: rotang.ShiftConfig{
 						Generator: "Fair",
+						TZ:        *mtvTime,
 						Shifts: []rotang.Shift{
 							{
 								Name:     "MTV All Day",
@@ -712,6 +761,7 @@ func TestHandleRotaModify(t *testing.T) {
 				},
 				Shifts: rotang.ShiftConfig{
 					Generator: "Fair",
+					TZ:        *mtvTime,
 					Shifts: []rotang.Shift{
 						{
 							Name:     "MTV All Day",
@@ -764,6 +814,7 @@ func TestHandleRotaModify(t *testing.T) {
 					},
 					Shifts: rotang.ShiftConfig{
 						Generator: "Fair",
+						TZ:        *mtvTime,
 						Shifts: []rotang.Shift{
 							{
 								Name:     "MTV All Day",
@@ -806,6 +857,7 @@ func TestHandleRotaModify(t *testing.T) {
 				},
 				Shifts: rotang.ShiftConfig{
 					Generator: "Fair",
+					TZ:        *mtvTime,
 					Shifts: []rotang.Shift{
 						{
 							Name:     "MTV All Day",
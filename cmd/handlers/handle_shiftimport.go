@@ -3,11 +3,11 @@ package handlers
 import (
 	"bytes"
 	"encoding/json"
-	"chromium.googlesource.com/infra/rotang"
 	"io"
 	"net/http"
 	"time"
 
+	rotang "github.com/miekg/rota"
 	"go.chromium.org/luci/common/logging"
 	"go.chromium.org/luci/server/router"
 	"google.golang.org/grpc/codes"
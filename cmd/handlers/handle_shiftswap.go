@@ -2,10 +2,10 @@ package handlers
 
 import (
 	"encoding/json"
-	"chromium.googlesource.com/infra/rotang"
 	"net/http"
+	"strings"
 
-	"go.chromium.org/luci/server/auth"
+	rotang "github.com/miekg/rota"
 	"go.chromium.org/luci/server/router"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -19,14 +19,14 @@ func (h *State) HandleShiftSwap(ctx *router.Context) {
 		http.Error(ctx.Writer, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	usr := auth.CurrentUser(ctx.Context)
+	usr := h.currentUser(ctx)
 	if usr == nil || usr.Email == "" {
 		http.Error(ctx.Writer, "login required", http.StatusForbidden)
 		return
 	}
 	var member *rotang.ShiftMember
 	for _, m := range cfg.Members {
-		if usr.Email == m.Email {
+		if strings.EqualFold(usr.Email, m.Email) {
 			member = &m
 			break
 		}
@@ -118,15 +118,16 @@ func shiftUserDiff(original, update *rotang.ShiftEntry, user rotang.ShiftMember)
 	}
 
 	cmpMember := func(o, u rotang.ShiftMember) bool {
-		if o.Email != u.Email || o.ShiftName != u.ShiftName {
+		if o.Email != u.Email || o.ShiftName != u.ShiftName ||
+			o.Role != u.Role || o.Mentor != u.Mentor {
 			return false
 		}
 		return true
 	}
 
 	// Possible changes.
-	// Same length - One entry set to user.
-	// Original shorter - Added entry should be user.
+	// Same length - One entry set to user, keeping the role of the entry.
+	// Original shorter - Added entry should be user, without a role.
 	// Update shorter - Removed entry should be user.
 	switch {
 	case len(original.OnCall) == len(update.OnCall):
@@ -134,9 +135,13 @@ func shiftUserDiff(original, update *rotang.ShiftEntry, user rotang.ShiftMember)
 		for i, o := range original.OnCall {
 			if !cmpMember(o, update.OnCall[i]) {
 				if update.OnCall[i].Email == user.Email &&
-					update.OnCall[i].ShiftName == user.ShiftName {
+					update.OnCall[i].ShiftName == user.ShiftName &&
+					update.OnCall[i].Role == o.Role &&
+					update.OnCall[i].Mentor == o.Mentor {
 					changes++
+					continue
 				}
+				return false
 			}
 		}
 		if changes != 1 {
@@ -146,8 +151,9 @@ func shiftUserDiff(original, update *rotang.ShiftEntry, user rotang.ShiftMember)
 		if len(update.OnCall)-len(original.OnCall) != 1 {
 			return false
 		}
-		if !(update.OnCall[len(update.OnCall)-1].Email == user.Email &&
-			update.OnCall[len(update.OnCall)-1].ShiftName == user.ShiftName) {
+		added := update.OnCall[len(update.OnCall)-1]
+		if !(added.Email == user.Email && added.ShiftName == user.ShiftName &&
+			added.Role == "" && added.Mentor == "") {
 			return false
 		}
 		for i, o := range original.OnCall {
@@ -2,11 +2,11 @@ package handlers
 
 import (
 	"context"
-	"chromium.googlesource.com/infra/rotang"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	rotang "github.com/miekg/rota"
 	"go.chromium.org/luci/auth/identity"
 	"go.chromium.org/luci/server/auth"
 	"go.chromium.org/luci/server/auth/authtest"
@@ -79,6 +79,32 @@ func TestHandleShiftSwap(t *testing.T) {
 				Rota: "Test Rota",
 			})),
 		},
+	}, {
+		name:  "Member e-mail in other case",
+		email: "Test@Member.com",
+		memberPool: []rotang.Member{
+			{
+				Name:  "Test User",
+				Email: "test@member.com",
+			},
+		},
+		cfg: &rotang.Configuration{
+			Config: rotang.Config{
+				Name: "Test Rota",
+			},
+			Members: []rotang.ShiftMember{
+				{
+					Email: "test@member.com",
+				},
+			},
+		},
+		ctx: &router.Context{
+			Context: ctx,
+			Writer:  httptest.NewRecorder(),
+			Request: httptest.NewRequest("POST", "/shiftswap", buildBody(t, &RotaShifts{
+				Rota: "Test Rota",
+			})),
+		},
 	},
 	}
 
@@ -943,6 +969,162 @@ func TestShiftUserDiff(t *testing.T) {
 			Email:     "test@test.com",
 			ShiftName: "MTV All Day",
 		},
+	}, {
+		name: "Role kept",
+		want: true,
+		original: &rotang.ShiftEntry{
+			Name:      "MTV All Day",
+			StartTime: midnight,
+			EndTime:   midnight.Add(5 * fullDay),
+			OnCall: []rotang.ShiftMember{
+				{
+					Email:     "notChanged1@test.com",
+					ShiftName: "MTV All Day",
+					Role:      rotang.Primary,
+				}, {
+					Email:     "toBeChanged@test.com",
+					ShiftName: "MTV All Day",
+					Role:      rotang.Secondary,
+				},
+			},
+		},
+		update: &rotang.ShiftEntry{
+			Name:      "MTV All Day",
+			StartTime: midnight,
+			EndTime:   midnight.Add(5 * fullDay),
+			Comment:   "Swapping shifts",
+			OnCall: []rotang.ShiftMember{
+				{
+					Email:     "notChanged1@test.com",
+					ShiftName: "MTV All Day",
+					Role:      rotang.Primary,
+				}, {
+					Email:     "test@test.com",
+					ShiftName: "MTV All Day",
+					Role:      rotang.Secondary,
+				},
+			},
+		},
+		user: rotang.ShiftMember{
+			Email:     "test@test.com",
+			ShiftName: "MTV All Day",
+		},
+	}, {
+		name: "Role taken",
+		want: false,
+		original: &rotang.ShiftEntry{
+			Name:      "MTV All Day",
+			StartTime: midnight,
+			EndTime:   midnight.Add(5 * fullDay),
+			OnCall: []rotang.ShiftMember{
+				{
+					Email:     "notChanged1@test.com",
+					ShiftName: "MTV All Day",
+					Role:      rotang.Primary,
+				}, {
+					Email:     "toBeChanged@test.com",
+					ShiftName: "MTV All Day",
+					Role:      rotang.Secondary,
+				},
+			},
+		},
+		update: &rotang.ShiftEntry{
+			Name:      "MTV All Day",
+			StartTime: midnight,
+			EndTime:   midnight.Add(5 * fullDay),
+			Comment:   "Swapping shifts",
+			OnCall: []rotang.ShiftMember{
+				{
+					Email:     "notChanged1@test.com",
+					ShiftName: "MTV All Day",
+					Role:      rotang.Primary,
+				}, {
+					Email:     "test@test.com",
+					ShiftName: "MTV All Day",
+					Role:      rotang.Primary,
+				},
+			},
+		},
+		user: rotang.ShiftMember{
+			Email:     "test@test.com",
+			ShiftName: "MTV All Day",
+		},
+	}, {
+		name: "Other role changed",
+		want: false,
+		original: &rotang.ShiftEntry{
+			Name:      "MTV All Day",
+			StartTime: midnight,
+			EndTime:   midnight.Add(5 * fullDay),
+			OnCall: []rotang.ShiftMember{
+				{
+					Email:     "notChanged1@test.com",
+					ShiftName: "MTV All Day",
+					Role:      rotang.Primary,
+				}, {
+					Email:     "toBeChanged@test.com",
+					ShiftName: "MTV All Day",
+					Role:      rotang.Secondary,
+				},
+			},
+		},
+		update: &rotang.ShiftEntry{
+			Name:      "MTV All Day",
+			StartTime: midnight,
+			EndTime:   midnight.Add(5 * fullDay),
+			Comment:   "Swapping shifts",
+			OnCall: []rotang.ShiftMember{
+				{
+					Email:     "notChanged1@test.com",
+					ShiftName: "MTV All Day",
+					Role:      rotang.Secondary,
+				}, {
+					Email:     "test@test.com",
+					ShiftName: "MTV All Day",
+					Role:      rotang.Secondary,
+				},
+			},
+		},
+		user: rotang.ShiftMember{
+			Email:     "test@test.com",
+			ShiftName: "MTV All Day",
+		},
+	}, {
+		name: "Member added with role",
+		want: false,
+		original: &rotang.ShiftEntry{
+			Name:      "MTV All Day",
+			StartTime: midnight,
+			EndTime:   midnight.Add(5 * fullDay),
+			OnCall: []rotang.ShiftMember{
+				{
+					Email:     "notChanged1@test.com",
+					ShiftName: "MTV All Day",
+					Role:      rotang.Primary,
+				},
+			},
+		},
+		update: &rotang.ShiftEntry{
+			Name:      "MTV All Day",
+			StartTime: midnight,
+			EndTime:   midnight.Add(5 * fullDay),
+			Comment:   "Swapping shifts",
+			OnCall: []rotang.ShiftMember{
+				{
+					Email:     "notChanged1@test.com",
+					ShiftName: "MTV All Day",
+					Role:      rotang.Primary,
+				}, {
+					Email:     "test@test.com",
+					ShiftName: "MTV All Day",
+					Role:      rotang.Primary,
+				},
+			},
+		},
+		user: rotang.ShiftMember{
+			Email:     "test@test.com",
+			ShiftName: "MTV All Day",
+		},
 	},
 	}
 
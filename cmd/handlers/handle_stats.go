@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	rotang "github.com/miekg/rota"
+	"github.com/miekg/rota/pkg/algo"
+	"go.chromium.org/luci/common/logging"
+	"go.chromium.org/luci/server/router"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RotaStats is the JSON returned by HandleStats.
+type RotaStats struct {
+	Rota    string
+	From    time.Time
+	To      time.Time
+	Members []algo.MemberStats
+}
+
+// HandleStats returns the oncall load of the members of a rotation as JSON.
+// The time window is set with the `from` and `to` dates, both inclusive, in the rotation
+// time zone. Without them the window covers all shifts of the rotation.
+func (h *State) HandleStats(ctx *router.Context) {
+	if err := ctx.Context.Err(); err != nil {
+		http.Error(ctx.Writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if usr := h.currentUser(ctx); usr == nil || usr.Email == "" {
+		http.Error(ctx.Writer, "not logged in", http.StatusForbidden)
+		return
+	}
+
+	rotaName := ctx.Request.FormValue("name")
+	if rotaName == "" {
+		http.Error(ctx.Writer, "no rota provided", http.StatusBadRequest)
+		return
+	}
+	rotas, err := h.configStore(ctx.Context).RotaConfig(ctx.Context, rotaName)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			http.Error(ctx.Writer, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(ctx.Writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(rotas) != 1 {
+		http.Error(ctx.Writer, "unexpected number of rotations returned", http.StatusInternalServerError)
+		return
+	}
+	rota := rotas[0]
+
+	shifts, err := h.shiftStore(ctx.Context).AllShifts(ctx.Context, rota.Config.Name)
+	if err != nil && status.Code(err) != codes.NotFound {
+		http.Error(ctx.Writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var from, to time.Time
+	for _, s := range shifts {
+		if from.IsZero() || s.StartTime.Before(from) {
+			from = s.StartTime
+		}
+		if s.EndTime.After(to) {
+			to = s.EndTime
+		}
+	}
+	loc := rota.Config.Shifts.Location()
+	if v := ctx.Request.FormValue("from"); v != "" {
+		if from, err = time.ParseInLocation(elementTimeFormat, v, loc); err != nil {
+			http.Error(ctx.Writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if v := ctx.Request.FormValue("to"); v != "" {
+		if to, err = time.ParseInLocation(elementTimeFormat, v, loc); err != nil {
+			http.Error(ctx.Writer, err.Error(), http.StatusBadRequest)
+			return
+		}
+		to = to.AddDate(0, 0, 1)
+	}
+
+	memberStore := h.memberStore(ctx.Context)
+	var members []rotang.Member
+	for _, m := range rota.Members {
+		m, err := memberStore.Member(ctx.Context, m.Email)
+		if err != nil {
+			http.Error(ctx.Writer, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		members = append(members, *m)
+	}
+
+	stats, err := algo.Stats(rota, members, shifts, from, to)
+	if err != nil {
+		// The error can contain parts of the holiday file, keep it out of the response.
+		logging.Errorf(ctx.Context, "algo.Stats(_) for rota: %q failed: %v", rota.Config.Name, err)
+		http.Error(ctx.Writer, "calculating the statistics failed", http.StatusInternalServerError)
+		return
+	}
+
+	var resBuf bytes.Buffer
+	if err := json.NewEncoder(&resBuf).Encode(&RotaStats{
+		Rota:    rota.Config.Name,
+		From:    from,
+		To:      to,
+		Members: stats,
+	}); err != nil {
+		http.Error(ctx.Writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	io.Copy(ctx.Writer, &resBuf)
+}
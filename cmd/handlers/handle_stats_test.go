@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+	rotang "github.com/miekg/rota"
+	"github.com/miekg/rota/pkg/algo"
+	"go.chromium.org/luci/auth/identity"
+	"go.chromium.org/luci/server/auth"
+	"go.chromium.org/luci/server/auth/authtest"
+	"go.chromium.org/luci/server/router"
+)
+
+func TestHandleStats(t *testing.T) {
+	ctx := newTestContext()
+	ctxCancel, cancel := context.WithCancel(ctx)
+	cancel()
+
+	cfg := &rotang.Configuration{
+		Config: rotang.Config{
+			Name: "Test Rota",
+			Shifts: rotang.ShiftConfig{
+				TZ:     *time.UTC,
+				Length: 1,
+				Shifts: []rotang.Shift{
+					{
+						Name:     "MTV All Day",
+						Duration: fullDay,
+					},
+				},
+			},
+		},
+		Members: []rotang.ShiftMember{
+			{
+				ShiftName: "MTV All Day",
+				Email:     "mtv1@oncall.com",
+			}, {
+				ShiftName: "MTV All Day",
+				Email:     "mtv2@oncall.com",
+			},
+		},
+	}
+	memberPool := []rotang.Member{
+		{
+			Email: "mtv1@oncall.com",
+		}, {
+			Email: "mtv2@oncall.com",
+		},
+	}
+	// midnight is a Sunday.
+	var shifts []rotang.ShiftEntry
+	for i, email := range []string{"mtv1@oncall.com", "mtv2@oncall.com", "mtv1@oncall.com", "mtv1@oncall.com"} {
+		shifts = append(shifts, rotang.ShiftEntry{
+			Name:      "MTV All Day",
+			StartTime: midnight.Add(fullDay * time.Duration(i)),
+			EndTime:   midnight.Add(fullDay * time.Duration(i+1)),
+			OnCall: []rotang.ShiftMember{
+				{
+					ShiftName: "MTV All Day",
+					Email:     email,
+				},
+			},
+		})
+	}
+
+	tests := []struct {
+		name   string
+		fail   bool
+		user   string
+		ctx    context.Context
+		values url.Values
+		want   []algo.MemberStats
+	}{{
+		name:   "Canceled context",
+		fail:   true,
+		user:   "test@testing.com",
+		ctx:    ctxCancel,
+		values: url.Values{"name": {"Test Rota"}},
+	}, {
+		name:   "Not logged in",
+		fail:   true,
+		ctx:    ctx,
+		values: url.Values{"name": {"Test Rota"}},
+	}, {
+		name: "No rota",
+		fail: true,
+		user: "test@testing.com",
+		ctx:  ctx,
+	}, {
+		name:   "Unknown rota",
+		fail:   true,
+		user:   "test@testing.com",
+		ctx:    ctx,
+		values: url.Values{"name": {"Unknown Rota"}},
+	}, {
+		name: "Bad window",
+		fail: true,
+		user: "test@testing.com",
+		ctx:  ctx,
+		values: url.Values{
+			"name": {"Test Rota"},
+			"from": {"yesterday"},
+		},
+	}, {
+		name:   "All shifts",
+		user:   "test@testing.com",
+		ctx:    ctx,
+		values: url.Values{"name": {"Test Rota"}},
+		want: []algo.MemberStats{
+			{
+				Email:        "mtv1@oncall.com",
+				Shifts:       3,
+				Hours:        72,
+				WeekendHours: 24,
+				NightHours:   27,
+				LongestGap:   fullDay,
+			}, {
+				Email:      "mtv2@oncall.com",
+				Shifts:     1,
+				Hours:      24,
+				NightHours: 9,
+			},
+		},
+	}, {
+		name: "Window",
+		user: "test@testing.com",
+		ctx:  ctx,
+		values: url.Values{
+			"name": {"Test Rota"},
+			"from": {"2006-04-03"},
+			"to":   {"2006-04-04"},
+		},
+		want: []algo.MemberStats{
+			{
+				Email:      "mtv1@oncall.com",
+				Shifts:     1,
+				Hours:      24,
+				NightHours: 9,
+			}, {
+				Email:      "mtv2@oncall.com",
+				Shifts:     1,
+				Hours:      24,
+				NightHours: 9,
+			},
+		},
+	},
+	}
+
+	h := testSetup(t)
+	for _, m := range memberPool {
+		if err := h.memberStore(ctx).CreateMember(ctx, &m); err != nil {
+			t.Fatalf("CreateMember(ctx, _) failed: %v", err)
+		}
+		defer h.memberStore(ctx).DeleteMember(ctx, m.Email)
+	}
+	if err := h.configStore(ctx).CreateRotaConfig(ctx, cfg); err != nil {
+		t.Fatalf("CreateRotaConfig(ctx, _) failed: %v", err)
+	}
+	defer h.configStore(ctx).DeleteRotaConfig(ctx, cfg.Config.Name)
+	if err := h.shiftStore(ctx).AddShifts(ctx, cfg.Config.Name, shifts); err != nil {
+		t.Fatalf("AddShifts(ctx, %q, _) failed: %v", cfg.Config.Name, err)
+	}
+	defer h.shiftStore(ctx).DeleteAllShifts(ctx, cfg.Config.Name)
+
+	for _, tst := range tests {
+		recorder := httptest.NewRecorder()
+		rctx := &router.Context{
+			Context: tst.ctx,
+			Writer:  recorder,
+			Request: httptest.NewRequest("GET", "/stats", nil),
+		}
+		if tst.user != "" {
+			rctx.Context = auth.WithState(rctx.Context, &authtest.FakeState{
+				Identity: identity.Identity("user:" + tst.user),
+			})
+		}
+		rctx.Request.Form = tst.values
+
+		h.HandleStats(rctx)
+		if got, want := (recorder.Code != http.StatusOK), tst.fail; got != want {
+			t.Errorf("%s: HandleStats(ctx) = %t want: %t, res: %v", tst.name, got, want, recorder.Body)
+			continue
+		}
+		if tst.fail {
+			continue
+		}
+		var res RotaStats
+		if err := json.NewDecoder(recorder.Body).Decode(&res); err != nil {
+			t.Fatalf("%s: Decode() failed: %v", tst.name, err)
+		}
+		if diff := pretty.Compare(tst.want, res.Members); diff != "" {
+			t.Errorf("%s: HandleStats(ctx) differ -want +got, %s", tst.name, diff)
+		}
+	}
+}
@@ -1,8 +1,9 @@
 package handlers
 
 import (
-	"chromium.googlesource.com/infra/rotang"
 	"testing"
+
+	rotang "github.com/miekg/rota"
 )
 
 func TestSafeToMigrate(t *testing.T) {
@@ -10,7 +10,7 @@ import (
 	"net/http"
 	"strings"
 
-	"chromium.googlesource.com/infra/rotang/pkg/jsoncfg"
+	"github.com/miekg/rota/pkg/jsoncfg"
 	"go.chromium.org/luci/common/logging"
 	"go.chromium.org/luci/server/router"
 	"go.chromium.org/luci/server/templates"
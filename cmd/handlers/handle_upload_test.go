@@ -15,9 +15,8 @@ import (
 	"testing"
 	"time"
 
-	"chromium.googlesource.com/infra/rotang"
-
 	"github.com/kylelemons/godebug/pretty"
+	rotang "github.com/miekg/rota"
 	"go.chromium.org/luci/server/router"
 	"go.chromium.org/luci/server/templates"
 )
@@ -9,15 +9,13 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"strings"
 	"time"
 
-	"chromium.googlesource.com/infra/rotang"
-	"chromium.googlesource.com/infra/rotang/pkg/algo"
-	"go.chromium.org/luci/server/auth"
+	rotang "github.com/miekg/rota"
+	"github.com/miekg/rota/pkg/algo"
 	"go.chromium.org/luci/server/router"
 	"go.chromium.org/luci/server/templates"
-	"google.golang.org/appengine"
-	aeuser "google.golang.org/appengine/user"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -30,20 +28,20 @@ var mtvTime = func() *time.Location {
 	return loc
 }()
 
-func adminOrOwner(ctx *router.Context, cfg *rotang.Configuration) bool {
-	usr := auth.CurrentUser(ctx.Context)
-	if usr == nil || usr.Email == "" {
-		return false
-	}
-	if aeuser.IsAdmin(appengine.NewContext(ctx.Request)) {
-		return true
-	}
-	for _, m := range cfg.Config.Owners {
-		if usr.Email == m {
-			return true
+// defaultTZStore returns rotations stored without a time zone with the
+// America/Los_Angeles time zone, all rotations used before the time zone could be configured.
+type defaultTZStore struct {
+	rotang.ConfigStorer
+}
+
+func (d *defaultTZStore) RotaConfig(ctx context.Context, name string) ([]*rotang.Configuration, error) {
+	cfgs, err := d.ConfigStorer.RotaConfig(ctx, name)
+	for _, cfg := range cfgs {
+		if cfg.Config.Shifts.TZ.String() == "" {
+			cfg.Config.Shifts.TZ = *mtvTime
 		}
 	}
-	return false
+	return cfgs, err
 }
 
 // listRotations generates a list of rotations owned by current user.
@@ -52,8 +50,8 @@ func (h *State) listRotations(ctx *router.Context) (templates.Args, error) {
 	if err := ctx.Context.Err(); err != nil {
 		return nil, err
 	}
-	usr := auth.CurrentUser(ctx.Context)
-	if usr == nil || usr.Email == "" {
+	usr := h.currentUser(ctx)
+	if usr == nil {
 		return nil, status.Errorf(codes.PermissionDenied, "not logged in")
 	}
 
@@ -62,17 +60,21 @@ func (h *State) listRotations(ctx *router.Context) (templates.Args, error) {
 		return nil, err
 	}
 
-	if !aeuser.IsAdmin(appengine.NewContext(ctx.Request)) {
+	if !h.isAdmin(ctx, usr) {
 		var permRotas []*rotang.Configuration
 		for _, rota := range rotas {
 			for _, m := range rota.Config.Owners {
-				if usr.Email == m {
+				if strings.EqualFold(usr.Email, m) {
 					permRotas = append(permRotas, rota)
 				}
 			}
 		}
 	}
-	return templates.Args{"Rotas": rotas}, nil
+	feeds := make(map[string]string)
+	for _, rota := range rotas {
+		feeds[rota.Config.Name] = h.rotaICSPath(rota.Config.Name)
+	}
+	return templates.Args{"Rotas": rotas, "ICS": feeds}, nil
 }
 
 // modifyRotations generates the configuration and generators list used by the
@@ -92,7 +94,7 @@ func (h *State) modifyRotation(ctx *router.Context) (templates.Args, error) {
 	}
 	rota := rotas[0]
 
-	if !adminOrOwner(ctx, rota) {
+	if !h.adminOrOwner(ctx, rota) {
 		return nil, status.Errorf(codes.PermissionDenied, "not in the rotation owners")
 	}
 
@@ -146,7 +148,7 @@ func (h *State) rota(ctx *router.Context) (*rotang.Configuration, error) {
 		return nil, status.Errorf(codes.Internal, "expected only one rota to be returned")
 	}
 
-	if !adminOrOwner(ctx, rota[0]) {
+	if !h.adminOrOwner(ctx, rota[0]) {
 		return nil, status.Errorf(codes.PermissionDenied, "not rotation owner")
 	}
 	return rota[0], nil
@@ -211,6 +213,10 @@ type State struct {
 	configStore    func(context.Context) rotang.ConfigStorer
 	mailAddress    string
 	mailSender     rotang.MailSender
+	authenticator  rotang.Authenticator
+	authorizer     rotang.Authorizer
+	accessGroup    string
+	icsKey         []byte
 	legacyMap      map[string]func(ctx *router.Context, file string) (string, error)
 }
 
@@ -228,6 +234,14 @@ type Options struct {
 	MemberStore func(context.Context) rotang.MemberStorer
 	ConfigStore func(context.Context) rotang.ConfigStorer
 	ShiftStore  func(context.Context) rotang.ShiftStorer
+
+	// Authenticator and Authorizer default to LUCI auth and AppEngine admins when not set.
+	Authenticator rotang.Authenticator
+	Authorizer    rotang.Authorizer
+	// AccessGroup is the group users must be part of to pass RequireAccess, empty lets in all authenticated users.
+	AccessGroup string
+	// ICSKey signs the tokens in the URLs of the rota and member iCalendar feeds, the feeds are not served when empty.
+	ICSKey []byte
 }
 
 // New creates a new handlers State container.
@@ -258,10 +272,22 @@ func New(opt *Options) (*State, error) {
 		generators:     opt.Generators,
 		memberStore:    opt.MemberStore,
 		shiftStore:     opt.ShiftStore,
-		configStore:    opt.ConfigStore,
-		mailSender:     opt.MailSender,
-		mailAddress:    opt.MailAddress,
-		backupCred:     opt.BackupCred,
+		configStore: func(ctx context.Context) rotang.ConfigStorer {
+			return &defaultTZStore{opt.ConfigStore(ctx)}
+		},
+		mailSender:    opt.MailSender,
+		mailAddress:   opt.MailAddress,
+		backupCred:    opt.BackupCred,
+		authenticator: opt.Authenticator,
+		authorizer:    opt.Authorizer,
+		accessGroup:   opt.AccessGroup,
+		icsKey:        opt.ICSKey,
+	}
+	if h.authenticator == nil {
+		h.authenticator = &luciAuth{}
+	}
+	if h.authorizer == nil {
+		h.authorizer = &luciAuth{}
 	}
 	h.legacyMap = buildLegacyMap(h)
 	return h, nil
@@ -11,10 +11,9 @@ import (
 	"testing"
 	"time"
 
-	"chromium.googlesource.com/infra/rotang"
-	"chromium.googlesource.com/infra/rotang/pkg/algo"
-	"chromium.googlesource.com/infra/rotang/pkg/calendar"
-	"chromium.googlesource.com/infra/rotang/pkg/datastore"
+	rotang "github.com/miekg/rota"
+	"github.com/miekg/rota/pkg/algo"
+	"github.com/miekg/rota/pkg/storage/memory"
 	"go.chromium.org/luci/appengine/gaetesting"
 	"go.chromium.org/luci/server/router"
 	"google.golang.org/grpc/codes"
@@ -24,9 +23,7 @@ import (
 const templatesLocation = "../app/templates"
 
 func newTestContext() context.Context {
-	ctx := gaetesting.TestingContext()
-	datastore.TestTable(ctx)
-	return ctx
+	return gaetesting.TestingContext()
 }
 
 func getRequest(url string) *http.Request {
@@ -49,7 +46,6 @@ func testSetup(t *testing.T) *State {
 	t.Helper()
 	// Sort out the generators.
 	gs := algo.New()
-	gs.Register(algo.NewLegacy())
 	gs.Register(algo.NewFair())
 	gs.Register(algo.NewRandomGen())
 	// Register Modifiers.
@@ -68,7 +64,7 @@ func testSetup(t *testing.T) *State {
 		ProdENV:        "production",
 		BackupCred:     defaultClient,
 	}
-	setupStoreHandlers(&opts, datastore.New)
+	setupStoreHandlers(&opts, memory.New())
 	h, err := New(&opts)
 	if err != nil {
 		t.Fatalf("New failed: %v", err)
@@ -159,16 +155,16 @@ func TestNew(t *testing.T) {
 			ProdENV:    "production",
 			Generators: &algo.Generators{},
 			MemberStore: func(ctx context.Context) rotang.MemberStorer {
-				return datastore.New(ctx)
+				return memory.New()
 			},
 			ShiftStore: func(ctx context.Context) rotang.ShiftStorer {
-				return datastore.New(ctx)
+				return memory.New()
 			},
 			ConfigStore: func(ctx context.Context) rotang.ConfigStorer {
-				return datastore.New(ctx)
+				return memory.New()
 			},
-			Calendar:       &calendar.Calendar{},
-			LegacyCalendar: &calendar.Calendar{},
+			Calendar:       &fakeCal{},
+			LegacyCalendar: &fakeCal{},
 			BackupCred:     defaultClient,
 		},
 	}, {
@@ -181,16 +177,16 @@ func TestNew(t *testing.T) {
 			ProjectID: idFunc,
 			ProdENV:   "production",
 			MemberStore: func(ctx context.Context) rotang.MemberStorer {
-				return datastore.New(ctx)
+				return memory.New()
 			},
 			ShiftStore: func(ctx context.Context) rotang.ShiftStorer {
-				return datastore.New(ctx)
+				return memory.New()
 			},
 			ConfigStore: func(ctx context.Context) rotang.ConfigStorer {
-				return datastore.New(ctx)
+				return memory.New()
 			},
-			Calendar:       &calendar.Calendar{},
-			LegacyCalendar: &calendar.Calendar{},
+			Calendar:       &fakeCal{},
+			LegacyCalendar: &fakeCal{},
 			BackupCred:     defaultClient,
 		},
 	}, {
@@ -200,10 +196,10 @@ func TestNew(t *testing.T) {
 			ProjectID:      idFunc,
 			ProdENV:        "production",
 			Generators:     &algo.Generators{},
-			Calendar:       &calendar.Calendar{},
-			LegacyCalendar: &calendar.Calendar{},
+			Calendar:       &fakeCal{},
+			LegacyCalendar: &fakeCal{},
 			ConfigStore: func(ctx context.Context) rotang.ConfigStorer {
-				return datastore.New(ctx)
+				return memory.New()
 			},
 			BackupCred: defaultClient,
 		},
@@ -214,15 +210,15 @@ func TestNew(t *testing.T) {
 			ProjectID:      idFunc,
 			ProdENV:        "production",
 			Generators:     &algo.Generators{},
-			LegacyCalendar: &calendar.Calendar{},
+			LegacyCalendar: &fakeCal{},
 			MemberStore: func(ctx context.Context) rotang.MemberStorer {
-				return datastore.New(ctx)
+				return memory.New()
 			},
 			ShiftStore: func(ctx context.Context) rotang.ShiftStorer {
-				return datastore.New(ctx)
+				return memory.New()
 			},
 			ConfigStore: func(ctx context.Context) rotang.ConfigStorer {
-				return datastore.New(ctx)
+				return memory.New()
 			},
 			BackupCred: defaultClient,
 		},
@@ -233,16 +229,16 @@ func TestNew(t *testing.T) {
 			ProjectID:  idFunc,
 			Generators: &algo.Generators{},
 			MemberStore: func(ctx context.Context) rotang.MemberStorer {
-				return datastore.New(ctx)
+				return memory.New()
 			},
 			ShiftStore: func(ctx context.Context) rotang.ShiftStorer {
-				return datastore.New(ctx)
+				return memory.New()
 			},
 			ConfigStore: func(ctx context.Context) rotang.ConfigStorer {
-				return datastore.New(ctx)
+				return memory.New()
 			},
-			Calendar:       &calendar.Calendar{},
-			LegacyCalendar: &calendar.Calendar{},
+			Calendar:       &fakeCal{},
+			LegacyCalendar: &fakeCal{},
 			BackupCred:     defaultClient,
 		},
 	}, {
@@ -253,15 +249,15 @@ func TestNew(t *testing.T) {
 			ProdENV:    "production",
 			Generators: &algo.Generators{},
 			MemberStore: func(ctx context.Context) rotang.MemberStorer {
-				return datastore.New(ctx)
+				return memory.New()
 			},
 			ShiftStore: func(ctx context.Context) rotang.ShiftStorer {
-				return datastore.New(ctx)
+				return memory.New()
 			},
 			ConfigStore: func(ctx context.Context) rotang.ConfigStorer {
-				return datastore.New(ctx)
+				return memory.New()
 			},
-			Calendar:   &calendar.Calendar{},
+			Calendar:   &fakeCal{},
 			BackupCred: defaultClient,
 		},
 	}, {
@@ -272,16 +268,16 @@ func TestNew(t *testing.T) {
 			ProdENV:    "production",
 			Generators: &algo.Generators{},
 			MemberStore: func(ctx context.Context) rotang.MemberStorer {
-				return datastore.New(ctx)
+				return memory.New()
 			},
 			ShiftStore: func(ctx context.Context) rotang.ShiftStorer {
-				return datastore.New(ctx)
+				return memory.New()
 			},
 			ConfigStore: func(ctx context.Context) rotang.ConfigStorer {
-				return datastore.New(ctx)
+				return memory.New()
 			},
-			Calendar:       &calendar.Calendar{},
-			LegacyCalendar: &calendar.Calendar{},
+			Calendar:       &fakeCal{},
+			LegacyCalendar: &fakeCal{},
 		},
 	}, {
 		name: "ProjectID missing",
@@ -290,16 +286,16 @@ func TestNew(t *testing.T) {
 			ProdENV:    "production",
 			Generators: &algo.Generators{},
 			MemberStore: func(ctx context.Context) rotang.MemberStorer {
-				return datastore.New(ctx)
+				return memory.New()
 			},
 			ShiftStore: func(ctx context.Context) rotang.ShiftStorer {
-				return datastore.New(ctx)
+				return memory.New()
 			},
 			ConfigStore: func(ctx context.Context) rotang.ConfigStorer {
-				return datastore.New(ctx)
+				return memory.New()
 			},
-			Calendar:       &calendar.Calendar{},
-			LegacyCalendar: &calendar.Calendar{},
+			Calendar:       &fakeCal{},
+			LegacyCalendar: &fakeCal{},
 			BackupCred:     defaultClient,
 		},
 	},
@@ -313,3 +309,53 @@ func TestNew(t *testing.T) {
 		}
 	}
 }
+
+func TestDefaultTZ(t *testing.T) {
+	ctx := newTestContext()
+	h := testSetup(t)
+
+	zurich, err := time.LoadLocation("Europe/Zurich")
+	if err != nil {
+		t.Fatalf("time.LoadLocation(%q) failed: %v", "Europe/Zurich", err)
+	}
+
+	tests := []struct {
+		name string
+		cfg  *rotang.Configuration
+		want string
+	}{{
+		name: "Stored without TZ",
+		cfg: &rotang.Configuration{
+			Config: rotang.Config{
+				Name: "No TZ",
+			},
+		},
+		want: "America/Los_Angeles",
+	}, {
+		name: "Stored with TZ",
+		cfg: &rotang.Configuration{
+			Config: rotang.Config{
+				Name: "Zurich",
+				Shifts: rotang.ShiftConfig{
+					TZ: *zurich,
+				},
+			},
+		},
+		want: "Europe/Zurich",
+	},
+	}
+
+	for _, tst := range tests {
+		if err := h.configStore(ctx).CreateRotaConfig(ctx, tst.cfg); err != nil {
+			t.Fatalf("%s: CreateRotaConfig(ctx, _) failed: %v", tst.name, err)
+		}
+		defer h.configStore(ctx).DeleteRotaConfig(ctx, tst.cfg.Config.Name)
+		rotas, err := h.configStore(ctx).RotaConfig(ctx, tst.cfg.Config.Name)
+		if err != nil {
+			t.Fatalf("%s: RotaConfig(ctx, %q) failed: %v", tst.name, tst.cfg.Config.Name, err)
+		}
+		if got := rotas[0].Config.Shifts.Location().String(); got != tst.want {
+			t.Errorf("%s: RotaConfig(ctx, %q) TZ = %q want: %q", tst.name, tst.cfg.Config.Name, got, tst.want)
+		}
+	}
+}
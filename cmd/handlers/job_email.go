@@ -2,11 +2,11 @@ package handlers
 
 import (
 	"bytes"
-	"chromium.googlesource.com/infra/rotang"
 	"net/http"
 	"text/template"
 	"time"
 
+	rotang "github.com/miekg/rota"
 	"go.chromium.org/gae/service/mail"
 	"go.chromium.org/luci/common/clock"
 	"go.chromium.org/luci/common/logging"
@@ -50,8 +50,11 @@ func (h *State) notifyEmail(ctx *router.Context, cfg *rotang.Configuration, t ti
 		logging.Infof(ctx.Context, "notifyEmail: %q not considered due to %s", cfg.Config.Name, msg)
 		return nil
 	}
-	expTime := t.Add(time.Duration(cfg.Config.Email.DaysBeforeNotify) * fullDay).UTC()
-	shifts, err := h.shiftStore(ctx.Context).ShiftsFromTo(ctx.Context, cfg.Config.Name, expTime, expTime.Add(fullDay))
+	// Days are counted in the rotation TZ to keep the notification time of day across DST changes.
+	loc := cfg.Config.Shifts.Location()
+	expTime := t.In(loc).AddDate(0, 0, cfg.Config.Email.DaysBeforeNotify)
+	expEnd := expTime.AddDate(0, 0, 1)
+	shifts, err := h.shiftStore(ctx.Context).ShiftsFromTo(ctx.Context, cfg.Config.Name, expTime, expEnd)
 	if err != nil {
 		if status.Code(err) == codes.NotFound {
 			return nil
@@ -63,7 +66,7 @@ func (h *State) notifyEmail(ctx *router.Context, cfg *rotang.Configuration, t ti
 		// startAfterExpiry checks that the shift StartTime is Equal or After the expiry time.
 		startAfterExpiry := s.StartTime.After(expTime) || s.StartTime.Equal(expTime)
 		// startInsideDay handles sending only one mail per shift.
-		startInsideDay := s.StartTime.Before(expTime.Add(fullDay))
+		startInsideDay := s.StartTime.Before(expEnd)
 		// notifyZero DatesBeforeNotify 0, then just check we're in the same day as ShiftStart.
 		notifyZero := t.Equal(expTime)
 		if (notifyZero || startAfterExpiry) && startInsideDay {
@@ -96,11 +99,17 @@ func (h *State) sendMail(ctx *router.Context, cfg *rotang.Configuration, shift *
 		return err
 	}
 
+	// Present the shift times in the rotation TZ.
+	loc := cfg.Config.Shifts.Location()
+	se := *shift
+	se.StartTime, se.EndTime = se.StartTime.In(loc), se.EndTime.In(loc)
+
 	subject, body, err := emailFromTemplate(cfg, &rotang.Info{
 		RotaName:    cfg.Config.Name,
 		ShiftConfig: cfg.Config.Shifts,
-		ShiftEntry:  *shift,
+		ShiftEntry:  se,
 		Member:      *m,
+		Role:        se.MemberRole(email),
 	})
 	if err != nil {
 		return err
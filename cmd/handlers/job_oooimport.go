@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"time"
+
+	rotang "github.com/miekg/rota"
+	"github.com/miekg/rota/pkg/ical"
+	"go.chromium.org/luci/common/clock"
+	"go.chromium.org/luci/common/logging"
+	"go.chromium.org/luci/server/router"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// syncedComment is used for imported events without a summary.
+const syncedComment = "Busy in calendar"
+
+// maxCalendarSize limits the size of the calendar feeds read.
+const maxCalendarSize = 10 << 20
+
+// calendarClient fetches the calendar feeds, the feeds are hosted outside of rota.
+var calendarClient = &http.Client{Timeout: 30 * time.Second}
+
+// JobOOOImport imports OOO entries from the iCalendar feeds of members, see Member.Calendar.
+func (h *State) JobOOOImport(ctx *router.Context) {
+	if err := ctx.Context.Err(); err != nil {
+		http.Error(ctx.Writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	now := clock.Now(ctx.Context)
+	members, err := h.memberStore(ctx.Context).AllMembers(ctx.Context)
+	if err != nil {
+		http.Error(ctx.Writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for _, m := range members {
+		if m.Calendar == "" {
+			continue
+		}
+		if err := h.oooImport(ctx, &m, now); err != nil {
+			logging.Warningf(ctx.Context, "oooImport(ctx, _, %v) for member: %q failed: %v", now, m.Email, err)
+		}
+	}
+}
+
+func (h *State) oooImport(ctx *router.Context, member *rotang.Member, t time.Time) error {
+	r, err := openCalendar(ctx.Context, member.Calendar)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	// Reading one byte more tells a feed at the limit from a larger one.
+	b, err := io.ReadAll(io.LimitReader(r, maxCalendarSize+1))
+	if err != nil {
+		return err
+	}
+	if len(b) > maxCalendarSize {
+		return status.Errorf(codes.FailedPrecondition, "calendar: %q larger than %d bytes", member.Calendar, maxCalendarSize)
+	}
+	events, err := ical.Parse(bytes.NewReader(b), &member.TZ)
+	if err != nil {
+		return err
+	}
+	ooo := syncOOO(member.OOO, events, t)
+	if reflect.DeepEqual(ooo, member.OOO) {
+		return nil
+	}
+	member.OOO = ooo
+	if err := h.memberStore(ctx.Context).UpdateMember(ctx.Context, member); err != nil {
+		return err
+	}
+	logging.Infof(ctx.Context, "OOO for member: %q imported from calendar", member.Email)
+	return nil
+}
+
+// syncOOO replaces the synced entries in ooo with the busy events not ended at t.
+// Manual entries are kept as is.
+func syncOOO(ooo []rotang.OOO, events []ical.Event, t time.Time) []rotang.OOO {
+	var res []rotang.OOO
+	for _, o := range ooo {
+		if !o.Synced {
+			res = append(res, o)
+		}
+	}
+	for _, e := range events {
+		if !e.Busy() || !e.End.After(t) || !e.End.After(e.Start) {
+			continue
+		}
+		comment := e.Summary
+		if comment == "" {
+			comment = syncedComment
+		}
+		res = append(res, rotang.OOO{
+			Start:    e.Start,
+			Duration: e.End.Sub(e.Start),
+			Comment:  comment,
+			Synced:   true,
+		})
+	}
+	return res
+}
+
+// openCalendar opens the iCalendar feed at src, either a http(s) URL or a local path.
+func openCalendar(ctx context.Context, src string) (io.ReadCloser, error) {
+	u, err := url.Parse(src)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "", "file":
+		return os.Open(u.Path)
+	case "http", "https":
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported calendar URL: %q", src)
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", src, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := calendarClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, status.Errorf(codes.Unavailable, "fetching calendar: %q failed: %s", src, resp.Status)
+	}
+	return resp.Body, nil
+}
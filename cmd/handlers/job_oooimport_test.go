@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+	rotang "github.com/miekg/rota"
+	"go.chromium.org/luci/common/clock"
+	"go.chromium.org/luci/common/clock/testclock"
+	"go.chromium.org/luci/server/router"
+)
+
+const testICS = `BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:vacation
+DTSTART;VALUE=DATE:20060410
+DTEND;VALUE=DATE:20060415
+SUMMARY:Vacation
+X-MICROSOFT-CDO-BUSYSTATUS:OOF
+END:VEVENT
+BEGIN:VEVENT
+UID:meeting
+DTSTART:20060403T090000Z
+DTEND:20060403T100000Z
+END:VEVENT
+BEGIN:VEVENT
+UID:free
+DTSTART:20060404T090000Z
+DTEND:20060404T100000Z
+TRANSP:TRANSPARENT
+END:VEVENT
+BEGIN:VEVENT
+UID:past
+DTSTART:20060301T090000Z
+DTEND:20060301T100000Z
+END:VEVENT
+END:VCALENDAR
+`
+
+func TestJobOOOImport(t *testing.T) {
+	ctx := newTestContext()
+	ctxCancel, cancel := context.WithCancel(ctx)
+	cancel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/calendar.ics":
+			fmt.Fprint(w, testICS)
+		case "/large.ics":
+			// The events are past the size limit.
+			fmt.Fprint(w, "BEGIN:VCALENDAR\n"+strings.Repeat("X-PADDING:"+strings.Repeat("x", 64)+"\n", maxCalendarSize/64)+testICS)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	icsFile := filepath.Join(t.TempDir(), "calendar.ics")
+	if err := os.WriteFile(icsFile, []byte(testICS), 0644); err != nil {
+		t.Fatalf("os.WriteFile(%q) failed: %v", icsFile, err)
+	}
+
+	manual := rotang.OOO{
+		Start:    midnight.Add(fullDay),
+		Duration: fullDay,
+		Comment:  "Dentist",
+	}
+	removed := rotang.OOO{
+		Start:    midnight,
+		Duration: time.Hour,
+		Comment:  "Removed from the calendar",
+		Synced:   true,
+	}
+	synced := []rotang.OOO{
+		{
+			Start:    time.Date(2006, 4, 10, 0, 0, 0, 0, time.UTC),
+			Duration: 5 * fullDay,
+			Comment:  "Vacation",
+			Synced:   true,
+		}, {
+			Start:    time.Date(2006, 4, 3, 9, 0, 0, 0, time.UTC),
+			Duration: time.Hour,
+			Comment:  syncedComment,
+			Synced:   true,
+		},
+	}
+
+	tests := []struct {
+		name       string
+		fail       bool
+		ctx        *router.Context
+		memberPool []rotang.Member
+		want       []rotang.Member
+	}{{
+		name: "Canceled context",
+		fail: true,
+		ctx: &router.Context{
+			Context: ctxCancel,
+			Writer:  httptest.NewRecorder(),
+		},
+	}, {
+		name: "HTTP calendar",
+		ctx: &router.Context{
+			Context: ctx,
+			Writer:  httptest.NewRecorder(),
+		},
+		memberPool: []rotang.Member{
+			{
+				Email:    "oncaller1@oncall.com",
+				TZ:       *time.UTC,
+				Calendar: srv.URL + "/calendar.ics",
+			}, {
+				Email: "oncaller2@oncall.com",
+				TZ:    *time.UTC,
+				OOO:   []rotang.OOO{manual},
+			},
+		},
+		want: []rotang.Member{
+			{
+				Email:    "oncaller1@oncall.com",
+				TZ:       *time.UTC,
+				Calendar: srv.URL + "/calendar.ics",
+				OOO:      synced,
+			}, {
+				Email: "oncaller2@oncall.com",
+				TZ:    *time.UTC,
+				OOO:   []rotang.OOO{manual},
+			},
+		},
+	}, {
+		name: "Local calendar keeps manual entries",
+		ctx: &router.Context{
+			Context: ctx,
+			Writer:  httptest.NewRecorder(),
+		},
+		memberPool: []rotang.Member{
+			{
+				Email:    "oncaller1@oncall.com",
+				TZ:       *time.UTC,
+				Calendar: icsFile,
+				OOO:      []rotang.OOO{manual, removed},
+			},
+		},
+		want: []rotang.Member{
+			{
+				Email:    "oncaller1@oncall.com",
+				TZ:       *time.UTC,
+				Calendar: icsFile,
+				OOO:      append([]rotang.OOO{manual}, synced...),
+			},
+		},
+	}, {
+		name: "Broken calendar",
+		ctx: &router.Context{
+			Context: ctx,
+			Writer:  httptest.NewRecorder(),
+		},
+		memberPool: []rotang.Member{
+			{
+				Email:    "oncaller1@oncall.com",
+				TZ:       *time.UTC,
+				Calendar: srv.URL + "/notfound.ics",
+				OOO:      []rotang.OOO{manual},
+			},
+		},
+		want: []rotang.Member{
+			{
+				Email:    "oncaller1@oncall.com",
+				TZ:       *time.UTC,
+				Calendar: srv.URL + "/notfound.ics",
+				OOO:      []rotang.OOO{manual},
+			},
+		},
+	}, {
+		name: "Calendar too large",
+		ctx: &router.Context{
+			Context: ctx,
+			Writer:  httptest.NewRecorder(),
+		},
+		memberPool: []rotang.Member{
+			{
+				Email:    "oncaller1@oncall.com",
+				TZ:       *time.UTC,
+				Calendar: srv.URL + "/large.ics",
+				OOO:      []rotang.OOO{manual, removed},
+			},
+		},
+		want: []rotang.Member{
+			{
+				Email:    "oncaller1@oncall.com",
+				TZ:       *time.UTC,
+				Calendar: srv.URL + "/large.ics",
+				OOO:      []rotang.OOO{manual, removed},
+			},
+		},
+	},
+	}
+
+	h := testSetup(t)
+
+	for _, tst := range tests {
+		t.Run(tst.name, func(t *testing.T) {
+			for _, m := range tst.memberPool {
+				if err := h.memberStore(ctx).CreateMember(ctx, &m); err != nil {
+					t.Fatalf("%s: CreateMember(ctx, _) failed: %v", tst.name, err)
+				}
+				defer h.memberStore(ctx).DeleteMember(ctx, m.Email)
+			}
+
+			tst.ctx.Context = clock.Set(tst.ctx.Context, testclock.New(midnight))
+			h.JobOOOImport(tst.ctx)
+
+			recorder := tst.ctx.Writer.(*httptest.ResponseRecorder)
+			if got, want := (recorder.Code != http.StatusOK), tst.fail; got != want {
+				t.Fatalf("%s: JobOOOImport(ctx) = %d want: %d", tst.name, recorder.Code, http.StatusOK)
+			}
+			if tst.fail {
+				return
+			}
+
+			for _, want := range tst.want {
+				got, err := h.memberStore(ctx).Member(ctx, want.Email)
+				if err != nil {
+					t.Fatalf("%s: Member(ctx, %q) failed: %v", tst.name, want.Email, err)
+				}
+				for i := range got.OOO {
+					got.OOO[i].Start = got.OOO[i].Start.UTC()
+				}
+				if diff := pretty.Compare(want, got); diff != "" {
+					t.Fatalf("%s: JobOOOImport(ctx) differ -want +got, %s", tst.name, diff)
+				}
+			}
+		})
+	}
+}
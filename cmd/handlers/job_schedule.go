@@ -1,10 +1,14 @@
 package handlers
 
 import (
-	"chromium.googlesource.com/infra/rotang"
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
+	rotang "github.com/miekg/rota"
+	"github.com/miekg/rota/pkg/algo"
+	"go.chromium.org/gae/service/mail"
 	"go.chromium.org/luci/common/clock"
 	"go.chromium.org/luci/common/logging"
 	"go.chromium.org/luci/server/router"
@@ -64,9 +68,6 @@ func (h *State) scheduleShifts(ctx *router.Context, cfg *rotang.Configuration, t
 		return err
 	}
 
-	// TODO(olakar): Remove this when rotation TZ is implemented.
-	cfg.Config.Shifts.TZ = *mtvTime
-
 	for _, m := range cfg.Config.Shifts.Modifiers {
 		mod, err := h.generators.FetchModifier(m)
 		if err != nil {
@@ -88,11 +89,71 @@ func (h *State) scheduleShifts(ctx *router.Context, cfg *rotang.Configuration, t
 
 	shiftStore := h.shiftStore(ctx.Context)
 	for _, s := range resShifts {
-		s.Comment = genComment
+		// Keep the comments of the Generator, eg. understaffed shifts.
+		comment := genComment
+		if s.Comment != "" {
+			comment += "; " + s.Comment
+		}
+		s.Comment = comment
 		if err := shiftStore.UpdateShift(ctx.Context, cfg.Config.Name, &s); err != nil {
 			return err
 		}
 	}
+	warnings := append(understaffedWarnings(cfg, algo.Understaffed(&cfg.Config.Shifts, ss)), coverageWarnings(cfg, ms, ss)...)
+	if err := h.notifyUnderstaffed(ctx, cfg, warnings); err != nil {
+		return err
+	}
 	logging.Infof(ctx.Context, "scheduling of shifts for rota: %q successful", cfg.Config.Name)
 	return nil
 }
+
+// understaffedWarnings describes the understaffed shifts, with times in the rotation TZ.
+func understaffedWarnings(cfg *rotang.Configuration, shifts []rotang.ShiftEntry) []string {
+	var res []string
+	for _, s := range shifts {
+		res = append(res, shiftWarning(cfg, s, algo.UnderstaffedComment(algo.Staffing(s), cfg.Config.Shifts.ShiftMembers)))
+	}
+	return res
+}
+
+// coverageWarnings describes the shifts missing members with the Shift.Coverage tags.
+func coverageWarnings(cfg *rotang.Configuration, members []rotang.Member, shifts []rotang.ShiftEntry) []string {
+	var res []string
+	for _, s := range shifts {
+		if missing := algo.MissingCoverage(cfg, members, s); len(missing) > 0 {
+			res = append(res, shiftWarning(cfg, s, algo.CoverageComment(missing)))
+		}
+	}
+	return res
+}
+
+func shiftWarning(cfg *rotang.Configuration, s rotang.ShiftEntry, msg string) string {
+	loc := cfg.Config.Shifts.Location()
+	return fmt.Sprintf("%s %s - %s: %s", s.Name, s.StartTime.In(loc).Format(mailTimeFormat), s.EndTime.In(loc).Format(mailTimeFormat), msg)
+}
+
+const mailTimeFormat = "Mon 2006-01-02 15:04 MST"
+
+// notifyUnderstaffed mails the rotation owners the warnings about understaffed shifts and shifts
+// missing coverage, to have them fixed before the shifts start.
+func (h *State) notifyUnderstaffed(ctx *router.Context, cfg *rotang.Configuration, warnings []string) error {
+	if len(warnings) == 0 {
+		return nil
+	}
+	subject := fmt.Sprintf("Understaffed shifts for rotation: %q", cfg.Config.Name)
+	body := fmt.Sprintf("Not enough members could be scheduled for the following shifts of rotation: %q\n\n%s\n",
+		cfg.Config.Name, strings.Join(warnings, "\n"))
+	for _, o := range cfg.Config.Owners {
+		to, sender := h.setSender(ctx, o)
+		if err := h.mailSender.Send(ctx.Context, &mail.Message{
+			Sender:  sender,
+			To:      []string{to},
+			Subject: subject,
+			Body:    body,
+		}); err != nil {
+			return err
+		}
+		logging.Infof(ctx.Context, "notifyUnderstaffed: mail sent out to: %q, rota: %q", o, cfg.Config.Name)
+	}
+	return nil
+}
@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"chromium.googlesource.com/infra/rotang"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -10,6 +9,8 @@ import (
 	"context"
 
 	"github.com/kylelemons/godebug/pretty"
+	rotang "github.com/miekg/rota"
+	"go.chromium.org/gae/service/mail"
 	"go.chromium.org/luci/server/router"
 )
 
@@ -589,8 +590,9 @@ func TestScheduleShifts(t *testing.T) {
 						ShiftName: "MTV All Day",
 					},
 				},
-				StartTime: midnight.Add(16 * fullDay),
-				EndTime:   midnight.Add(21 * fullDay),
+				// Generated on a Sunday in the rotation TZ (UTC), moved to Monday.
+				StartTime: midnight.Add(15 * fullDay),
+				EndTime:   midnight.Add(20 * fullDay),
 				EvtID:     "0",
 				Comment:   genComment,
 			}, {
@@ -601,8 +603,8 @@ func TestScheduleShifts(t *testing.T) {
 						ShiftName: "MTV All Day",
 					},
 				},
-				StartTime: midnight.Add(23 * fullDay),
-				EndTime:   midnight.Add(28 * fullDay),
+				StartTime: midnight.Add(22 * fullDay),
+				EndTime:   midnight.Add(27 * fullDay),
 				EvtID:     "1",
 				Comment:   genComment,
 			},
@@ -962,3 +964,130 @@ func TestScheduleShifts(t *testing.T) {
 		})
 	}
 }
+
+func TestScheduleShiftsUnderstaffed(t *testing.T) {
+	ctx := newTestContext()
+	rctx := &router.Context{
+		Context: ctx,
+		Request: getRequest("/"),
+	}
+
+	cfg := &rotang.Configuration{
+		Config: rotang.Config{
+			Name:             "Test Rota",
+			Owners:           []string{"owner1@oncall.com", "owner2@oncall.com"},
+			Enabled:          true,
+			Expiration:       2,
+			ShiftsToSchedule: 2,
+			Shifts: rotang.ShiftConfig{
+				StartTime:    midnight,
+				Length:       5,
+				Skip:         2,
+				Generator:    "Fair",
+				ShiftMembers: 2,
+				Shifts: []rotang.Shift{
+					{
+						Name:     "MTV All Day",
+						Duration: fullDay,
+						Coverage: []string{"db"},
+					},
+				},
+			},
+		},
+		Members: []rotang.ShiftMember{
+			{
+				Email:     "oncaller1@oncall.com",
+				ShiftName: "MTV All Day",
+			},
+		},
+	}
+	oncall := []rotang.ShiftMember{
+		{
+			Email:     "oncaller1@oncall.com",
+			ShiftName: "MTV All Day",
+		},
+	}
+	shifts := []rotang.ShiftEntry{
+		{
+			Name:      "MTV All Day",
+			OnCall:    oncall,
+			StartTime: midnight,
+			EndTime:   midnight.Add(5 * fullDay),
+		},
+	}
+	want := append(shifts, []rotang.ShiftEntry{
+		{
+			Name:      "MTV All Day",
+			OnCall:    oncall,
+			StartTime: midnight.Add(weekDuration),
+			EndTime:   midnight.Add(5*fullDay + weekDuration),
+			EvtID:     "0",
+			Comment:   genComment + "; only 1 of 2 members could be scheduled; no member with tags: db",
+		}, {
+			Name:      "MTV All Day",
+			OnCall:    oncall,
+			StartTime: midnight.Add(2 * weekDuration),
+			EndTime:   midnight.Add(5*fullDay + 2*weekDuration),
+			EvtID:     "1",
+			Comment:   genComment + "; only 1 of 2 members could be scheduled; no member with tags: db",
+		},
+	}...)
+	body := `Not enough members could be scheduled for the following shifts of rotation: "Test Rota"
+
+MTV All Day Sun 2006-04-09 00:00 UTC - Fri 2006-04-14 00:00 UTC: only 1 of 2 members could be scheduled
+MTV All Day Sun 2006-04-16 00:00 UTC - Fri 2006-04-21 00:00 UTC: only 1 of 2 members could be scheduled
+MTV All Day Sun 2006-04-09 00:00 UTC - Fri 2006-04-14 00:00 UTC: no member with tags: db
+MTV All Day Sun 2006-04-16 00:00 UTC - Fri 2006-04-21 00:00 UTC: no member with tags: db
+`
+	wantMsg := []mail.Message{
+		{
+			Sender:  "admin@example.com",
+			To:      []string{"owner1@oncall.com"},
+			Subject: `Understaffed shifts for rotation: "Test Rota"`,
+			Body:    body,
+		}, {
+			Sender:  "admin@example.com",
+			To:      []string{"owner2@oncall.com"},
+			Subject: `Understaffed shifts for rotation: "Test Rota"`,
+			Body:    body,
+		},
+	}
+
+	h := testSetup(t)
+	testMail := mail.GetTestable(ctx)
+	testMail.Reset()
+
+	if err := h.memberStore(ctx).CreateMember(ctx, &rotang.Member{Email: "oncaller1@oncall.com"}); err != nil {
+		t.Fatalf("CreateMember(ctx, _) failed: %v", err)
+	}
+	defer h.memberStore(ctx).DeleteMember(ctx, "oncaller1@oncall.com")
+	if err := h.configStore(ctx).CreateRotaConfig(ctx, cfg); err != nil {
+		t.Fatalf("CreateRotaConfig(ctx, _) failed: %v", err)
+	}
+	defer h.configStore(ctx).DeleteRotaConfig(ctx, cfg.Config.Name)
+	if err := h.shiftStore(ctx).AddShifts(ctx, cfg.Config.Name, shifts); err != nil {
+		t.Fatalf("AddShifts(ctx, _) failed: %v", err)
+	}
+	defer h.shiftStore(ctx).DeleteAllShifts(ctx, cfg.Config.Name)
+
+	h.calendar.(*fakeCal).Set(nil, false, false, 0)
+	if err := h.scheduleShifts(rctx, cfg, midnight); err != nil {
+		t.Fatalf("scheduleShifts(ctx, _, %v) failed: %v", midnight, err)
+	}
+
+	got, err := h.shiftStore(ctx).AllShifts(ctx, cfg.Config.Name)
+	if err != nil {
+		t.Fatalf("AllShifts(ctx, %q) failed: %v", cfg.Config.Name, err)
+	}
+	if diff := pretty.Compare(want, got); diff != "" {
+		t.Errorf("scheduleShifts(ctx, _, %v) differ -want +got, %s", midnight, diff)
+	}
+
+	var gotMsg []mail.Message
+	for _, m := range testMail.SentMessages() {
+		gotMsg = append(gotMsg, m.Message)
+	}
+	if diff := pretty.Compare(wantMsg, gotMsg); diff != "" {
+		t.Errorf("scheduleShifts(ctx, _, %v) mails differ -want +got, %s", midnight, diff)
+	}
+}
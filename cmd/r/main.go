@@ -1,8 +1,21 @@
+// Command r is a small tool to try out the rota generators.
+//
+// Usage:
+//
+//	r [generate] [-generator name] [-n shifts]
+//	r simulate [-config file] [-members file] [-shifts file] [-start date] [-n shifts] [-modifiers list] [-seed n] [-v]
+//
+// Without the files the built-in postmaster rotation is used. The members file holds a JSON list of
+// rotang.Member with TZ set to the name of a time zone, eg. "Europe/Amsterdam". All generators are run
+// with the same seed, set with -seed to reproduce a simulation.
 package main
 
 import (
+	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
+	"os"
 	"time"
 
 	rotang "github.com/miekg/rota"
@@ -21,22 +34,60 @@ var smembers = []rotang.ShiftMember{
 }
 
 func main() {
-	members := make([]rotang.Member, len(smembers))
-	for i := range smembers {
-		members[i] = rotang.Member{Email: smembers[i].Email}
-	}
-	members[0].Preferences = []rotang.Preference{rotang.NoOncall}
-
 	gs := algo.New()
 	gs.Register(algo.NewFair())
 	gs.Register(algo.NewRandomGen())
+	gs.Register(algo.NewTZFair())
+	gs.Register(algo.NewShadow(algo.NewFair()))
+	gs.Register(algo.NewFollowTheSun())
+	gs.Register(algo.NewFairHours())
+	gs.Register(algo.NewOptimal())
 	gs.RegisterModifier(algo.NewWeekendSkip())
 	gs.RegisterModifier(algo.NewSplitShift())
+	gs.RegisterModifier(algo.NewHolidaySkip())
 
-	g, err := gs.Fetch("Fair")
+	cmd, args := "generate", os.Args[1:]
+	if len(args) > 0 && (args[0] == "generate" || args[0] == "simulate") {
+		cmd, args = args[0], args[1:]
+	}
+	var err error
+	switch cmd {
+	case "generate":
+		err = generate(gs, args)
+	case "simulate":
+		err = simulate(gs, args)
+	}
 	if err != nil {
 		log.Fatal(err)
 	}
+}
+
+func generate(gs *algo.Generators, args []string) error {
+	fs := flag.NewFlagSet("generate", flag.ExitOnError)
+	name := fs.String("generator", "Fair", "generator to use")
+	n := fs.Int("n", 5, "number of shifts to generate")
+	fs.Parse(args)
+
+	g, err := gs.Fetch(*name)
+	if err != nil {
+		return err
+	}
+	sc, members := postmaster()
+	ss, err := g.Generate(sc, time.Now(), nil, members, *n)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%+v\n", ss)
+	return nil
+}
+
+// postmaster returns the built-in postmaster rotation and its members.
+func postmaster() (*rotang.Configuration, []rotang.Member) {
+	members := make([]rotang.Member, len(smembers))
+	for i := range smembers {
+		members[i] = rotang.Member{Email: smembers[i].Email}
+	}
+	members[0].Preferences = []rotang.Preference{rotang.NoOncall}
 
 	c := rotang.Config{
 		Name:             "postmaster",
@@ -46,19 +97,23 @@ func main() {
 			ShiftMembers: 2,
 			Length:       7,
 			Shifts: []rotang.Shift{
-				{Name: "postmaster", Duration: 24 * 7 * time.Hour},
+				{Name: "postmaster", Duration: 24 * time.Hour},
 			},
 		},
 	}
 
-	sc := &rotang.Configuration{
+	return &rotang.Configuration{
 		Config:  c,
 		Members: smembers,
-	}
+	}, members
+}
 
-	ss, err := g.Generate(sc, time.Now(), nil, members, 5)
+// readJSON decodes the JSON in file into v.
+func readJSON(file string, v interface{}) error {
+	f, err := os.Open(file)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
-	fmt.Printf("%+v\n", ss)
+	defer f.Close()
+	return json.NewDecoder(f).Decode(v)
 }
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	rotang "github.com/miekg/rota"
+	"github.com/miekg/rota/pkg/algo"
+)
+
+// simulate runs all generators over the same input and prints their fairness and violations side by side.
+func simulate(gs *algo.Generators, args []string) error {
+	fs := flag.NewFlagSet("simulate", flag.ExitOnError)
+	configFile := fs.String("config", "", "rotation configuration, JSON encoded rotang.Configuration")
+	membersFile := fs.String("members", "", "members of the rotation, JSON encoded []rotang.Member with TZ as a time zone name")
+	shiftsFile := fs.String("shifts", "", "previous shifts, JSON encoded []rotang.ShiftEntry")
+	startDate := fs.String("start", "", "start date of the simulation, YYYY-MM-DD, defaults to today")
+	n := fs.Int("n", 0, "number of shifts to schedule, defaults to ShiftsToSchedule of the rotation")
+	modifiers := fs.String("modifiers", "", "comma separated list of modifiers to apply")
+	seed := fs.Int64("seed", 0, "seed for the generators, defaults to the rotation seed or a random one")
+	verbose := fs.Bool("v", false, "print the statistics per member")
+	fs.Parse(args)
+
+	sc, members := postmaster()
+	if *configFile != "" {
+		sc = &rotang.Configuration{}
+		if err := readJSON(*configFile, sc); err != nil {
+			return err
+		}
+		members = nil
+		for _, m := range sc.Members {
+			members = append(members, rotang.Member{Email: m.Email})
+		}
+	}
+	if *membersFile != "" {
+		var err error
+		if members, err = readMembers(*membersFile); err != nil {
+			return err
+		}
+	}
+	var previous []rotang.ShiftEntry
+	if *shiftsFile != "" {
+		if err := readJSON(*shiftsFile, &previous); err != nil {
+			return err
+		}
+	}
+	loc := sc.Config.Shifts.Location()
+	y, m, d := time.Now().In(loc).Date()
+	start := time.Date(y, m, d, 0, 0, 0, 0, loc)
+	if *startDate != "" {
+		var err error
+		if start, err = time.ParseInLocation("2006-01-02", *startDate, loc); err != nil {
+			return err
+		}
+	}
+	if *n == 0 {
+		*n = sc.Config.ShiftsToSchedule
+	}
+	var mods []string
+	if *modifiers != "" {
+		mods = strings.Split(*modifiers, ",")
+	}
+	if *seed != 0 {
+		sc.Config.Shifts.Seed = *seed
+	}
+
+	sims, err := gs.Simulate(sc, start, previous, members, *n, mods)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "Generator\tShift spread\tHours spread\tWeekend spread\tViolations\t")
+	for _, s := range sims {
+		if s.Err != nil {
+			fmt.Fprintf(tw, "%s\terror: %v\t\t\t\t\n", s.Generator, s.Err)
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%.1f\t%.1f\t%d\t\n", s.Generator, s.ShiftSpread, s.HoursSpread, s.WeekendSpread, len(s.Violations))
+	}
+	tw.Flush()
+
+	for _, s := range sims {
+		if len(s.Violations) == 0 && !*verbose {
+			continue
+		}
+		fmt.Printf("\n%s\n", s.Generator)
+		for _, v := range s.Violations {
+			fmt.Printf("  %s\n", v)
+		}
+		if !*verbose {
+			continue
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(tw, "  Member\tShifts\tHours\tWeekend\tHoliday\tNight\tLongest gap\t")
+		for _, ms := range s.Stats {
+			fmt.Fprintf(tw, "  %s\t%d\t%.1f\t%.1f\t%.1f\t%.1f\t%s\t\n", ms.Email, ms.Shifts, ms.Hours, ms.WeekendHours, ms.HolidayHours, ms.NightHours, ms.LongestGap)
+		}
+		tw.Flush()
+	}
+	return nil
+}
+
+// readMembers reads the members in file, the TZ of a member is the name of a time zone, eg. "Europe/Amsterdam".
+func readMembers(file string) ([]rotang.Member, error) {
+	var ms []struct {
+		rotang.Member
+		TZ string
+	}
+	if err := readJSON(file, &ms); err != nil {
+		return nil, err
+	}
+	res := make([]rotang.Member, len(ms))
+	for i, m := range ms {
+		res[i] = m.Member
+		if m.TZ == "" {
+			continue
+		}
+		loc, err := time.LoadLocation(m.TZ)
+		if err != nil {
+			return nil, fmt.Errorf("member: %q: %v", m.Email, err)
+		}
+		res[i].TZ = *loc
+	}
+	return res, nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"time"
+
+	rotang "github.com/miekg/rota"
+	"go.chromium.org/luci/server/router"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// noCalendar is used when no calendar backend is configured.
+// Shifts are kept in the store only and no events are created.
+type noCalendar struct{}
+
+var _ rotang.Calenderer = &noCalendar{}
+
+func (n *noCalendar) CreateEvent(_ *router.Context, _ *rotang.Configuration, shifts []rotang.ShiftEntry, _ bool) ([]rotang.ShiftEntry, error) {
+	return shifts, nil
+}
+
+func (n *noCalendar) UpdateEvent(_ *router.Context, _ *rotang.Configuration, updated *rotang.ShiftEntry) (*rotang.ShiftEntry, error) {
+	return updated, nil
+}
+
+func (n *noCalendar) DeleteEvent(_ *router.Context, _ *rotang.Configuration, _ *rotang.ShiftEntry) error {
+	return nil
+}
+
+// Event returns the shift as is, making the stored shifts the source of truth.
+func (n *noCalendar) Event(_ *router.Context, _ *rotang.Configuration, shift *rotang.ShiftEntry) (*rotang.ShiftEntry, error) {
+	return shift, nil
+}
+
+func (n *noCalendar) Events(_ *router.Context, _ *rotang.Configuration, _, _ time.Time) ([]rotang.ShiftEntry, error) {
+	return nil, nil
+}
+
+func (n *noCalendar) TrooperOncall(_ *router.Context, _, _ string, _ time.Time) ([]string, error) {
+	return nil, status.Errorf(codes.Unimplemented, "no calendar configured")
+}
+
+func (n *noCalendar) TrooperShifts(_ *router.Context, _, _ string, _, _ time.Time) ([]rotang.ShiftEntry, error) {
+	return nil, status.Errorf(codes.Unimplemented, "no calendar configured")
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	rotang "github.com/miekg/rota"
+	"github.com/miekg/rota/pkg/auth"
+	"github.com/miekg/rota/pkg/calendar/caldav"
+	"github.com/miekg/rota/pkg/calendar/ics"
+	"github.com/miekg/rota/pkg/storage/bolt"
+	"github.com/miekg/rota/pkg/storage/memory"
+)
+
+// Config is the rotad configuration file, stored as JSON.
+type Config struct {
+	// Listen is the address the HTTP server listens on, defaults to ":8080".
+	Listen string
+	// Env is one of "production", "staging" or "local", defaults to "production".
+	Env string
+	// ProjectID identifies this rota installation.
+	ProjectID string
+	// Templates is the directory containing the HTML templates, defaults to "templates".
+	Templates string
+	// MailAddress is the sender address used for e-mails.
+	MailAddress string
+	// ShutdownTimeout is how long to wait for in-flight requests on shutdown, defaults to "10s".
+	ShutdownTimeout string
+	// CronToken must be sent as a bearer token to trigger the recurring jobs on /cron/*,
+	// the jobs are refused when empty.
+	CronToken string
+	// ICSKey is the secret the URLs of the rota and member iCalendar feeds are signed with, the
+	// feeds are not served when empty. Changing it invalidates the subscribed URLs.
+	ICSKey string
+	// HolidayDir is the directory with the holiday files rotations can use, only the built-in
+	// holiday regions are available when empty.
+	HolidayDir string
+
+	Storage  BackendConfig
+	Mail     BackendConfig
+	Calendar BackendConfig
+	Auth     AuthConfig
+}
+
+// BackendConfig selects a backend by name. The remaining fields are backend specific.
+type BackendConfig struct {
+	Backend string
+	// Path is used by the file based backends.
+	Path string
+	// Addr is the host:port of a remote service, eg. the SMTP server.
+	Addr string
+	// URL is the base URL of a remote service, eg. the CalDAV server. The CalDAV calendar
+	// only uses the collections under it.
+	URL      string
+	Username string
+	Password string
+}
+
+// AuthConfig configures authentication and authorization.
+type AuthConfig struct {
+	// Backend is one of "basic" or "proxy", defaults to "basic".
+	Backend string
+	// UsersFile is the static users file, see auth.Users. It holds the passwords
+	// for basic auth, and the admins and groups for all backends.
+	UsersFile string
+	// AccessGroup is the group users must be part of to use the service, empty
+	// lets in all authenticated users.
+	AccessGroup string
+	// Realm is the basic auth realm.
+	Realm string
+	// Trusted lists the addresses or networks of the proxies trusted to set the user headers.
+	Trusted []string
+	// EmailHeader, NameHeader and GroupsHeader override the default proxy headers.
+	EmailHeader  string
+	NameHeader   string
+	GroupsHeader string
+	// LoginURL is where the proxy backend redirects unauthenticated users.
+	LoginURL string
+}
+
+const (
+	defaultListen          = ":8080"
+	defaultEnv             = "production"
+	defaultTemplates       = "templates"
+	defaultShutdownTimeout = 10 * time.Second
+)
+
+// loadConfig reads the configuration file and fills in defaults.
+func loadConfig(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var cfg Config
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("parsing %q failed: %v", path, err)
+	}
+	if cfg.Listen == "" {
+		cfg.Listen = defaultListen
+	}
+	if cfg.Env == "" {
+		cfg.Env = defaultEnv
+	}
+	switch cfg.Env {
+	case "production", "local", "staging":
+	default:
+		return nil, fmt.Errorf("Env must be one of `production`, `local` or `staging`, got: %q", cfg.Env)
+	}
+	if cfg.Templates == "" {
+		cfg.Templates = defaultTemplates
+	}
+	if _, err := cfg.shutdownTimeout(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+func (c *Config) shutdownTimeout() (time.Duration, error) {
+	if c.ShutdownTimeout == "" {
+		return defaultShutdownTimeout, nil
+	}
+	d, err := time.ParseDuration(c.ShutdownTimeout)
+	if err != nil {
+		return 0, fmt.Errorf("ShutdownTimeout: %v", err)
+	}
+	return d, nil
+}
+
+// store combines the storer interfaces, all storage backends implement it.
+type store interface {
+	rotang.MemberStorer
+	rotang.ShiftStorer
+	rotang.ConfigStorer
+}
+
+// The backend maps contain the available backends by name.
+var (
+	storeBackends = map[string]func(*BackendConfig) (store, error){
+		"memory": func(_ *BackendConfig) (store, error) {
+			return memory.New(), nil
+		},
+		"bolt": func(bc *BackendConfig) (store, error) {
+			if bc.Path == "" {
+				return nil, fmt.Errorf("bolt storage needs a Path")
+			}
+			return bolt.New(bc.Path)
+		},
+	}
+
+	mailBackends = map[string]func(*BackendConfig) (rotang.MailSender, error){
+		"log": func(_ *BackendConfig) (rotang.MailSender, error) {
+			return &logMailer{}, nil
+		},
+		"smtp": func(bc *BackendConfig) (rotang.MailSender, error) {
+			if bc.Addr == "" {
+				return nil, fmt.Errorf("smtp mail needs an Addr")
+			}
+			return newSMTPMailer(bc), nil
+		},
+	}
+
+	calendarBackends = map[string]func(*BackendConfig) (rotang.Calenderer, error){
+		"none": func(_ *BackendConfig) (rotang.Calenderer, error) {
+			return &noCalendar{}, nil
+		},
+		"ics": func(bc *BackendConfig) (rotang.Calenderer, error) {
+			if bc.Path == "" {
+				return nil, fmt.Errorf("ics calendar needs a Path")
+			}
+			return ics.New(bc.Path)
+		},
+		"caldav": func(bc *BackendConfig) (rotang.Calenderer, error) {
+			if bc.URL == "" {
+				return nil, fmt.Errorf("caldav calendar needs a URL")
+			}
+			return caldav.New(nil, bc.URL, bc.Username, bc.Password)
+		},
+	}
+)
+
+func newAuth(ac *AuthConfig) (rotang.Authenticator, rotang.Authorizer, error) {
+	users := &auth.Users{}
+	if ac.UsersFile != "" {
+		var err error
+		if users, err = auth.LoadUsers(ac.UsersFile); err != nil {
+			return nil, nil, err
+		}
+	}
+	name := ac.Backend
+	if name == "" {
+		name = "basic"
+	}
+	switch name {
+	case "basic":
+		if ac.UsersFile == "" {
+			return nil, nil, fmt.Errorf("basic auth needs a UsersFile")
+		}
+		return auth.NewBasic(users, ac.Realm), users, nil
+	case "proxy":
+		p, err := auth.NewProxy(ac.Trusted)
+		if err != nil {
+			return nil, nil, err
+		}
+		p.EmailHeader, p.NameHeader, p.GroupsHeader = ac.EmailHeader, ac.NameHeader, ac.GroupsHeader
+		p.LoginURL = ac.LoginURL
+		return p, users, nil
+	}
+	return nil, nil, fmt.Errorf("auth backend: %q not found", name)
+}
+
+// newStore returns the storage backend, only local installations default to the memory backend.
+func newStore(bc *BackendConfig, env string) (store, error) {
+	name := bc.Backend
+	if name == "" {
+		if env != "local" {
+			return nil, fmt.Errorf("Storage.Backend must be set for the %s environment", env)
+		}
+		name = "memory"
+	}
+	if name == "memory" && env != "local" {
+		log.Printf("WARNING: the memory storage backend loses all rotations, members and shifts on restart")
+	}
+	f, ok := storeBackends[name]
+	if !ok {
+		return nil, fmt.Errorf("storage backend: %q not found", name)
+	}
+	return f(bc)
+}
+
+func newMailSender(bc *BackendConfig) (rotang.MailSender, error) {
+	name := bc.Backend
+	if name == "" {
+		name = "log"
+	}
+	f, ok := mailBackends[name]
+	if !ok {
+		return nil, fmt.Errorf("mail backend: %q not found", name)
+	}
+	return f(bc)
+}
+
+func newCalendar(bc *BackendConfig) (rotang.Calenderer, error) {
+	name := bc.Backend
+	if name == "" {
+		name = "none"
+	}
+	f, ok := calendarBackends[name]
+	if !ok {
+		return nil, fmt.Errorf("calendar backend: %q not found", name)
+	}
+	return f(bc)
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+	"go.chromium.org/gae/service/mail"
+)
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		fail   bool
+		config string
+		want   *Config
+	}{{
+		name:   "Defaults",
+		config: `{}`,
+		want: &Config{
+			Listen:    defaultListen,
+			Env:       defaultEnv,
+			Templates: defaultTemplates,
+		},
+	}, {
+		name: "Bolt storage",
+		config: `{
+			"Listen": "localhost:9090",
+			"Env": "local",
+			"Storage": {"Backend": "bolt", "Path": "/tmp/rota.db"}
+		}`,
+		want: &Config{
+			Listen:    "localhost:9090",
+			Env:       "local",
+			Templates: defaultTemplates,
+			Storage: BackendConfig{
+				Backend: "bolt",
+				Path:    "/tmp/rota.db",
+			},
+		},
+	}, {
+		name:   "Unknown field",
+		fail:   true,
+		config: `{"Listn": ":80"}`,
+	}, {
+		name:   "Bad env",
+		fail:   true,
+		config: `{"Env": "dev"}`,
+	}, {
+		name:   "Bad timeout",
+		fail:   true,
+		config: `{"ShutdownTimeout": "soon"}`,
+	},
+	}
+
+	dir, err := ioutil.TempDir("", "rotad")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, tst := range tests {
+		path := filepath.Join(dir, "rotad.json")
+		if err := ioutil.WriteFile(path, []byte(tst.config), 0644); err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := loadConfig(path)
+		if got, want := (err != nil), tst.fail; got != want {
+			t.Errorf("%s: loadConfig(_) = %t want: %t, err: %v", tst.name, got, want, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if diff := pretty.Compare(tst.want, cfg); diff != "" {
+			t.Errorf("%s: loadConfig(_) differ -want +got, %s", tst.name, diff)
+		}
+	}
+}
+
+func TestBackends(t *testing.T) {
+	tests := []struct {
+		name string
+		fail bool
+		new  func() error
+	}{{
+		name: "Default storage",
+		new: func() error {
+			_, err := newStore(&BackendConfig{}, "local")
+			return err
+		},
+	}, {
+		name: "Default storage in production",
+		fail: true,
+		new: func() error {
+			_, err := newStore(&BackendConfig{}, "production")
+			return err
+		},
+	}, {
+		name: "Memory storage in production",
+		new: func() error {
+			_, err := newStore(&BackendConfig{Backend: "memory"}, "production")
+			return err
+		},
+	}, {
+		name: "Bolt without path",
+		fail: true,
+		new: func() error {
+			_, err := newStore(&BackendConfig{Backend: "bolt"}, "local")
+			return err
+		},
+	}, {
+		name: "Unknown storage",
+		fail: true,
+		new: func() error {
+			_, err := newStore(&BackendConfig{Backend: "datastore"}, "local")
+			return err
+		},
+	}, {
+		name: "SMTP without addr",
+		fail: true,
+		new: func() error {
+			_, err := newMailSender(&BackendConfig{Backend: "smtp"})
+			return err
+		},
+	}, {
+		name: "SMTP",
+		new: func() error {
+			_, err := newMailSender(&BackendConfig{Backend: "smtp", Addr: "localhost:25", Username: "rota"})
+			return err
+		},
+	}, {
+		name: "Basic auth without users",
+		fail: true,
+		new: func() error {
+			_, _, err := newAuth(&AuthConfig{})
+			return err
+		},
+	}, {
+		name: "Proxy auth",
+		new: func() error {
+			_, _, err := newAuth(&AuthConfig{Backend: "proxy", Trusted: []string{"127.0.0.1"}})
+			return err
+		},
+	}, {
+		name: "Proxy auth without trusted proxies",
+		fail: true,
+		new: func() error {
+			_, _, err := newAuth(&AuthConfig{Backend: "proxy"})
+			return err
+		},
+	}, {
+		name: "ICS calendar without path",
+		fail: true,
+		new: func() error {
+			_, err := newCalendar(&BackendConfig{Backend: "ics"})
+			return err
+		},
+	}, {
+		name: "ICS calendar",
+		new: func() error {
+			_, err := newCalendar(&BackendConfig{Backend: "ics", Path: t.TempDir()})
+			return err
+		},
+	}, {
+		name: "CalDAV calendar without URL",
+		fail: true,
+		new: func() error {
+			_, err := newCalendar(&BackendConfig{Backend: "caldav", Username: "rota", Password: "secret"})
+			return err
+		},
+	}, {
+		name: "CalDAV calendar",
+		new: func() error {
+			_, err := newCalendar(&BackendConfig{Backend: "caldav", URL: "https://dav.example.com/calendars/", Username: "rota", Password: "secret"})
+			return err
+		},
+	}, {
+		name: "Unknown calendar",
+		fail: true,
+		new: func() error {
+			_, err := newCalendar(&BackendConfig{Backend: "google"})
+			return err
+		},
+	},
+	}
+
+	for _, tst := range tests {
+		err := tst.new()
+		if got, want := (err != nil), tst.fail; got != want {
+			t.Errorf("%s: new = %t want: %t, err: %v", tst.name, got, want, err)
+		}
+	}
+}
+
+func TestBuildMessage(t *testing.T) {
+	now := time.Date(2018, 10, 17, 12, 0, 0, 0, time.UTC)
+	msg := &mail.Message{
+		Sender:  "rota@example.com",
+		To:      []string{"a@example.com", "b@example.com"},
+		Subject: "Oncall\r\nBcc: evil@example.com",
+		Body:    "line1\nline2",
+	}
+	want := strings.Join([]string{
+		"From: rota@example.com",
+		"To: a@example.com, b@example.com",
+		"Subject: OncallBcc: evil@example.com",
+		"Date: Wed, 17 Oct 2018 12:00:00 +0000",
+		"MIME-Version: 1.0",
+		`Content-Type: text/plain; charset="utf-8"`,
+		"",
+		"line1",
+		"line2",
+	}, "\r\n")
+	if got := string(buildMessage(msg, now)); got != want {
+		t.Errorf("buildMessage(_) differ -want +got, %s", pretty.Compare(want, got))
+	}
+}
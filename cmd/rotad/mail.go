@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	rotang "github.com/miekg/rota"
+	"go.chromium.org/gae/service/mail"
+	"go.chromium.org/luci/common/logging"
+)
+
+// logMailer logs e-mails instead of sending them.
+type logMailer struct{}
+
+var _ rotang.MailSender = &logMailer{}
+
+func (l *logMailer) Send(ctx context.Context, msg *mail.Message) error {
+	logging.Infof(ctx, "mail from: %q to: %v subject: %q\n%s", msg.Sender, msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// smtpMailer sends e-mails through an SMTP server.
+type smtpMailer struct {
+	addr string
+	auth smtp.Auth
+}
+
+var _ rotang.MailSender = &smtpMailer{}
+
+func newSMTPMailer(bc *BackendConfig) *smtpMailer {
+	s := &smtpMailer{addr: bc.Addr}
+	if bc.Username != "" {
+		host, _, err := net.SplitHostPort(bc.Addr)
+		if err != nil {
+			host = bc.Addr
+		}
+		s.auth = smtp.PlainAuth("", bc.Username, bc.Password, host)
+	}
+	return s
+}
+
+func (s *smtpMailer) Send(ctx context.Context, msg *mail.Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	rcpt := append(append(append([]string{}, msg.To...), msg.Cc...), msg.Bcc...)
+	if len(rcpt) == 0 {
+		return fmt.Errorf("mail has no recipients")
+	}
+	return smtp.SendMail(s.addr, s.auth, msg.Sender, rcpt, buildMessage(msg, time.Now()))
+}
+
+// buildMessage formats msg as a plain text RFC 5322 message.
+func buildMessage(msg *mail.Message, now time.Time) []byte {
+	var buf bytes.Buffer
+	header := func(k, v string) {
+		// Strip newlines to avoid header injection through the templates.
+		v = strings.NewReplacer("\r", "", "\n", "").Replace(v)
+		fmt.Fprintf(&buf, "%s: %s\r\n", k, v)
+	}
+	header("From", msg.Sender)
+	header("To", strings.Join(msg.To, ", "))
+	if len(msg.Cc) > 0 {
+		header("Cc", strings.Join(msg.Cc, ", "))
+	}
+	if msg.ReplyTo != "" {
+		header("Reply-To", msg.ReplyTo)
+	}
+	header("Subject", msg.Subject)
+	header("Date", now.Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	header("Content-Type", `text/plain; charset="utf-8"`)
+	buf.WriteString("\r\n")
+	buf.WriteString(strings.Replace(msg.Body, "\n", "\r\n", -1))
+	return buf.Bytes()
+}
@@ -0,0 +1,210 @@
+// Command rotad runs the rota web application as a standalone HTTP server.
+//
+// Storage, mail, calendar and auth backends are selected by name in the JSON configuration file, see Config.
+// Recurring jobs are served on /cron/* and are expected to be triggered by an external scheduler, eg. cron(8),
+// sending the CronToken of the configuration as a bearer token.
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"flag"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	rotang "github.com/miekg/rota"
+	"github.com/miekg/rota/cmd/handlers"
+	"github.com/miekg/rota/pkg/algo"
+	"go.chromium.org/luci/common/logging"
+	"go.chromium.org/luci/common/logging/gologger"
+	"go.chromium.org/luci/server/router"
+	"go.chromium.org/luci/server/templates"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var configFile = flag.String("config", "rotad.json", "path to the configuration file")
+
+func main() {
+	flag.Parse()
+
+	cfg, err := loadConfig(*configFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ctx := gologger.StdConfig.Use(context.Background())
+
+	h, st, err := newServer(ctx, cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	timeout, _ := cfg.shutdownTimeout()
+	srv := &http.Server{
+		Addr:    cfg.Listen,
+		Handler: h,
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		<-sig
+		logging.Infof(ctx, "shutting down")
+		sctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		if err := srv.Shutdown(sctx); err != nil {
+			logging.Errorf(ctx, "shutdown failed: %v", err)
+		}
+	}()
+
+	logging.Infof(ctx, "listening on %s", cfg.Listen)
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+	<-done
+	if c, ok := st.(io.Closer); ok {
+		if err := c.Close(); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// newServer sets up the backends and routes.
+func newServer(ctx context.Context, cfg *Config) (http.Handler, store, error) {
+	st, err := newStore(&cfg.Storage, cfg.Env)
+	if err != nil {
+		return nil, nil, err
+	}
+	mailer, err := newMailSender(&cfg.Mail)
+	if err != nil {
+		return nil, nil, err
+	}
+	cal, err := newCalendar(&cfg.Calendar)
+	if err != nil {
+		return nil, nil, err
+	}
+	authn, authz, err := newAuth(&cfg.Auth)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	algo.HolidayDir = cfg.HolidayDir
+
+	// Sort out the generators.
+	gs := algo.New()
+	gs.Register(algo.NewFair())
+	gs.Register(algo.NewRandomGen())
+	gs.Register(algo.NewOptimal())
+	gs.Register(algo.NewTZFair())
+	gs.Register(algo.NewShadow(algo.NewFair()))
+	gs.Register(algo.NewFollowTheSun())
+	gs.Register(algo.NewFairHours())
+
+	// And the modifiers.
+	gs.RegisterModifier(algo.NewWeekendSkip())
+	gs.RegisterModifier(algo.NewSplitShift())
+	gs.RegisterModifier(algo.NewHolidaySkip())
+
+	opts := handlers.Options{
+		ProjectID: func(context.Context) string {
+			return cfg.ProjectID
+		},
+		ProdENV:        cfg.Env,
+		Calendar:       cal,
+		LegacyCalendar: cal,
+		Generators:     gs,
+		MailSender:     mailer,
+		MailAddress:    cfg.MailAddress,
+		BackupCred: func(*router.Context) (*http.Client, error) {
+			return nil, status.Errorf(codes.Unimplemented, "backups are handled by the storage backend")
+		},
+		MemberStore: func(context.Context) rotang.MemberStorer {
+			return st
+		},
+		ShiftStore: func(context.Context) rotang.ShiftStorer {
+			return st
+		},
+		ConfigStore: func(context.Context) rotang.ConfigStorer {
+			return st
+		},
+		Authenticator: authn,
+		Authorizer:    authz,
+		AccessGroup:   cfg.Auth.AccessGroup,
+		ICSKey:        []byte(cfg.ICSKey),
+	}
+	h, err := handlers.New(&opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r := router.NewWithRootContext(ctx)
+	tmw := router.NewMiddlewareChain(templates.WithTemplates(&templates.Bundle{
+		Loader: templates.FileSystemLoader(cfg.Templates),
+	}))
+	protected := tmw.Extend(h.RequireAccess)
+
+	r.GET("/", protected, h.HandleIndex)
+	r.GET("/upload", protected, h.HandleUpload)
+	r.GET("/list", protected, h.HandleList)
+	r.GET("/createrota", protected, h.HandleRotaCreate)
+	r.GET("/managerota", protected, h.HandleManageRota)
+	r.GET("/modifyrota", protected, h.HandleRotaModify)
+	r.GET("/importshiftsjson", protected, h.HandleShiftImportJSON)
+	r.GET("/manageshifts", protected, h.HandleManageShifts)
+	r.GET("/legacy/:name", tmw, h.HandleLegacy)
+	r.GET("/ics/rota/:name/:token", tmw, h.HandleRotaICS)
+	r.GET("/ics/member/:email/:token", tmw, h.HandleMemberICS)
+	r.GET("/oncall", protected, h.HandleOncall)
+	r.GET("/oncall/:name", protected, h.HandleOncall)
+	r.GET("/memberjson", protected, h.HandleMember)
+	r.GET("/switchlist", protected, h.HandleRotaSwitchList)
+	r.GET("/switchrota", protected, h.HandleRotaSwitch)
+	r.GET("/caltest", protected, h.HandleCalTest)
+	r.GET("/emailtest", protected, h.HandleEmailTest)
+	r.GET("/emailjsontest", protected, h.HandleEmailTestJSON)
+	r.GET("/emailsendtest", protected, h.HandleEmailTestSend)
+	r.GET("/stats", protected, h.HandleStats)
+
+	r.POST("/oncalljson", protected, h.HandleOncallJSON)
+	r.POST("/shiftsupdate", protected, h.HandleShiftUpdate)
+	r.POST("/shiftsgenerate", protected, h.HandleShiftGenerate)
+	r.POST("/shiftswap", protected, h.HandleShiftSwap)
+	r.POST("/generate", protected, h.HandleGenerate)
+	r.POST("/modifyrota", protected, h.HandleRotaModify)
+	r.POST("/createrota", protected, h.HandleRotaCreate)
+	r.POST("/deleterota", protected, h.HandleDeleteRota)
+	r.POST("/upload", protected, h.HandleUpload)
+	r.POST("/enabledisable", protected, h.HandleEnableDisable)
+	r.POST("/memberjson", protected, h.HandleMember)
+
+	// Recurring jobs.
+	if cfg.CronToken == "" {
+		logging.Warningf(ctx, "no CronToken configured, the recurring jobs on /cron/* are refused")
+	}
+	cron := tmw.Extend(requireCronToken(cfg.CronToken))
+	r.GET("/cron/joblegacy", cron, h.JobLegacy)
+	r.GET("/cron/email", cron, h.JobEmail)
+	r.GET("/cron/schedule", cron, h.JobSchedule)
+	r.GET("/cron/eventupdate", cron, h.JobEventUpdate)
+	r.GET("/cron/oooimport", cron, h.JobOOOImport)
+
+	return r, st, nil
+}
+
+// requireCronToken only lets through requests with the bearer token, all requests are refused when token is empty.
+func requireCronToken(token string) router.Middleware {
+	return func(ctx *router.Context, next router.Handler) {
+		got := strings.TrimPrefix(ctx.Request.Header.Get("Authorization"), "Bearer ")
+		if token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			http.Error(ctx.Writer, "forbidden", http.StatusForbidden)
+			return
+		}
+		next(ctx)
+	}
+}
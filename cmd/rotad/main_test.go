@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.chromium.org/luci/server/router"
+)
+
+func TestRequireCronToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		token  string
+		header string
+		want   bool
+	}{{
+		name:   "Token",
+		token:  "secret",
+		header: "Bearer secret",
+		want:   true,
+	}, {
+		name:   "Wrong token",
+		token:  "secret",
+		header: "Bearer wrong",
+	}, {
+		name:  "No token sent",
+		token: "secret",
+	}, {
+		name:   "No token configured",
+		header: "Bearer ",
+	},
+	}
+
+	for _, tst := range tests {
+		req := httptest.NewRequest("GET", "/cron/email", nil)
+		if tst.header != "" {
+			req.Header.Set("Authorization", tst.header)
+		}
+		recorder := httptest.NewRecorder()
+		var called bool
+		requireCronToken(tst.token)(&router.Context{
+			Context: context.Background(),
+			Writer:  recorder,
+			Request: req,
+		}, func(*router.Context) {
+			called = true
+		})
+		if called != tst.want {
+			t.Errorf("%s: requireCronToken(%q) called the job = %t want: %t", tst.name, tst.token, called, tst.want)
+		}
+		if !tst.want && recorder.Code != http.StatusForbidden {
+			t.Errorf("%s: requireCronToken(%q) = %d want: %d", tst.name, tst.token, recorder.Code, http.StatusForbidden)
+		}
+	}
+}
@@ -3,23 +3,32 @@ module github.com/miekg/rota
 go 1.22
 
 require (
-	github.com/dustin/go-humanize v1.0.0 // indirect
-	github.com/golang/protobuf v1.2.1-0.20190205222052-c823c79ea157 // indirect
-	github.com/gopherjs/gopherjs v0.0.0-20181103185306-d547d1d9531e // indirect
-	github.com/jtolds/gls v4.2.1+incompatible // indirect
 	github.com/julienschmidt/httprouter v1.2.0
 	github.com/kylelemons/godebug v0.0.0-20170820004349-d65d576e9348
-	github.com/luci/gtreap v0.0.0-20161228054646-35df89791e8f // indirect
-	github.com/mitchellh/go-homedir v1.1.0 // indirect
-	github.com/op/go-logging v0.0.0-20160315200505-970db520ece7 // indirect
-	github.com/smartystreets/assertions v0.0.0-20190215210624-980c5ac6f3ac // indirect
-	github.com/smartystreets/goconvey v0.0.0-20181108003508-044398e4856c // indirect
 	go.chromium.org/gae v0.0.0-20180903135824-2e2072ed4889
 	go.chromium.org/luci v0.0.0-20190216021511-147bb2c6d6e4
-	golang.org/x/crypto v0.0.0-20190211182817-74369b46fc67 // indirect
+	go.etcd.io/bbolt v1.3.10
+	golang.org/x/crypto v0.0.0-20190211182817-74369b46fc67
 	golang.org/x/net v0.0.0-20190213061140-3a22650c66bd
 	golang.org/x/oauth2 v0.0.0-20190212230446-3e8b2be13635
 	google.golang.org/api v0.1.0
 	google.golang.org/appengine v1.4.0
 	google.golang.org/grpc v1.18.0
 )
+
+require (
+	cloud.google.com/go v0.34.0 // indirect
+	github.com/dustin/go-humanize v1.0.0 // indirect
+	github.com/golang/protobuf v1.2.1-0.20190205222052-c823c79ea157 // indirect
+	github.com/gopherjs/gopherjs v0.0.0-20181103185306-d547d1d9531e // indirect
+	github.com/jtolds/gls v4.2.1+incompatible // indirect
+	github.com/luci/gtreap v0.0.0-20161228054646-35df89791e8f // indirect
+	github.com/mitchellh/go-homedir v1.1.0 // indirect
+	github.com/op/go-logging v0.0.0-20160315200505-970db520ece7 // indirect
+	github.com/smartystreets/assertions v0.0.0-20190215210624-980c5ac6f3ac // indirect
+	github.com/smartystreets/goconvey v0.0.0-20181108003508-044398e4856c // indirect
+	golang.org/x/sys v0.4.0 // indirect
+	golang.org/x/text v0.3.0 // indirect
+	google.golang.org/genproto v0.0.0-20181202183823-bd91e49a0898 // indirect
+	gopkg.in/yaml.v2 v2.2.1 // indirect
+)
@@ -0,0 +1,112 @@
+package algo
+
+import (
+	"sort"
+	"time"
+
+	rotang "github.com/miekg/rota"
+)
+
+// Absent returns why the member can't be scheduled for a shift, SkippedOOO when out of office and
+// SkippedUnavailable during one of the Member.Unavailable periods, or an empty Reason if the member
+// can be scheduled. With ShiftConfig.MaxOverlap set, members absent for less than MaxOverlap of the
+// shift can be scheduled.
+func Absent(sc *rotang.ShiftConfig, shiftStart time.Time, shiftDays int, shiftDuration time.Duration, member rotang.Member) rotang.Reason {
+	ooo, unavailable := absences(shiftStart, shiftDays, shiftDuration, member)
+	if sc.MaxOverlap <= 0 {
+		switch {
+		case PersonalOutage(shiftStart, shiftDays, shiftDuration, member):
+			return rotang.SkippedOOO
+		case len(unavailable) > 0:
+			return rotang.SkippedUnavailable
+		}
+		return ""
+	}
+	if overlap(append(append([][2]time.Time{}, ooo...), unavailable...)) < sc.MaxOverlap {
+		return ""
+	}
+	if len(ooo) > 0 {
+		return rotang.SkippedOOO
+	}
+	return rotang.SkippedUnavailable
+}
+
+// absences returns the parts of the shift the member is out of office, and unavailable.
+func absences(shiftStart time.Time, shiftDays int, shiftDuration time.Duration, member rotang.Member) ([][2]time.Time, [][2]time.Time) {
+	loc := time.UTC
+	if member.TZ.String() != "" {
+		tz := member.TZ
+		loc = &tz
+	}
+	var ooo, unavailable [][2]time.Time
+	for i := 0; i < shiftDays; i++ {
+		dayStart := shiftStart.Add(time.Duration(i) * fullDay)
+		dayEnd := dayStart.Add(shiftDuration)
+		for _, o := range member.OOO {
+			if iv, ok := clip(o.Start, o.Start.Add(o.Duration), dayStart, dayEnd); ok {
+				ooo = append(ooo, iv)
+			}
+		}
+		// Periods starting the day before can last into the shift.
+		y, m, d := dayStart.In(loc).Date()
+		for day := time.Date(y, m, d-1, 0, 0, 0, 0, loc); day.Before(dayEnd); day = nextMidnight(day) {
+			for _, u := range member.Unavailable {
+				if !onWeekday(u.Weekdays, day.Weekday()) {
+					continue
+				}
+				from := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, int(u.From/time.Second), 0, loc)
+				to := nextMidnight(day)
+				if u.To > 0 {
+					to = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, int(u.To/time.Second), 0, loc)
+				}
+				if iv, ok := clip(from, to, dayStart, dayEnd); ok {
+					unavailable = append(unavailable, iv)
+				}
+			}
+		}
+	}
+	return ooo, unavailable
+}
+
+// clip returns the part of [start, end) within [from, to), false if they don't overlap.
+func clip(start, end, from, to time.Time) ([2]time.Time, bool) {
+	if start.Before(from) {
+		start = from
+	}
+	if end.After(to) {
+		end = to
+	}
+	return [2]time.Time{start, end}, start.Before(end)
+}
+
+// onWeekday is true if wd is one of the weekdays, or weekdays is empty.
+func onWeekday(weekdays []time.Weekday, wd time.Weekday) bool {
+	if len(weekdays) == 0 {
+		return true
+	}
+	for _, w := range weekdays {
+		if w == wd {
+			return true
+		}
+	}
+	return false
+}
+
+// overlap returns the time covered by the intervals, counting overlapping intervals once.
+func overlap(intervals [][2]time.Time) time.Duration {
+	sort.Slice(intervals, func(i, j int) bool {
+		return intervals[i][0].Before(intervals[j][0])
+	})
+	var res time.Duration
+	var end time.Time
+	for _, iv := range intervals {
+		if iv[0].Before(end) {
+			iv[0] = end
+		}
+		if iv[0].Before(iv[1]) {
+			res += iv[1].Sub(iv[0])
+			end = iv[1]
+		}
+	}
+	return res
+}
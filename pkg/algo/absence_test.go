@@ -0,0 +1,117 @@
+package algo
+
+import (
+	"testing"
+	"time"
+
+	rotang "github.com/miekg/rota"
+)
+
+func TestAbsent(t *testing.T) {
+	// midnight is a Wednesday.
+	friday := midnight.Add(2 * fullDay)
+	fridayAfternoon := []rotang.Unavailable{{
+		Weekdays: []time.Weekday{time.Friday},
+		From:     14 * time.Hour,
+	}}
+
+	tests := []struct {
+		name       string
+		maxOverlap time.Duration
+		start      time.Time
+		days       int
+		member     rotang.Member
+		want       rotang.Reason
+	}{{
+		name:   "Available",
+		start:  friday.Add(8 * time.Hour),
+		days:   1,
+		member: rotang.Member{Email: "A@A.com"},
+	}, {
+		name:  "Out of office",
+		start: friday.Add(8 * time.Hour),
+		days:  1,
+		member: rotang.Member{
+			Email: "A@A.com",
+			OOO: []rotang.OOO{{
+				Start:    friday,
+				Duration: 10 * time.Hour,
+				Type:     rotang.Sick,
+			}},
+		},
+		want: rotang.SkippedOOO,
+	}, {
+		name:  "Unavailable friday afternoon",
+		start: friday.Add(8 * time.Hour),
+		days:  1,
+		member: rotang.Member{
+			Email:       "A@A.com",
+			Unavailable: fridayAfternoon,
+		},
+		want: rotang.SkippedUnavailable,
+	}, {
+		name:  "Friday afternoon on a thursday",
+		start: friday.Add(-fullDay + 8*time.Hour),
+		days:  1,
+		member: rotang.Member{
+			Email:       "A@A.com",
+			Unavailable: fridayAfternoon,
+		},
+	}, {
+		name:  "Part-time spanning a day off",
+		start: midnight.Add(8 * time.Hour),
+		days:  3,
+		member: rotang.Member{
+			Email: "A@A.com",
+			Unavailable: []rotang.Unavailable{{
+				Weekdays: []time.Weekday{time.Thursday},
+			}},
+		},
+		want: rotang.SkippedUnavailable,
+	}, {
+		name:       "Overlap below MaxOverlap",
+		maxOverlap: 4 * time.Hour,
+		start:      friday.Add(8 * time.Hour),
+		days:       1,
+		member: rotang.Member{
+			Email:       "A@A.com",
+			Unavailable: fridayAfternoon,
+		},
+	}, {
+		name:       "Overlapping absences counted once",
+		maxOverlap: 4 * time.Hour,
+		start:      friday.Add(8 * time.Hour),
+		days:       1,
+		member: rotang.Member{
+			Email: "A@A.com",
+			OOO: []rotang.OOO{{
+				Start:    friday.Add(12 * time.Hour),
+				Duration: 3 * time.Hour,
+				Type:     rotang.Conference,
+			}},
+			Unavailable: fridayAfternoon,
+		},
+		want: rotang.SkippedOOO,
+	}, {
+		name:       "Overlapping absences below MaxOverlap",
+		maxOverlap: 5 * time.Hour,
+		start:      friday.Add(8 * time.Hour),
+		days:       1,
+		member: rotang.Member{
+			Email: "A@A.com",
+			OOO: []rotang.OOO{{
+				Start:    friday.Add(12 * time.Hour),
+				Duration: 3 * time.Hour,
+			}},
+			Unavailable: fridayAfternoon,
+		},
+	},
+	}
+
+	for _, tst := range tests {
+		sc := &rotang.ShiftConfig{MaxOverlap: tst.maxOverlap}
+		if got := Absent(sc, tst.start, tst.days, 8*time.Hour, tst.member); got != tst.want {
+			t.Errorf("%s: Absent(_, %v, %d, _, _) = %q, want: %q", tst.name, tst.start, tst.days, got, tst.want)
+		}
+	}
+}
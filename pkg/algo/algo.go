@@ -8,6 +8,7 @@ package algo
 import (
 	"fmt"
 	"math/rand"
+	"strings"
 	"time"
 
 	rotang "github.com/miekg/rota"
@@ -92,16 +93,21 @@ func (a *Generators) ListModifiers() []string {
 const fullDay = 24 * time.Hour
 
 // ShiftStartEnd calculates the start and end time of a shift.
+// Shift boundaries are calculated on the wall clock of the rotation time zone,
+// keeping shifts at the same time of day across DST changes.
 func ShiftStartEnd(start time.Time, shiftNumber, shiftIdx int, sc *rotang.ShiftConfig) (time.Time, time.Time) {
+	loc := sc.Location()
 	hour, minute, _ := sc.StartTime.Clock()
-	year, month, day := start.Date()
-	shiftStart := time.Date(year, month, day, hour, minute, 0, 0, time.UTC).Add(time.Duration(shiftNumber) *
-		time.Duration(sc.Length+sc.Skip) * fullDay)
+	year, month, day := start.In(loc).Date()
+	day += shiftNumber * (sc.Length + sc.Skip)
+	var offset time.Duration
 	for i := 0; i < shiftIdx; i++ {
-		shiftStart = shiftStart.Add(sc.Shifts[i].Duration)
+		offset += sc.Shifts[i].Duration
 	}
-	shiftEnd := shiftStart.Add(time.Duration(sc.Length) * fullDay)
-	shiftEnd = shiftEnd.Add(sc.Shifts[shiftIdx].Duration - fullDay)
+	// time.Date normalizes the overflowing seconds on the wall clock.
+	shiftStart := time.Date(year, month, day, hour, minute, int(offset/time.Second), 0, loc)
+	// The shift ends on the last day of the shift, after the shift duration.
+	shiftEnd := time.Date(year, month, day+sc.Length-1, hour, minute, int((offset+sc.Shifts[shiftIdx].Duration)/time.Second), 0, loc)
 	return shiftStart.In(start.Location()), shiftEnd.In(start.Location())
 }
 
@@ -121,16 +127,43 @@ func PersonalOutage(shiftStart time.Time, shiftDays int, shiftDuration time.Dura
 }
 
 // PersonalPreference checks the user's preferences before scheduling a rotation.
+// Weekday preferences are checked against the days the shift overlaps, in the
+// location of shiftStart.
 func PersonalPreference(shiftStart time.Time, shiftDays int, shiftDuration time.Duration, member rotang.Member) bool {
+	noDays := make(map[time.Weekday]bool)
 	for _, pref := range member.Preferences {
-		switch pref {
-		case rotang.NoOncall:
+		switch {
+		case pref == rotang.NoOncall:
 			return false
+		case pref == rotang.NoWeekends:
+			noDays[time.Saturday] = true
+			noDays[time.Sunday] = true
+		case pref >= rotang.NoMonday && pref <= rotang.NoSunday:
+			// NoMonday -> time.Monday (1) ... NoSunday -> time.Sunday (0).
+			noDays[time.Weekday((pref-rotang.NoMonday+1)%7)] = true
+		}
+	}
+	if len(noDays) == 0 {
+		return true
+	}
+	for i := 0; i < shiftDays; i++ {
+		todayStart := shiftStart.Add(time.Duration(i) * fullDay)
+		todayEnd := todayStart.Add(shiftDuration)
+		for d := todayStart; d.Before(todayEnd); d = nextMidnight(d) {
+			if noDays[d.Weekday()] {
+				return false
+			}
 		}
 	}
 	return true
 }
 
+// nextMidnight returns the start of the day following t.
+func nextMidnight(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day+1, 0, 0, 0, 0, t.Location())
+}
+
 // HandleShiftEntries is a helper function to split up a slice of ShiftEntries into a slice for each
 // configured shift.
 func HandleShiftEntries(sc *rotang.Configuration, shifts []rotang.ShiftEntry) [][]rotang.ShiftEntry {
@@ -178,12 +211,32 @@ func HandleShiftMembers(sc *rotang.Configuration, cm []rotang.Member) [][]rotang
 
 // MakeShifts takes a rota configuration and a slice of members. It generates the specified number of
 // ShiftEntries using the provided members in order. If the number of shifts to generate is larger than the
-// provided list of members the members assigned repeat. The function handles PersonalOutages, skip shifts
-// and split shifts.
+// provided list of members the members assigned repeat. The function handles absences, see Absent, PersonalPreferences,
+// skip shifts and split shifts.
+//
+// Members are skipped for shifts overlapping days they opted out of, in the rotation's time zone. If not enough
+// other members are available the skipped members are scheduled anyway and noted in the ShiftEntry Comment.
+// Shifts with fewer members than ShiftMembers get an UnderstaffedComment, see Understaffed.
+// With ShiftConfig.Trace set every member of the shift is recorded in the ShiftEntry Trace, members not
+// considered because the shift was full are Available.
 //
 // Eg. Members ["A", "B", "C", "D"] with shiftsToSchedule == 8 -> []rotang.ShiftEntry{"A", "B", "C", "D"}
 func MakeShifts(sc *rotang.Configuration, start time.Time, membersByShift [][]rotang.Member, shiftsToSchedule int) []rotang.ShiftEntry {
+	return MakeShiftsAfter(sc, start, nil, membersByShift, shiftsToSchedule)
+}
+
+// MakeShiftsAfter works like MakeShifts for shifts following the previous shifts. Members are never
+// scheduled with less than ShiftConfig.MinRest between shifts, for more than ShiftConfig.MaxConsecutive
+// shifts in a row, counting the previous shifts, or for two shifts at the same time. The shifts are left
+// understaffed instead.
+// Members get their roles in the shift with AssignRoles.
+//
+// Members without any of the Shift.Coverage tags still missing are passed over while the seats left are needed
+// for members with those tags. Shifts that could not be covered get a CoverageComment, see MissingCoverage.
+func MakeShiftsAfter(sc *rotang.Configuration, start time.Time, previous []rotang.ShiftEntry, membersByShift [][]rotang.Member, shiftsToSchedule int) []rotang.ShiftEntry {
 	var res []rotang.ShiftEntry
+	loc := sc.Config.Shifts.Location()
+	rs := newRest(&sc.Config.Shifts, previous)
 	perShiftIdx := make([]int, len(sc.Config.Shifts.Shifts))
 	for i := 0; i < shiftsToSchedule; i++ {
 		for shiftIdx, shift := range sc.Config.Shifts.Shifts {
@@ -193,40 +246,219 @@ func MakeShifts(sc *rotang.Configuration, start time.Time, membersByShift [][]ro
 				StartTime: shiftStart,
 				EndTime:   shiftEnd,
 			}
+			cov := newCoverage(shift.Coverage)
+			// The shifts scheduled so far at the same time as this one.
+			parallel := res[len(res)-shiftIdx:]
 			if len(membersByShift[shiftIdx]) == 0 {
-				res = append(res, se)
+				rs.add(se)
+				res = append(res, annotateCoverage(annotateUnderstaffed(&sc.Config.Shifts, se), cov.missing))
 				continue
 			}
 			// With idx: 2 {"A", "B", "C", "D", "E", "F"} -> {"C", "D", "E", "F", "A", "B"}
 			shiftMembers := make([]rotang.Member, len(membersByShift[shiftIdx]))
 			copy(shiftMembers, membersByShift[shiftIdx])
 			shiftMembers = append(shiftMembers[perShiftIdx[shiftIdx]%len(shiftMembers):], shiftMembers[:perShiftIdx[shiftIdx]%len(shiftMembers)]...)
-			for oncallIdx := 0; oncallIdx < sc.Config.Shifts.ShiftMembers && len(shiftMembers) > 0; {
+			var notPreferred, notNeeded []rotang.Member
+			oncallIdx := 0
+			for oncallIdx < sc.Config.Shifts.ShiftMembers && len(shiftMembers) > 0 {
 				propMember := shiftMembers[0]
 				shiftMembers = shiftMembers[1:]
-				if PersonalOutage(shiftStart, sc.Config.Shifts.Length, sc.Config.Shifts.Shifts[shiftIdx].Duration, propMember) {
+				if reason := Absent(&sc.Config.Shifts, shiftStart, sc.Config.Shifts.Length, sc.Config.Shifts.Shifts[shiftIdx].Duration, propMember); reason != "" {
+					se.Trace = decide(sc, se.Trace, propMember.Email, reason)
+					continue
+				}
+				if reason := rs.check(shiftIdx, shiftStart, propMember.Email); reason != "" {
+					se.Trace = decide(sc, se.Trace, propMember.Email, reason)
+					continue
+				}
+				if oncallDuring(parallel, propMember.Email, shiftStart, shiftEnd) {
+					se.Trace = decide(sc, se.Trace, propMember.Email, rotang.SkippedScheduled)
 					continue
 				}
-				if !PersonalPreference(shiftStart, sc.Config.Shifts.Length, sc.Config.Shifts.Shifts[shiftIdx].Duration, propMember) {
+				if !PersonalPreference(shiftStart.In(loc), sc.Config.Shifts.Length, sc.Config.Shifts.Shifts[shiftIdx].Duration, propMember) {
+					if !hasPreference(propMember, rotang.NoOncall) {
+						notPreferred = append(notPreferred, propMember)
+					}
+					se.Trace = decide(sc, se.Trace, propMember.Email, rotang.SkippedPreference)
+					continue
+				}
+				// Keep the seats left for members with the tags still missing.
+				if cov.needed(propMember, sc.Config.Shifts.ShiftMembers-oncallIdx) {
+					notNeeded = append(notNeeded, propMember)
+					se.Trace = decide(sc, se.Trace, propMember.Email, rotang.SkippedCoverage)
 					continue
 				}
 				se.OnCall = append(se.OnCall, rotang.ShiftMember{
 					Email:     propMember.Email,
 					ShiftName: shift.Name,
 				})
+				se.Trace = decide(sc, se.Trace, propMember.Email, rotang.Scheduled)
+				cov.add(propMember)
 				perShiftIdx[shiftIdx]++
 				oncallIdx++
 			}
-			res = append(res, se)
+			// The shift is full, record why the members left would not have been scheduled.
+			if sc.Config.Shifts.Trace {
+				for _, m := range shiftMembers {
+					reason := Absent(&sc.Config.Shifts, shiftStart, sc.Config.Shifts.Length, sc.Config.Shifts.Shifts[shiftIdx].Duration, m)
+					if reason == "" {
+						reason = rs.check(shiftIdx, shiftStart, m.Email)
+					}
+					switch {
+					case reason != "":
+					case oncallDuring(parallel, m.Email, shiftStart, shiftEnd):
+						reason = rotang.SkippedScheduled
+					case !PersonalPreference(shiftStart.In(loc), sc.Config.Shifts.Length, sc.Config.Shifts.Shifts[shiftIdx].Duration, m):
+						reason = rotang.SkippedPreference
+					default:
+						reason = rotang.Available
+					}
+					se.Trace = decide(sc, se.Trace, m.Email, reason)
+				}
+			}
+			// Fill up with members skipped for coverage if the tags could not be covered.
+			for ; oncallIdx < sc.Config.Shifts.ShiftMembers && len(notNeeded) > 0; oncallIdx++ {
+				propMember := notNeeded[0]
+				notNeeded = notNeeded[1:]
+				se.OnCall = append(se.OnCall, rotang.ShiftMember{
+					Email:     propMember.Email,
+					ShiftName: shift.Name,
+				})
+				for i := range se.Trace {
+					if se.Trace[i].Email == propMember.Email {
+						se.Trace[i].Reason = rotang.Scheduled
+					}
+				}
+				perShiftIdx[shiftIdx]++
+			}
+			// Fall back to members who'd rather not be oncall for this shift.
+			var fallback []string
+			for ; oncallIdx < sc.Config.Shifts.ShiftMembers && len(notPreferred) > 0; oncallIdx++ {
+				propMember := notPreferred[0]
+				notPreferred = notPreferred[1:]
+				se.OnCall = append(se.OnCall, rotang.ShiftMember{
+					Email:     propMember.Email,
+					ShiftName: shift.Name,
+				})
+				cov.add(propMember)
+				fallback = append(fallback, propMember.Email)
+				for i := range se.Trace {
+					if se.Trace[i].Email == propMember.Email {
+						se.Trace[i].Reason = rotang.ScheduledAgainstPreference
+					}
+				}
+				perShiftIdx[shiftIdx]++
+			}
+			if len(fallback) > 0 {
+				se.Comment = fmt.Sprintf("scheduled against preferences, no other members available: %s", strings.Join(fallback, ", "))
+			}
+			rs.add(se)
+			res = append(res, annotateCoverage(annotateUnderstaffed(&sc.Config.Shifts, se), cov.missing))
+		}
+	}
+	return AssignRoles(&sc.Config.Shifts, previous, res)
+}
+
+// oncallDuring is true if the member is oncall in one of the shifts overlapping start to end.
+func oncallDuring(shifts []rotang.ShiftEntry, email string, start, end time.Time) bool {
+	for _, s := range shifts {
+		if !s.StartTime.Before(end) || !s.EndTime.After(start) {
+			continue
+		}
+		for _, o := range s.OnCall {
+			if o.Email == email {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Staffing returns the number of members oncall for the shift, not counting trainees shadowing a member.
+func Staffing(se rotang.ShiftEntry) int {
+	n := 0
+	for _, o := range se.OnCall {
+		if o.Mentor == "" {
+			n++
+		}
+	}
+	return n
+}
+
+// Understaffed returns the shifts with fewer than ShiftMembers members oncall, see Staffing.
+func Understaffed(sc *rotang.ShiftConfig, shifts []rotang.ShiftEntry) []rotang.ShiftEntry {
+	var res []rotang.ShiftEntry
+	for _, s := range shifts {
+		if Staffing(s) < sc.ShiftMembers {
+			res = append(res, s)
 		}
 	}
 	return res
 }
 
+// UnderstaffedComment describes a shift with only oncall of the wanted members.
+func UnderstaffedComment(oncall, want int) string {
+	if oncall == 0 {
+		return "nobody could be scheduled"
+	}
+	return fmt.Sprintf("only %d of %d members could be scheduled", oncall, want)
+}
+
+// annotateUnderstaffed adds the UnderstaffedComment to the Comment of an understaffed shift.
+func annotateUnderstaffed(sc *rotang.ShiftConfig, se rotang.ShiftEntry) rotang.ShiftEntry {
+	if Staffing(se) >= sc.ShiftMembers {
+		return se
+	}
+	if se.Comment != "" {
+		se.Comment += "; "
+	}
+	se.Comment += UnderstaffedComment(Staffing(se), sc.ShiftMembers)
+	return se
+}
+
+// decide adds a Decision for the member to trace if the rotation asks for a trace.
+func decide(sc *rotang.Configuration, trace []rotang.Decision, email string, reason rotang.Reason) []rotang.Decision {
+	if !sc.Config.Shifts.Trace {
+		return trace
+	}
+	return append(trace, rotang.Decision{Email: email, Reason: reason})
+}
+
+// hasPreference is true if the member has set the preference.
+func hasPreference(member rotang.Member, pref rotang.Preference) bool {
+	for _, p := range member.Preferences {
+		if p == pref {
+			return true
+		}
+	}
+	return false
+}
+
 // Random arranges a slice of members randomly.
-func Random(m []rotang.Member) {
-	for i := range m {
-		swapDest := rand.Int() % len(m)
-		m[i], m[swapDest] = m[swapDest], m[i]
+func Random(rnd *rand.Rand, m []rotang.Member) {
+	rnd.Shuffle(len(m), func(i, j int) {
+		m[i], m[j] = m[j], m[i]
+	})
+}
+
+// Seed returns the seed to use when generating shifts for the rotation, the
+// configured ShiftConfig.Seed or a new seed if not set.
+func Seed(sc *rotang.Configuration) int64 {
+	if sc.Config.Shifts.Seed != 0 {
+		return sc.Config.Shifts.Seed
+	}
+	return time.Now().UnixNano()
+}
+
+// NewRand returns a new source of random numbers seeded with seed.
+func NewRand(seed int64) *rand.Rand {
+	return rand.New(rand.NewSource(seed))
+}
+
+// setSeed records the seed used to generate the shifts.
+func setSeed(shifts []rotang.ShiftEntry, seed int64) []rotang.ShiftEntry {
+	for i := range shifts {
+		shifts[i].Seed = seed
 	}
+	return shifts
 }
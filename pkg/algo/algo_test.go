@@ -72,6 +72,59 @@ func TestShiftStartEnd(t *testing.T) {
 	}
 }
 
+func TestShiftStartEndDST(t *testing.T) {
+	amsTime, err := time.LoadLocation("Europe/Amsterdam")
+	if err != nil {
+		t.Fatalf("time.LoadLocation() failed: %v", err)
+	}
+	sc := &rotang.ShiftConfig{
+		StartTime: time.Date(0, 1, 1, 9, 0, 0, 0, time.UTC),
+		Length:    1,
+		TZ:        *amsTime,
+		Shifts: []rotang.Shift{
+			{
+				Name:     "Day",
+				Duration: 8 * time.Hour,
+			}, {
+				Name:     "Night",
+				Duration: 16 * time.Hour,
+			},
+		},
+	}
+	// DST starts 2018-03-25 02:00 in Amsterdam.
+	start := time.Date(2018, 3, 24, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		shiftNumber int
+		shiftIdx    int
+		wantStart   time.Time
+		wantEnd     time.Time
+	}{{
+		name:      "Before DST",
+		wantStart: time.Date(2018, 3, 24, 9, 0, 0, 0, amsTime),
+		wantEnd:   time.Date(2018, 3, 24, 17, 0, 0, 0, amsTime),
+	}, {
+		name:      "Night over DST change",
+		shiftIdx:  1,
+		wantStart: time.Date(2018, 3, 24, 17, 0, 0, 0, amsTime),
+		wantEnd:   time.Date(2018, 3, 25, 9, 0, 0, 0, amsTime),
+	}, {
+		name:        "After DST",
+		shiftNumber: 1,
+		wantStart:   time.Date(2018, 3, 25, 9, 0, 0, 0, amsTime),
+		wantEnd:     time.Date(2018, 3, 25, 17, 0, 0, 0, amsTime),
+	},
+	}
+
+	for _, tst := range tests {
+		gotStart, gotEnd := ShiftStartEnd(start, tst.shiftNumber, tst.shiftIdx, sc)
+		if !gotStart.Equal(tst.wantStart) || !gotEnd.Equal(tst.wantEnd) {
+			t.Errorf("%s: ShiftStartEnd(_, %d, %d, _) = %v - %v, want: %v - %v", tst.name, tst.shiftNumber, tst.shiftIdx, gotStart, gotEnd, tst.wantStart, tst.wantEnd)
+		}
+	}
+}
+
 func TestRegisterList(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -671,6 +724,228 @@ func TestPersonalOutage(t *testing.T) {
 	}
 }
 
+func TestPersonalPreference(t *testing.T) {
+	sydTime, err := time.LoadLocation("Australia/Sydney")
+	if err != nil {
+		t.Fatalf("time.LoadLocation() failed: %v", err)
+	}
+	// midnight is a Wednesday.
+	evening := midnight.Add(20 * time.Hour)
+
+	tests := []struct {
+		name     string
+		start    time.Time
+		days     int
+		duration time.Duration
+		prefs    []rotang.Preference
+		want     bool
+	}{{
+		name:     "No preferences",
+		start:    midnight,
+		days:     7,
+		duration: fullDay,
+		want:     true,
+	}, {
+		name:     "No oncall",
+		start:    midnight,
+		days:     1,
+		duration: fullDay,
+		prefs:    []rotang.Preference{rotang.NoOncall},
+	}, {
+		name:     "No weekends shift before weekend",
+		start:    midnight,
+		days:     2,
+		duration: fullDay,
+		prefs:    []rotang.Preference{rotang.NoWeekends},
+		want:     true,
+	}, {
+		name:     "No weekends shift over weekend",
+		start:    midnight,
+		days:     5,
+		duration: fullDay,
+		prefs:    []rotang.Preference{rotang.NoWeekends},
+	}, {
+		name:     "No wednesday",
+		start:    midnight,
+		days:     1,
+		duration: 8 * time.Hour,
+		prefs:    []rotang.Preference{rotang.NoMonday, rotang.NoWednesday},
+	}, {
+		name:     "No sunday",
+		start:    midnight.Add(4 * fullDay),
+		days:     1,
+		duration: time.Hour,
+		prefs:    []rotang.Preference{rotang.NoSunday},
+	}, {
+		name:     "Shift ends at midnight",
+		start:    midnight,
+		days:     1,
+		duration: fullDay,
+		prefs:    []rotang.Preference{rotang.NoThursday},
+		want:     true,
+	}, {
+		name:     "Shift overlaps into next day",
+		start:    evening,
+		days:     1,
+		duration: 8 * time.Hour,
+		prefs:    []rotang.Preference{rotang.NoThursday},
+	}, {
+		name:     "Wednesday in UTC",
+		start:    evening,
+		days:     1,
+		duration: 4 * time.Hour,
+		prefs:    []rotang.Preference{rotang.NoThursday},
+		want:     true,
+	}, {
+		name:     "Thursday in Sydney",
+		start:    evening.In(sydTime),
+		days:     1,
+		duration: 4 * time.Hour,
+		prefs:    []rotang.Preference{rotang.NoThursday},
+	},
+	}
+
+	for _, tst := range tests {
+		res := PersonalPreference(tst.start, tst.days, tst.duration, rotang.Member{
+			Email:       "test@oncall.com",
+			Preferences: tst.prefs,
+		})
+		if got, want := res, tst.want; got != want {
+			t.Errorf("%s: PersonalPreference() = %t, want: %t", tst.name, got, want)
+		}
+	}
+}
+
+func TestMakeShiftsPreferences(t *testing.T) {
+	sydTime, err := time.LoadLocation("Australia/Sydney")
+	if err != nil {
+		t.Fatalf("time.LoadLocation() failed: %v", err)
+	}
+	// midnight is a Wednesday.
+	friday := midnight.Add(2 * fullDay)
+	saturday := midnight.Add(3 * fullDay)
+	thursday := midnight.Add(fullDay)
+
+	tests := []struct {
+		name       string
+		start      time.Time
+		startTime  time.Time
+		duration   time.Duration
+		tz         *time.Location
+		memberPool []rotang.Member
+		want       []rotang.ShiftEntry
+	}{{
+		name:      "Skip member",
+		start:     friday,
+		startTime: midnight,
+		duration:  fullDay,
+		memberPool: []rotang.Member{
+			{
+				Email:       "a@oncall.com",
+				Preferences: []rotang.Preference{rotang.NoFriday},
+			}, {
+				Email: "b@oncall.com",
+			},
+		},
+		want: []rotang.ShiftEntry{
+			{
+				Name:      "Shift",
+				StartTime: friday,
+				EndTime:   friday.Add(fullDay),
+				OnCall: []rotang.ShiftMember{
+					{
+						Email:     "b@oncall.com",
+						ShiftName: "Shift",
+					},
+				},
+			},
+		},
+	}, {
+		name:      "Fall back to member",
+		start:     saturday,
+		startTime: midnight,
+		duration:  fullDay,
+		memberPool: []rotang.Member{
+			{
+				Email:       "a@oncall.com",
+				Preferences: []rotang.Preference{rotang.NoWeekends},
+			}, {
+				Email:       "b@oncall.com",
+				Preferences: []rotang.Preference{rotang.NoOncall},
+			},
+		},
+		want: []rotang.ShiftEntry{
+			{
+				Name:      "Shift",
+				StartTime: saturday,
+				EndTime:   saturday.Add(fullDay),
+				OnCall: []rotang.ShiftMember{
+					{
+						Email:     "a@oncall.com",
+						ShiftName: "Shift",
+					},
+				},
+				Comment: "scheduled against preferences, no other members available: a@oncall.com",
+			},
+		},
+	}, {
+		// Thursday 06:00 in Sydney is Wednesday 20:00 UTC.
+		name:      "Rotation time zone",
+		start:     thursday,
+		startTime: midnight.Add(6 * time.Hour),
+		duration:  4 * time.Hour,
+		tz:        sydTime,
+		memberPool: []rotang.Member{
+			{
+				Email:       "a@oncall.com",
+				Preferences: []rotang.Preference{rotang.NoThursday},
+			}, {
+				Email: "b@oncall.com",
+			},
+		},
+		want: []rotang.ShiftEntry{
+			{
+				Name:      "Shift",
+				StartTime: midnight.Add(20 * time.Hour),
+				EndTime:   midnight.Add(24 * time.Hour),
+				OnCall: []rotang.ShiftMember{
+					{
+						Email:     "b@oncall.com",
+						ShiftName: "Shift",
+					},
+				},
+			},
+		},
+	},
+	}
+
+	for _, tst := range tests {
+		cfg := &rotang.Configuration{
+			Config: rotang.Config{
+				Name: "test rota",
+				Shifts: rotang.ShiftConfig{
+					StartTime:    tst.startTime,
+					ShiftMembers: 1,
+					Length:       1,
+					Shifts: []rotang.Shift{
+						{
+							Name:     "Shift",
+							Duration: tst.duration,
+						},
+					},
+				},
+			},
+		}
+		if tst.tz != nil {
+			cfg.Config.Shifts.TZ = *tst.tz
+		}
+		res := MakeShifts(cfg, tst.start, [][]rotang.Member{tst.memberPool}, 1)
+		if diff := pretty.Compare(tst.want, res); diff != "" {
+			t.Errorf("%s: MakeShifts(_) differ -want +got, %s", tst.name, diff)
+		}
+	}
+}
+
 func TestMakeShifts(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -1121,3 +1396,373 @@ func TestMakeShifts(t *testing.T) {
 		}
 	}
 }
+
+func TestTrace(t *testing.T) {
+	// midnight is a Wednesday, the fourth shift is on a Saturday.
+	members := []rotang.Member{
+		{
+			Email: "a@oncall.com",
+			OOO: []rotang.OOO{
+				{
+					Start:    midnight,
+					Duration: 4 * fullDay,
+				},
+			},
+		}, {
+			Email:       "b@oncall.com",
+			Preferences: []rotang.Preference{rotang.NoWeekends},
+		}, {
+			Email: "c@oncall.com",
+		},
+	}
+	previous := []rotang.ShiftEntry{
+		{
+			Name:      "Day",
+			StartTime: midnight.Add(-2 * fullDay),
+			EndTime:   midnight.Add(-fullDay),
+			OnCall: []rotang.ShiftMember{
+				{
+					Email:     "c@oncall.com",
+					ShiftName: "Day",
+				},
+			},
+		}, {
+			Name:      "Day",
+			StartTime: midnight.Add(-fullDay),
+			EndTime:   midnight,
+			OnCall: []rotang.ShiftMember{
+				{
+					Email:     "b@oncall.com",
+					ShiftName: "Day",
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name string
+		// generator is nil to use MakeShifts with the members in order.
+		generator rotang.RotaGenerator
+		members   []rotang.Member
+		previous  []rotang.ShiftEntry
+		want      [][]rotang.Decision
+	}{{
+		name:    "Skipped members",
+		members: members,
+		want: [][]rotang.Decision{
+			{
+				{Email: "a@oncall.com", Reason: rotang.SkippedOOO},
+				{Email: "b@oncall.com", Reason: rotang.Scheduled},
+				{Email: "c@oncall.com", Reason: rotang.Available},
+			}, {
+				{Email: "b@oncall.com", Reason: rotang.Scheduled},
+				{Email: "c@oncall.com", Reason: rotang.Available},
+				{Email: "a@oncall.com", Reason: rotang.SkippedOOO},
+			}, {
+				{Email: "c@oncall.com", Reason: rotang.Scheduled},
+				{Email: "a@oncall.com", Reason: rotang.SkippedOOO},
+				{Email: "b@oncall.com", Reason: rotang.Available},
+			}, {
+				{Email: "a@oncall.com", Reason: rotang.SkippedOOO},
+				{Email: "b@oncall.com", Reason: rotang.SkippedPreference},
+				{Email: "c@oncall.com", Reason: rotang.Scheduled},
+			},
+		},
+	}, {
+		name:    "Against preferences",
+		members: members[:2],
+		want: [][]rotang.Decision{
+			{
+				{Email: "a@oncall.com", Reason: rotang.SkippedOOO},
+				{Email: "b@oncall.com", Reason: rotang.Scheduled},
+			}, {
+				{Email: "b@oncall.com", Reason: rotang.Scheduled},
+				{Email: "a@oncall.com", Reason: rotang.SkippedOOO},
+			}, {
+				{Email: "a@oncall.com", Reason: rotang.SkippedOOO},
+				{Email: "b@oncall.com", Reason: rotang.Scheduled},
+			}, {
+				{Email: "b@oncall.com", Reason: rotang.ScheduledAgainstPreference},
+				{Email: "a@oncall.com", Reason: rotang.SkippedOOO},
+			},
+		},
+	}, {
+		name:      "Fair weights",
+		generator: NewFair(),
+		members:   members,
+		previous:  previous,
+		want: [][]rotang.Decision{
+			{
+				{Email: "a@oncall.com", Reason: rotang.SkippedOOO, Weight: 1},
+				{Email: "c@oncall.com", Reason: rotang.Scheduled, Weight: 2},
+				{Email: "b@oncall.com", Reason: rotang.Available, Weight: 3},
+			}, {
+				{Email: "c@oncall.com", Reason: rotang.Scheduled, Weight: 2},
+				{Email: "b@oncall.com", Reason: rotang.Available, Weight: 3},
+				{Email: "a@oncall.com", Reason: rotang.SkippedOOO, Weight: 1},
+			}, {
+				{Email: "b@oncall.com", Reason: rotang.Scheduled, Weight: 3},
+				{Email: "a@oncall.com", Reason: rotang.SkippedOOO, Weight: 1},
+				{Email: "c@oncall.com", Reason: rotang.Available, Weight: 2},
+			}, {
+				{Email: "a@oncall.com", Reason: rotang.SkippedOOO, Weight: 1},
+				{Email: "c@oncall.com", Reason: rotang.Scheduled, Weight: 2},
+				{Email: "b@oncall.com", Reason: rotang.SkippedPreference, Weight: 3},
+			},
+		},
+	}, {
+		name:      "Optimal",
+		generator: NewOptimal(),
+		members:   members,
+		want: [][]rotang.Decision{
+			{
+				{Email: "a@oncall.com", Reason: rotang.SkippedOOO},
+				{Email: "b@oncall.com", Reason: rotang.Scheduled, Weight: 2},
+				{Email: "c@oncall.com", Reason: rotang.Available, Weight: 2},
+			}, {
+				{Email: "a@oncall.com", Reason: rotang.SkippedOOO},
+				{Email: "b@oncall.com", Reason: rotang.Scheduled, Weight: 2},
+				{Email: "c@oncall.com", Reason: rotang.Available, Weight: 2},
+			}, {
+				{Email: "a@oncall.com", Reason: rotang.SkippedOOO},
+				{Email: "b@oncall.com", Reason: rotang.Available, Weight: 2},
+				{Email: "c@oncall.com", Reason: rotang.Scheduled, Weight: 2},
+			}, {
+				{Email: "a@oncall.com", Reason: rotang.SkippedOOO},
+				{Email: "b@oncall.com", Reason: rotang.SkippedPreference, Weight: 2},
+				{Email: "c@oncall.com", Reason: rotang.Scheduled, Weight: 2},
+			},
+		},
+	},
+	}
+
+	for _, tst := range tests {
+		cfg := &rotang.Configuration{
+			Config: rotang.Config{
+				Name: "Test Rota",
+				Shifts: rotang.ShiftConfig{
+					ShiftMembers: 1,
+					Length:       1,
+					Seed:         7357,
+					Trace:        true,
+					Shifts: []rotang.Shift{
+						{
+							Name:     "Day",
+							Duration: fullDay,
+						},
+					},
+				},
+			},
+		}
+		for _, m := range tst.members {
+			cfg.Members = append(cfg.Members, rotang.ShiftMember{Email: m.Email, ShiftName: "Day"})
+		}
+		shifts := MakeShifts(cfg, midnight, [][]rotang.Member{tst.members}, 4)
+		if tst.generator != nil {
+			var err error
+			if shifts, err = tst.generator.Generate(cfg, midnight, tst.previous, append([]rotang.Member{}, tst.members...), 4); err != nil {
+				t.Fatalf("%s: Generate(_) failed: %v", tst.name, err)
+			}
+		}
+		var got [][]rotang.Decision
+		for _, s := range shifts {
+			got = append(got, s.Trace)
+		}
+		if diff := pretty.Compare(tst.want, got); diff != "" {
+			t.Errorf("%s: Generate(_) trace differ -want +got, %s", tst.name, diff)
+		}
+	}
+}
+
+func TestTraceScheduled(t *testing.T) {
+	// With shifts of two days the spans of the shifts overlap.
+	cfg := &rotang.Configuration{
+		Config: rotang.Config{
+			Name: "Test Rota",
+			Shifts: rotang.ShiftConfig{
+				ShiftMembers: 1,
+				Length:       2,
+				Trace:        true,
+				Shifts: []rotang.Shift{
+					{Name: "Early", Duration: 12 * time.Hour},
+					{Name: "Late", Duration: 12 * time.Hour},
+				},
+			},
+		},
+	}
+	shifts := MakeShifts(cfg, midnight, [][]rotang.Member{
+		stringToMembers("A", time.UTC),
+		stringToMembers("AB", time.UTC),
+	}, 1)
+	want := [][]rotang.Decision{
+		{
+			{Email: "A@A.com", Reason: rotang.Scheduled},
+		}, {
+			{Email: "A@A.com", Reason: rotang.SkippedScheduled},
+			{Email: "B@B.com", Reason: rotang.Scheduled},
+		},
+	}
+	var got [][]rotang.Decision
+	for _, s := range shifts {
+		got = append(got, s.Trace)
+	}
+	if diff := pretty.Compare(want, got); diff != "" {
+		t.Errorf("MakeShifts(_) trace differ -want +got, %s", diff)
+	}
+}
+
+func TestUnderstaffed(t *testing.T) {
+	tests := []struct {
+		name        string
+		memberPool  []rotang.Member
+		wantOnCall  int
+		wantComment string
+	}{{
+		name:        "Fully staffed",
+		memberPool:  stringToMembers("AB", time.UTC),
+		wantOnCall:  2,
+		wantComment: "",
+	}, {
+		name:        "Understaffed",
+		memberPool:  stringToMembers("A", time.UTC),
+		wantOnCall:  1,
+		wantComment: "only 1 of 2 members could be scheduled",
+	}, {
+		name: "Empty",
+		memberPool: []rotang.Member{
+			{
+				Email: "A@A.com",
+				OOO: []rotang.OOO{
+					{
+						Start:    midnight,
+						Duration: fullDay,
+					},
+				},
+			},
+		},
+		wantComment: "nobody could be scheduled",
+	}, {
+		name:        "No members",
+		wantComment: "nobody could be scheduled",
+	},
+	}
+
+	for _, tst := range tests {
+		cfg := &rotang.Configuration{
+			Config: rotang.Config{
+				Name: "Test Rota",
+				Shifts: rotang.ShiftConfig{
+					ShiftMembers: 2,
+					Length:       1,
+					Shifts: []rotang.Shift{
+						{
+							Name:     "Day",
+							Duration: fullDay,
+						},
+					},
+				},
+			},
+		}
+		shifts := MakeShifts(cfg, midnight, [][]rotang.Member{tst.memberPool}, 1)
+		if got, want := len(shifts[0].OnCall), tst.wantOnCall; got != want {
+			t.Errorf("%s: MakeShifts(_) scheduled %d members, want: %d", tst.name, got, want)
+		}
+		if got, want := shifts[0].Comment, tst.wantComment; got != want {
+			t.Errorf("%s: MakeShifts(_) Comment = %q want: %q", tst.name, got, want)
+		}
+		if got, want := len(Understaffed(&cfg.Config.Shifts, shifts)) == 1, tst.wantOnCall < 2; got != want {
+			t.Errorf("%s: Understaffed(_) = %t want: %t", tst.name, got, want)
+		}
+	}
+}
+
+func TestMakeShiftsAfter(t *testing.T) {
+	// previous has A oncall the day before midnight.
+	previous := []rotang.ShiftEntry{
+		{
+			Name:      "Day",
+			StartTime: midnight.Add(-fullDay),
+			EndTime:   midnight,
+			OnCall: []rotang.ShiftMember{
+				{
+					Email:     "A@A.com",
+					ShiftName: "Day",
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		members        string
+		previous       []rotang.ShiftEntry
+		minRest        time.Duration
+		maxConsecutive int
+		// want has the member oncall per shift, '-' for an empty shift.
+		want string
+	}{{
+		name:    "No constraints",
+		members: "AB",
+		want:    "ABAB",
+	}, {
+		name:     "Previous without constraints",
+		members:  "AB",
+		previous: previous,
+		want:     "ABAB",
+	}, {
+		name:     "Minimum rest",
+		members:  "AB",
+		previous: previous,
+		minRest:  fullDay,
+		want:     "BABA",
+	}, {
+		name:    "Not enough members to rest",
+		members: "A",
+		minRest: fullDay,
+		want:    "A-A-",
+	}, {
+		name:           "Maximum consecutive",
+		members:        "A",
+		maxConsecutive: 2,
+		want:           "AA-A",
+	}, {
+		name:           "Maximum consecutive with previous",
+		members:        "A",
+		previous:       previous,
+		maxConsecutive: 2,
+		want:           "A-AA",
+	},
+	}
+
+	for _, tst := range tests {
+		cfg := &rotang.Configuration{
+			Config: rotang.Config{
+				Name: "Test Rota",
+				Shifts: rotang.ShiftConfig{
+					ShiftMembers:   1,
+					Length:         1,
+					MinRest:        tst.minRest,
+					MaxConsecutive: tst.maxConsecutive,
+					Shifts: []rotang.Shift{
+						{
+							Name:     "Day",
+							Duration: fullDay,
+						},
+					},
+				},
+			},
+		}
+		shifts := MakeShiftsAfter(cfg, midnight, tst.previous, [][]rotang.Member{stringToMembers(tst.members, time.UTC)}, len(tst.want))
+		var got []byte
+		for _, s := range shifts {
+			if len(s.OnCall) == 0 {
+				got = append(got, '-')
+				continue
+			}
+			got = append(got, s.OnCall[0].Email[0])
+		}
+		if got, want := string(got), tst.want; got != want {
+			t.Errorf("%s: MakeShiftsAfter(_) = %q want: %q", tst.name, got, want)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package algo
+
+import (
+	"strings"
+
+	rotang "github.com/miekg/rota"
+)
+
+// coverage keeps track of the Shift.Coverage tags not yet covered by the members picked for a shift.
+type coverage struct {
+	missing []string
+}
+
+func newCoverage(tags []string) *coverage {
+	return &coverage{
+		missing: append([]string{}, tags...),
+	}
+}
+
+// covers returns the number of missing tags the member has.
+func (c *coverage) covers(m rotang.Member) int {
+	n := 0
+	for _, t := range c.missing {
+		if hasTag(m, t) {
+			n++
+		}
+	}
+	return n
+}
+
+// needed is true if the member should be skipped to keep the seats left for members with missing tags.
+// seatsLeft are the seats left including the one the member would take.
+func (c *coverage) needed(m rotang.Member, seatsLeft int) bool {
+	return c.covers(m) == 0 && len(c.missing) >= seatsLeft
+}
+
+// add removes the tags of the member from the missing tags.
+func (c *coverage) add(m rotang.Member) {
+	var missing []string
+	for _, t := range c.missing {
+		if !hasTag(m, t) {
+			missing = append(missing, t)
+		}
+	}
+	c.missing = missing
+}
+
+// hasTag is true if the member has the tag.
+func hasTag(m rotang.Member, tag string) bool {
+	for _, t := range m.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// MissingCoverage returns the Shift.Coverage tags none of the members oncall for the shift have.
+func MissingCoverage(sc *rotang.Configuration, members []rotang.Member, se rotang.ShiftEntry) []string {
+	var tags []string
+	for _, s := range sc.Config.Shifts.Shifts {
+		if s.Name == se.Name {
+			tags = s.Coverage
+		}
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+	byEmail := make(map[string]rotang.Member)
+	for _, m := range members {
+		byEmail[m.Email] = m
+	}
+	c := newCoverage(tags)
+	for _, o := range se.OnCall {
+		if o.Mentor == "" {
+			c.add(byEmail[o.Email])
+		}
+	}
+	return c.missing
+}
+
+// CoverageComment describes a shift missing members with the tags.
+func CoverageComment(missing []string) string {
+	return "no member with tags: " + strings.Join(missing, ", ")
+}
+
+// annotateCoverage adds the CoverageComment to the Comment of a shift missing coverage.
+func annotateCoverage(se rotang.ShiftEntry, missing []string) rotang.ShiftEntry {
+	if len(missing) == 0 {
+		return se
+	}
+	if se.Comment != "" {
+		se.Comment += "; "
+	}
+	se.Comment += CoverageComment(missing)
+	return se
+}
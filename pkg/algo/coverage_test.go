@@ -0,0 +1,116 @@
+package algo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+	rotang "github.com/miekg/rota"
+)
+
+// withTags returns the members with the tags set for the members in tagged.
+func withTags(members []rotang.Member, tagged map[string][]string) []rotang.Member {
+	for i := range members {
+		members[i].Tags = tagged[members[i].Email]
+	}
+	return members
+}
+
+func TestCoverage(t *testing.T) {
+	tests := []struct {
+		name     string
+		members  []rotang.Member
+		coverage []string
+		// want has the members of each shift.
+		want        []string
+		wantComment string
+	}{{
+		name:    "No coverage",
+		members: withTags(stringToMembers("ABCD", time.UTC), map[string][]string{"C@C.com": {"db"}}),
+		want:    []string{"A B", "C D", "A B"},
+	}, {
+		name:     "Member with tag",
+		members:  withTags(stringToMembers("ABCD", time.UTC), map[string][]string{"C@C.com": {"db"}}),
+		coverage: []string{"db"},
+		want:     []string{"A C", "C D", "A C"},
+	}, {
+		name: "Two tags",
+		members: withTags(stringToMembers("ABCD", time.UTC), map[string][]string{
+			"A@A.com": {"network"},
+			"D@D.com": {"db"},
+		}),
+		coverage: []string{"db", "network"},
+		want:     []string{"A D", "D A", "A D"},
+	}, {
+		name:        "Not covered",
+		members:     withTags(stringToMembers("AB", time.UTC), map[string][]string{"A@A.com": {"network"}}),
+		coverage:    []string{"db", "network"},
+		want:        []string{"A B", "A B", "A B"},
+		wantComment: "no member with tags: db",
+	},
+	}
+
+	for _, tst := range tests {
+		cfg := &rotang.Configuration{
+			Config: rotang.Config{
+				Name: "Test Rota",
+				Shifts: rotang.ShiftConfig{
+					ShiftMembers: 2,
+					Length:       1,
+					Shifts: []rotang.Shift{
+						{
+							Name:     "Day",
+							Duration: fullDay,
+							Coverage: tst.coverage,
+						},
+					},
+				},
+			},
+		}
+		shifts := MakeShifts(cfg, midnight, [][]rotang.Member{tst.members}, len(tst.want))
+		if diff := pretty.Compare(tst.want, shiftsToString(shifts)); diff != "" {
+			t.Errorf("%s: MakeShifts(_) differ -want +got, %s", tst.name, diff)
+		}
+		for _, s := range shifts {
+			if got, want := s.Comment, tst.wantComment; got != want {
+				t.Errorf("%s: MakeShifts(_) = %q has comment: %q, want: %q", tst.name, s.OnCall, got, want)
+			}
+		}
+	}
+}
+
+func TestOptimalCoverage(t *testing.T) {
+	cfg := &rotang.Configuration{
+		Config: rotang.Config{
+			Name: "Test Rota",
+			Shifts: rotang.ShiftConfig{
+				ShiftMembers: 2,
+				Length:       1,
+				Seed:         7357,
+				Shifts: []rotang.Shift{
+					{
+						Name:     "Day",
+						Duration: fullDay,
+						Coverage: []string{"db", "network"},
+					},
+				},
+			},
+		},
+		Members: stringToShiftMembers("ABCDEF", "Day"),
+	}
+	members := withTags(stringToMembers("ABCDEF", time.UTC), map[string][]string{
+		"A@A.com": {"network"},
+		"B@B.com": {"network"},
+		"E@E.com": {"db"},
+		"F@F.com": {"db"},
+	})
+	shifts, err := NewOptimal().Generate(cfg, midnight, nil, members, 8)
+	if err != nil {
+		t.Fatalf("Generate(_) failed: %v", err)
+	}
+	for _, s := range shifts {
+		if missing := MissingCoverage(cfg, members, s); len(missing) > 0 {
+			t.Errorf("Generate(_) = %q missing tags: %v", s.OnCall, missing)
+		}
+	}
+}
@@ -47,13 +47,14 @@ func NewFair() *Fair {
 // Fair for this Genarator means for a member to not be scheduled for a shift before a member who has not been on shift.
 // For members that have all been on shift the Generator uses weights where both how recent a member was on shift and
 // number of shifts done by a member is considered. This information is fethed from the provided 'previous' slice.
-// If no previous shifts are provided members are selected randomly.
+// If no previous shifts are provided members are selected randomly, see Seed.
 //
 // See the tests for further examples of how members are selected.
 func (f *Fair) Generate(sc *rotang.Configuration, start time.Time, previous []rotang.ShiftEntry, members []rotang.Member, shiftsToSchedule int) ([]rotang.ShiftEntry, error) {
 	if len(previous) < 1 {
-		Random(members)
-		return MakeShifts(sc, start, HandleShiftMembers(sc, members), shiftsToSchedule), nil
+		seed := Seed(sc)
+		Random(NewRand(seed), members)
+		return setSeed(MakeShifts(sc, start, HandleShiftMembers(sc, members), shiftsToSchedule), seed), nil
 	}
 
 	start = previous[len(previous)-1].EndTime
@@ -61,11 +62,19 @@ func (f *Fair) Generate(sc *rotang.Configuration, start time.Time, previous []ro
 	start = start.Add(fullDay * time.Duration(sc.Config.Shifts.Skip))
 	membersByShift := HandleShiftMembers(sc, members)
 	entriesByShift := HandleShiftEntries(sc, previous)
-	for i := range sc.Config.Shifts.Shifts {
+	weights := make(map[string]map[string]int)
+	for i, s := range sc.Config.Shifts.Shifts {
 		membersByShift[i] = makeFair(membersByShift[i], entriesByShift[i])
+		weights[s.Name] = fairWeights(membersByShift[i], entriesByShift[i])
 	}
 
-	return MakeShifts(sc, start, membersByShift, shiftsToSchedule), nil
+	shifts := MakeShiftsAfter(sc, start, previous, membersByShift, shiftsToSchedule)
+	for _, s := range shifts {
+		for i := range s.Trace {
+			s.Trace[i].Weight = weights[s.Name][s.Trace[i].Email]
+		}
+	}
+	return shifts, nil
 }
 
 // Name returns the name of the Generator.
@@ -75,33 +84,39 @@ func (f *Fair) Name() string {
 
 // makeFair sorts the oncall members according to most recent oncall and number of oncall shifts.
 func makeFair(members []rotang.Member, previous []rotang.ShiftEntry) []rotang.Member {
+	weights := fairWeights(members, previous)
+	var fr []*fair
+	for _, m := range members {
+		fr = append(fr, &fair{
+			weight: weights[m.Email],
+			member: m,
+		})
+	}
+
+	sort.Sort(byFair(fr))
+	var res []rotang.Member
+	for _, m := range fr {
+		res = append(res, m.member)
+	}
+	return res
+}
+
+// fairWeights returns the weight of each member, members who were oncall more and more recent weigh more.
+func fairWeights(members []rotang.Member, previous []rotang.ShiftEntry) map[string]int {
 	sort.Sort(ByStart(previous))
 
-	oncalls := make(map[string]*fair)
+	res := make(map[string]int)
 	for _, m := range members {
-		oncalls[m.Email] = &fair{
-			weight: 1,
-			member: m,
-		}
+		res[m.Email] = 1
 	}
 	for weight, e := range previous {
 		for _, o := range e.OnCall {
 			// If someone in a previous shift is not a member anymore.
-			if _, ok := oncalls[o.Email]; !ok {
+			if _, ok := res[o.Email]; !ok {
 				continue
 			}
-			oncalls[o.Email].weight += weight + len(previous)/2
+			res[o.Email] += weight + len(previous)/2
 		}
 	}
-	var fr []*fair
-	for _, v := range oncalls {
-		fr = append(fr, v)
-	}
-
-	sort.Sort(byFair(fr))
-	var res []rotang.Member
-	for _, m := range fr {
-		res = append(res, m.member)
-	}
 	return res
 }
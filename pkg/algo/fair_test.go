@@ -6,7 +6,6 @@ package algo
 
 import (
 	"fmt"
-	"math/rand"
 	"testing"
 	"time"
 
@@ -16,6 +15,14 @@ import (
 
 var midnight = time.Date(2006, 8, 2, 0, 0, 0, 0, time.UTC)
 
+var mtvTime = func() *time.Location {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}()
+
 func stringToShifts(in, shiftName string) []rotang.ShiftEntry {
 	var res []rotang.ShiftEntry
 	for tm, c := range in {
@@ -263,9 +270,6 @@ func TestGenerateFair(t *testing.T) {
 		},
 	}
 
-	// Should give the same pseudo random sequence every time.
-	rand.Seed(7357)
-
 	as := New()
 	as.Register(NewFair())
 	generator, err := as.Fetch("Fair")
@@ -275,6 +279,8 @@ func TestGenerateFair(t *testing.T) {
 
 	for _, tst := range tests {
 		tst.cfg.Members = stringToShiftMembers(tst.members, tst.cfg.Config.Shifts.Shifts[0].Name)
+		// Should give the same pseudo random sequence every time.
+		tst.cfg.Config.Shifts.Seed = 7357
 		shifts, err := generator.Generate(tst.cfg, tst.start, stringToShifts(tst.previous, tst.cfg.Config.Shifts.Shifts[0].Name), stringToMembers(tst.members, mtvTime), tst.numShifts)
 		if got, want := (err != nil), tst.fail; got != want {
 			t.Errorf("%s: Generate(_) = %t want: %t, err: %v", tst.name, got, want, err)
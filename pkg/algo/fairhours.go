@@ -0,0 +1,132 @@
+package algo
+
+import (
+	"sort"
+	"time"
+
+	rotang "github.com/miekg/rota"
+)
+
+// FairHours implements a rota Generator balancing the hours members are oncall, rather than the
+// number of shifts. A shift split up by a modifier counts for the hours left, and weekend, night and
+// holiday hours are weighted with ShiftConfig.HourWeights. Holidays are taken from ShiftConfig.Holidays.
+type FairHours struct{}
+
+var _ rotang.RotaGenerator = &FairHours{}
+
+// NewFairHours returns an instance of the FairHours generator.
+func NewFairHours() *FairHours {
+	return &FairHours{}
+}
+
+// Name returns the name of the Generator.
+func (f *FairHours) Name() string {
+	return "FairHours"
+}
+
+// Generate generates shifts one round at a time, every round the members with the fewest weighted hours
+// in the previous and generated shifts go first. Members with the same hours are ordered randomly, see Seed.
+func (f *FairHours) Generate(sc *rotang.Configuration, start time.Time, previous []rotang.ShiftEntry, members []rotang.Member, shiftsToSchedule int) ([]rotang.ShiftEntry, error) {
+	seed := Seed(sc)
+	Random(NewRand(seed), members)
+	history := append([]rotang.ShiftEntry{}, previous...)
+	sort.Sort(ByStart(history))
+	if len(history) > 0 {
+		start = history[len(history)-1].EndTime
+		// Need to add in the skip day(s) when taking in previous shifts.
+		start = start.Add(fullDay * time.Duration(sc.Config.Shifts.Skip))
+	}
+
+	loc := sc.Config.Shifts.Location()
+	hs := holidays{}
+	if sc.Config.Shifts.Holidays != "" {
+		from := start
+		if len(history) > 0 {
+			from = history[0].StartTime
+		}
+		to, _ := ShiftStartEnd(start, shiftsToSchedule, 0, &sc.Config.Shifts)
+		var err error
+		if hs, err = loadHolidays(sc.Config.Shifts.Holidays, from.In(loc).Year(), to.In(loc).Year(), loc); err != nil {
+			return nil, err
+		}
+	}
+
+	membersByShift := HandleShiftMembers(sc, members)
+	var res []rotang.ShiftEntry
+	for i := 0; i < shiftsToSchedule; i++ {
+		hours := weightedLoad(sc, history, hs)
+		for _, ms := range membersByShift {
+			sort.SliceStable(ms, func(a, b int) bool {
+				return hours[ms[a].Email] < hours[ms[b].Email]
+			})
+		}
+		roundStart, _ := ShiftStartEnd(start, i, 0, &sc.Config.Shifts)
+		ss := MakeShiftsAfter(sc, roundStart, history, membersByShift, 1)
+		res = append(res, ss...)
+		history = append(history, ss...)
+	}
+	return setSeed(res, seed), nil
+}
+
+// weightedLoad returns the weighted hours oncall per member, not counting shadow shifts.
+func weightedLoad(sc *rotang.Configuration, shifts []rotang.ShiftEntry, hs holidays) map[string]float64 {
+	loc := sc.Config.Shifts.Location()
+	durations := make(map[string]time.Duration)
+	for _, s := range sc.Config.Shifts.Shifts {
+		durations[s.Name] = s.Duration
+	}
+	res := make(map[string]float64)
+	for _, s := range shifts {
+		var hours float64
+		for _, iv := range oncallIntervals(s, durations, loc) {
+			hours += weightedHours(sc.Config.Shifts.HourWeights, iv[0], iv[1], loc, hs)
+		}
+		for _, o := range s.OnCall {
+			if o.Mentor == "" {
+				res[o.Email] += hours
+			}
+		}
+	}
+	return res
+}
+
+// weightedHours returns the hours in [start, end) multiplied by the largest of the HourWeights applying to them.
+func weightedHours(w rotang.HourWeights, start, end time.Time, loc *time.Location, hs holidays) float64 {
+	var res float64
+	for t := start; t.Before(end); {
+		next := nextBoundary(t, loc, loc)
+		if next.After(end) {
+			next = end
+		}
+		var weights []float64
+		if wd := t.In(loc).Weekday(); wd == time.Saturday || wd == time.Sunday {
+			weights = append(weights, w.Weekend)
+		}
+		if hour := t.In(loc).Hour(); hour >= NightStart || hour < NightEnd {
+			weights = append(weights, w.Night)
+		}
+		if _, ok := hs[dateOf(t.In(loc))]; ok {
+			weights = append(weights, w.Holiday)
+		}
+		res += maxWeight(weights) * next.Sub(t).Hours()
+		t = next
+	}
+	return res
+}
+
+// maxWeight returns the largest of the weights, 1 if there are none. A weight of 0 counts as 1.
+func maxWeight(weights []float64) float64 {
+	if len(weights) == 0 {
+		return 1
+	}
+	res := 0.0
+	for _, w := range weights {
+		if w == 0 {
+			w = 1
+		}
+		if w > res {
+			res = w
+		}
+	}
+	return res
+}
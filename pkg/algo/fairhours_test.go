@@ -0,0 +1,143 @@
+package algo
+
+import (
+	"testing"
+	"time"
+
+	rotang "github.com/miekg/rota"
+)
+
+func TestWeightedHours(t *testing.T) {
+	// midnight is a Wednesday.
+	saturday := midnight.Add(3 * fullDay)
+	hs := holidays{dateOf(midnight.Add(fullDay)): "Test Holiday"}
+
+	tests := []struct {
+		name       string
+		weights    rotang.HourWeights
+		start, end time.Time
+		want       float64
+	}{{
+		name:  "No weights",
+		start: saturday,
+		end:   saturday.Add(fullDay),
+		want:  24,
+	}, {
+		name:    "Weekend",
+		weights: rotang.HourWeights{Weekend: 2},
+		start:   midnight,
+		end:     midnight.Add(5 * fullDay),
+		want:    3*24 + 2*2*24,
+	}, {
+		name:    "Night",
+		weights: rotang.HourWeights{Night: 1.5},
+		start:   midnight,
+		end:     midnight.Add(fullDay),
+		want:    (NightEnd+24-NightStart)*1.5 + (NightStart - NightEnd),
+	}, {
+		name:    "Holiday",
+		weights: rotang.HourWeights{Holiday: 3},
+		start:   midnight,
+		end:     midnight.Add(2 * fullDay),
+		want:    24 + 3*24,
+	}, {
+		name:    "Largest weight",
+		weights: rotang.HourWeights{Night: 1.5, Holiday: 3},
+		start:   midnight.Add(fullDay),
+		end:     midnight.Add(2 * fullDay),
+		want:    3 * 24,
+	}, {
+		name:    "Weight below one",
+		weights: rotang.HourWeights{Weekend: 0.5},
+		start:   saturday,
+		end:     saturday.Add(12 * time.Hour),
+		want:    NightEnd + (12-NightEnd)*0.5,
+	},
+	}
+
+	for _, tst := range tests {
+		if got := weightedHours(tst.weights, tst.start, tst.end, time.UTC, hs); got != tst.want {
+			t.Errorf("%s: weightedHours(_, %v, %v) = %v, want: %v", tst.name, tst.start, tst.end, got, tst.want)
+		}
+	}
+}
+
+func TestFairHours(t *testing.T) {
+	shift := func(email string, start time.Time, d time.Duration) rotang.ShiftEntry {
+		return rotang.ShiftEntry{
+			Name:      "Day",
+			StartTime: start,
+			EndTime:   start.Add(d),
+			OnCall:    []rotang.ShiftMember{{Email: email, ShiftName: "Day"}},
+		}
+	}
+	// midnight is a Wednesday.
+	saturday := midnight.Add(-4 * fullDay)
+	sunday := midnight.Add(-3 * fullDay)
+
+	tests := []struct {
+		name     string
+		weights  rotang.HourWeights
+		previous []rotang.ShiftEntry
+		want     string
+	}{{
+		name: "Same hours",
+		previous: []rotang.ShiftEntry{
+			shift("A@A.com", saturday, fullDay),
+			shift("B@B.com", sunday, fullDay),
+		},
+		want: "BA",
+	}, {
+		name:    "Weekend weighs more",
+		weights: rotang.HourWeights{Weekend: 3},
+		previous: []rotang.ShiftEntry{
+			shift("A@A.com", saturday, fullDay),
+			shift("B@B.com", midnight.Add(-fullDay), fullDay),
+		},
+		want: "BB",
+	}, {
+		name: "Split shifts count their hours",
+		previous: []rotang.ShiftEntry{
+			shift("A@A.com", midnight.Add(-2*fullDay), 6*time.Hour),
+			shift("A@A.com", midnight.Add(-2*fullDay+12*time.Hour), 6*time.Hour),
+			shift("B@B.com", midnight.Add(-fullDay), fullDay),
+		},
+		want: "AB",
+	},
+	}
+
+	for _, tst := range tests {
+		cfg := &rotang.Configuration{
+			Config: rotang.Config{
+				Name: "Test Rota",
+				Shifts: rotang.ShiftConfig{
+					ShiftMembers: 1,
+					Length:       1,
+					Seed:         7357,
+					HourWeights:  tst.weights,
+					Shifts: []rotang.Shift{
+						{
+							Name:     "Day",
+							Duration: fullDay,
+						},
+					},
+				},
+			},
+			Members: stringToShiftMembers("AB", "Day"),
+		}
+		shifts, err := NewFairHours().Generate(cfg, midnight, tst.previous, stringToMembers("AB", time.UTC), len(tst.want))
+		if err != nil {
+			t.Errorf("%s: Generate(_) failed: %v", tst.name, err)
+			continue
+		}
+		var got []byte
+		for _, s := range shifts {
+			for _, o := range s.OnCall {
+				got = append(got, o.Email[0])
+			}
+		}
+		if string(got) != tst.want {
+			t.Errorf("%s: Generate(_) = %q, want: %q", tst.name, got, tst.want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package algo
+
+import (
+	"sort"
+	"time"
+
+	rotang "github.com/miekg/rota"
+)
+
+// FollowTheSun implements a rota Generator scheduling members only for shifts within their business hours.
+// Members are not grouped by ShiftName, every shift of the day is filled with the members for whom the
+// shift falls within business hours in their Member.TZ, eg. an APAC, EU and US shift each get members
+// from their own region. Within a region members are scheduled fairly, like the Fair generator does.
+//
+// Shifts without any member in business hours are left empty.
+type FollowTheSun struct {
+	// WorkStart and WorkEnd are the start and end of the business day, as the time since local midnight.
+	WorkStart time.Duration
+	WorkEnd   time.Duration
+}
+
+var _ rotang.RotaGenerator = &FollowTheSun{}
+
+// NewFollowTheSun returns an instance of the FollowTheSun generator with business hours from 08:00 to 20:00.
+func NewFollowTheSun() *FollowTheSun {
+	return &FollowTheSun{
+		WorkStart: 8 * time.Hour,
+		WorkEnd:   20 * time.Hour,
+	}
+}
+
+// Name returns the name of the Generator.
+func (f *FollowTheSun) Name() string {
+	return "FollowTheSun"
+}
+
+// Generate generates shifts following the sun.
+func (f *FollowTheSun) Generate(sc *rotang.Configuration, start time.Time, previous []rotang.ShiftEntry, members []rotang.Member, shiftsToSchedule int) ([]rotang.ShiftEntry, error) {
+	seed := Seed(sc)
+	if len(previous) > 0 {
+		previous = append([]rotang.ShiftEntry{}, previous...)
+		sort.Sort(ByStart(previous))
+		start = previous[len(previous)-1].EndTime
+		// Need to add in the skip day(s) when taking in previous shifts.
+		start = start.Add(fullDay * time.Duration(sc.Config.Shifts.Skip))
+	} else {
+		Random(NewRand(seed), members)
+	}
+
+	membersByShift := make([][]rotang.Member, len(sc.Config.Shifts.Shifts))
+	for shiftIdx := range sc.Config.Shifts.Shifts {
+		var region []rotang.Member
+		for _, m := range members {
+			if f.businessHours(sc, start, shiftIdx, shiftsToSchedule, m) {
+				region = append(region, m)
+			}
+		}
+		if len(previous) > 0 {
+			region = makeFair(region, previous)
+		}
+		membersByShift[shiftIdx] = region
+	}
+	return setSeed(MakeShiftsAfter(sc, start, previous, membersByShift, shiftsToSchedule), seed), nil
+}
+
+// businessHours is true if all the shifts to schedule for shiftIdx are within the business hours of the member.
+func (f *FollowTheSun) businessHours(sc *rotang.Configuration, start time.Time, shiftIdx, shiftsToSchedule int, member rotang.Member) bool {
+	loc := sc.Config.Shifts.Location()
+	duration := sc.Config.Shifts.Shifts[shiftIdx].Duration
+	for i := 0; i < shiftsToSchedule; i++ {
+		shiftStart, _ := ShiftStartEnd(start, i, shiftIdx, &sc.Config.Shifts)
+		for day := 0; day < sc.Config.Shifts.Length; day++ {
+			local := shiftStart.In(loc).AddDate(0, 0, day).In(&member.TZ)
+			year, month, d := local.Date()
+			since := local.Sub(time.Date(year, month, d, 0, 0, 0, 0, local.Location()))
+			if since < f.WorkStart || since+duration > f.WorkEnd {
+				return false
+			}
+		}
+	}
+	return true
+}
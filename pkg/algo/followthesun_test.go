@@ -0,0 +1,112 @@
+package algo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+	rotang "github.com/miekg/rota"
+)
+
+func TestFollowTheSun(t *testing.T) {
+	locs := make(map[string]*time.Location)
+	for _, name := range []string{"Asia/Tokyo", "Europe/Amsterdam", "America/New_York"} {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			t.Fatalf("time.LoadLocation(%q) failed: %v", name, err)
+		}
+		locs[name] = loc
+	}
+	apac := stringToMembers("AB", locs["Asia/Tokyo"])
+	eu := append(stringToMembers("CD", locs["Europe/Amsterdam"]), stringToMembers("G", time.UTC)...)
+	us := stringToMembers("EF", locs["America/New_York"])
+
+	tests := []struct {
+		name     string
+		members  []rotang.Member
+		previous []rotang.ShiftEntry
+		// want has the members in business hours for every shift, an empty region leaves the shift empty.
+		want map[string][]rotang.Member
+	}{{
+		name:    "All regions",
+		members: append(append(append([]rotang.Member{}, apac...), eu...), us...),
+		want: map[string][]rotang.Member{
+			"APAC": apac,
+			"EU":   eu,
+			"US":   us,
+		},
+	}, {
+		name:     "With previous",
+		members:  append(append(append([]rotang.Member{}, apac...), eu...), us...),
+		previous: stringToShifts("CCC", "EU"),
+		want: map[string][]rotang.Member{
+			"APAC": apac,
+			"EU":   eu,
+			"US":   us,
+		},
+	}, {
+		name:    "Nobody in the US",
+		members: append(append([]rotang.Member{}, apac...), eu...),
+		want: map[string][]rotang.Member{
+			"APAC": apac,
+			"EU":   eu,
+		},
+	},
+	}
+
+	for _, tst := range tests {
+		cfg := &rotang.Configuration{
+			Config: rotang.Config{
+				Name: "Test Rota",
+				Shifts: rotang.ShiftConfig{
+					ShiftMembers: 1,
+					Length:       1,
+					Seed:         7357,
+					Shifts: []rotang.Shift{
+						{Name: "APAC", Duration: 8 * time.Hour},
+						{Name: "EU", Duration: 8 * time.Hour},
+						{Name: "US", Duration: 8 * time.Hour},
+					},
+				},
+			},
+		}
+		// Enough shifts for every member to be scheduled twice.
+		const n = 6
+		previous := append([]rotang.ShiftEntry{}, tst.previous...)
+		shifts, err := NewFollowTheSun().Generate(cfg, midnight, tst.previous, append([]rotang.Member{}, tst.members...), n)
+		if err != nil {
+			t.Errorf("%s: Generate(_) failed: %v", tst.name, err)
+			continue
+		}
+		if diff := pretty.Compare(previous, tst.previous); diff != "" {
+			t.Errorf("%s: Generate(_) modified the previous shifts -want +got, %s", tst.name, diff)
+		}
+		load := make(map[string]int)
+		for _, s := range shifts {
+			region := tst.want[s.Name]
+			if len(region) == 0 {
+				if len(s.OnCall) != 0 || s.Comment != UnderstaffedComment(0, 1) {
+					t.Errorf("%s: Generate(_) = %v for shift: %s %v with comment: %q, want nobody scheduled", tst.name, s.OnCall, s.Name, s.StartTime, s.Comment)
+				}
+				continue
+			}
+			for _, o := range s.OnCall {
+				found := false
+				for _, m := range region {
+					found = found || m.Email == o.Email
+				}
+				if !found {
+					t.Errorf("%s: Generate(_) scheduled %q for shift: %s %v, outside business hours", tst.name, o.Email, s.Name, s.StartTime)
+				}
+				load[o.Email]++
+			}
+		}
+		for name, region := range tst.want {
+			for _, m := range region {
+				if got, want := load[m.Email], n/len(region); got != want {
+					t.Errorf("%s: Generate(_) scheduled %q %d times for %s, want: %d", tst.name, m.Email, got, name, want)
+				}
+			}
+		}
+	}
+}
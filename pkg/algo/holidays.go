@@ -0,0 +1,285 @@
+package algo
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/miekg/rota/pkg/ical"
+)
+
+// civilDate is a day on the calendar, independent of time zones.
+type civilDate struct {
+	Year  int
+	Month time.Month
+	Day   int
+}
+
+func dateOf(t time.Time) civilDate {
+	y, m, d := t.Date()
+	return civilDate{y, m, d}
+}
+
+// holidays maps dates to the name of the holiday.
+type holidays map[civilDate]string
+
+// holidayRule returns the date of a holiday in the provided year.
+type holidayRule struct {
+	name string
+	date func(year int) (time.Month, int)
+}
+
+// regions holds the built-in holiday tables.
+var regions = map[string][]holidayRule{
+	"NL": {
+		{"Nieuwjaarsdag", fixed(time.January, 1)},
+		{"Eerste Paasdag", easter(0)},
+		{"Tweede Paasdag", easter(1)},
+		{"Koningsdag", koningsdag},
+		{"Bevrijdingsdag", fixed(time.May, 5)},
+		{"Hemelvaartsdag", easter(39)},
+		{"Eerste Pinksterdag", easter(49)},
+		{"Tweede Pinksterdag", easter(50)},
+		{"Eerste Kerstdag", fixed(time.December, 25)},
+		{"Tweede Kerstdag", fixed(time.December, 26)},
+	},
+	"DE": {
+		{"Neujahr", fixed(time.January, 1)},
+		{"Karfreitag", easter(-2)},
+		{"Ostermontag", easter(1)},
+		{"Tag der Arbeit", fixed(time.May, 1)},
+		{"Christi Himmelfahrt", easter(39)},
+		{"Pfingstmontag", easter(50)},
+		{"Tag der Deutschen Einheit", fixed(time.October, 3)},
+		{"Erster Weihnachtstag", fixed(time.December, 25)},
+		{"Zweiter Weihnachtstag", fixed(time.December, 26)},
+	},
+	"GB": {
+		{"New Year's Day", fixed(time.January, 1)},
+		{"Good Friday", easter(-2)},
+		{"Easter Monday", easter(1)},
+		{"Early May Bank Holiday", weekday(1, time.Monday, time.May)},
+		{"Spring Bank Holiday", weekday(-1, time.Monday, time.May)},
+		{"Summer Bank Holiday", weekday(-1, time.Monday, time.August)},
+		{"Christmas Day", fixed(time.December, 25)},
+		{"Boxing Day", fixed(time.December, 26)},
+	},
+	"US": {
+		{"New Year's Day", fixed(time.January, 1)},
+		{"Martin Luther King Jr. Day", weekday(3, time.Monday, time.January)},
+		{"Washington's Birthday", weekday(3, time.Monday, time.February)},
+		{"Memorial Day", weekday(-1, time.Monday, time.May)},
+		{"Juneteenth", since(2021, fixed(time.June, 19))},
+		{"Independence Day", fixed(time.July, 4)},
+		{"Labor Day", weekday(1, time.Monday, time.September)},
+		{"Columbus Day", weekday(2, time.Monday, time.October)},
+		{"Veterans Day", fixed(time.November, 11)},
+		{"Thanksgiving Day", weekday(4, time.Thursday, time.November)},
+		{"Christmas Day", fixed(time.December, 25)},
+	},
+}
+
+// observed holds the regions where holidays falling in the weekend are also observed on a weekday.
+var observed = map[string]func(time.Time) time.Time{
+	"US": usObserved,
+}
+
+// Regions returns the names of the built-in holiday tables.
+func Regions() []string {
+	var res []string
+	for r := range regions {
+		res = append(res, r)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func fixed(m time.Month, d int) func(int) (time.Month, int) {
+	return func(int) (time.Month, int) {
+		return m, d
+	}
+}
+
+// since returns a rule only producing a date from the year first, the zero month otherwise.
+func since(first int, rule func(int) (time.Month, int)) func(int) (time.Month, int) {
+	return func(year int) (time.Month, int) {
+		if year < first {
+			return 0, 0
+		}
+		return rule(year)
+	}
+}
+
+// easter returns a rule for days relative to Easter Sunday.
+func easter(offset int) func(int) (time.Month, int) {
+	return func(year int) (time.Month, int) {
+		// Anonymous Gregorian algorithm.
+		a := year % 19
+		b, c := year/100, year%100
+		d, e := b/4, b%4
+		f := (b + 8) / 25
+		g := (b - f + 1) / 3
+		h := (19*a + b - d - g + 15) % 30
+		i, k := c/4, c%4
+		l := (32 + 2*e + 2*i - h - k) % 7
+		m := (a + 11*h + 22*l) / 451
+		month := (h + l - 7*m + 114) / 31
+		day := (h+l-7*m+114)%31 + 1
+		t := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC).AddDate(0, 0, offset)
+		return t.Month(), t.Day()
+	}
+}
+
+// weekday returns a rule for the n'th weekday of the month, n < 0 counts from the end of the month.
+func weekday(n int, wd time.Weekday, m time.Month) func(int) (time.Month, int) {
+	return func(year int) (time.Month, int) {
+		if n < 0 {
+			last := time.Date(year, m+1, 0, 0, 0, 0, 0, time.UTC)
+			back := (int(last.Weekday()) - int(wd) + 7) % 7
+			return m, last.Day() - back + (n+1)*7
+		}
+		first := time.Date(year, m, 1, 0, 0, 0, 0, time.UTC)
+		return m, 1 + (int(wd)-int(first.Weekday())+7)%7 + (n-1)*7
+	}
+}
+
+// koningsdag is celebrated on the 27th of April, or the 26th when the 27th falls on a Sunday.
+// Before 2014 it was Koninginnedag on the 30th of April.
+func koningsdag(year int) (time.Month, int) {
+	day := 27
+	if year < 2014 {
+		day = 30
+	}
+	if time.Date(year, time.April, day, 0, 0, 0, 0, time.UTC).Weekday() == time.Sunday {
+		day--
+	}
+	return time.April, day
+}
+
+// usObserved moves holidays on a Saturday to the Friday before and on a Sunday to the Monday after.
+func usObserved(t time.Time) time.Time {
+	switch t.Weekday() {
+	case time.Saturday:
+		return t.AddDate(0, 0, -1)
+	case time.Sunday:
+		return t.AddDate(0, 0, 1)
+	}
+	return t
+}
+
+// regionHolidays returns the holidays of a built-in region for the years in [from, to].
+func regionHolidays(region string, from, to int) (holidays, error) {
+	rules, ok := regions[strings.ToUpper(region)]
+	if !ok {
+		return nil, fmt.Errorf("holidays: region %q not found", region)
+	}
+	observe := observed[strings.ToUpper(region)]
+	res := make(holidays)
+	for y := from; y <= to; y++ {
+		for _, r := range rules {
+			m, d := r.date(y)
+			if m == 0 {
+				continue
+			}
+			res[civilDate{y, m, d}] = r.name
+			if observe == nil {
+				continue
+			}
+			day := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+			if o := observe(day); !o.Equal(day) {
+				if _, ok := res[dateOf(o)]; !ok {
+					res[dateOf(o)] = r.name + " (observed)"
+				}
+			}
+		}
+	}
+	return res, nil
+}
+
+// HolidayDir is the directory the holiday files named in ShiftConfig.Holidays are read from.
+// When empty only the built-in regions can be used.
+var HolidayDir string
+
+// loadHolidays reads holidays from source; either the name of a built-in region or
+// the name of an iCalendar (.ics) or CSV (.csv) file in HolidayDir.
+func loadHolidays(source string, from, to int, loc *time.Location) (holidays, error) {
+	var parse func(io.Reader, *time.Location) (holidays, error)
+	switch strings.ToLower(filepath.Ext(source)) {
+	case ".ics", ".ical":
+		parse = parseICSHolidays
+	case ".csv":
+		parse = parseCSVHolidays
+	default:
+		return regionHolidays(source, from, to)
+	}
+	switch {
+	case HolidayDir == "":
+		return nil, fmt.Errorf("holidays: %s: holiday files are not enabled", source)
+	case filepath.Base(source) != source:
+		return nil, fmt.Errorf("holidays: %s: must be the name of a file in the holiday directory", source)
+	}
+	f, err := os.Open(filepath.Join(HolidayDir, source))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	res, err := parse(f, loc)
+	if err != nil {
+		return nil, fmt.Errorf("holidays: %s: %v", source, err)
+	}
+	return res, nil
+}
+
+// parseICSHolidays adds every day touched by an event as a holiday.
+func parseICSHolidays(r io.Reader, loc *time.Location) (holidays, error) {
+	events, err := ical.Parse(r, loc)
+	if err != nil {
+		return nil, err
+	}
+	res := make(holidays)
+	for _, e := range events {
+		start, end := e.Start.In(loc), e.End.In(loc)
+		for day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, loc); ; day = day.AddDate(0, 0, 1) {
+			res[dateOf(day)] = e.Summary
+			if !day.AddDate(0, 0, 1).Before(end) {
+				break
+			}
+		}
+	}
+	return res, nil
+}
+
+// parseCSVHolidays reads lines of `date,name` with dates formatted as 2006-01-02.
+// The name is optional, lines starting with # are ignored.
+func parseCSVHolidays(r io.Reader, _ *time.Location) (holidays, error) {
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	res := make(holidays)
+	for i, rec := range records {
+		d, err := time.Parse("2006-01-02", strings.TrimSpace(rec[0]))
+		if err != nil {
+			// Allow for a header.
+			if i == 0 && strings.EqualFold(strings.TrimSpace(rec[0]), "date") {
+				continue
+			}
+			return nil, err
+		}
+		var name string
+		if len(rec) > 1 {
+			name = rec[1]
+		}
+		res[dateOf(d)] = name
+	}
+	return res, nil
+}
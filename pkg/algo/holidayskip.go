@@ -0,0 +1,89 @@
+package algo
+
+import (
+	"fmt"
+
+	rotang "github.com/miekg/rota"
+)
+
+// HolidaySkip implements the ShiftModifier interface.
+type HolidaySkip struct {
+}
+
+var _ rotang.ShiftModifier = &HolidaySkip{}
+
+// NewHolidaySkip returns a new instance of the HolidaySkip modifier.
+func NewHolidaySkip() *HolidaySkip {
+	return &HolidaySkip{}
+}
+
+// Name returns the name of this ShiftModifier.
+func (h *HolidaySkip) Name() string {
+	return "HolidaySkip"
+}
+
+// Description describes the ShiftModifier.
+func (h *HolidaySkip) Description() string {
+	return "Does not schedule shifts on public holidays, see ShiftConfig.Holidays."
+}
+
+// Modify modifies provided shifts to avoid the holidays set in ShiftConfig.Holidays.
+// A shift starting on a holiday is moved forward a day, a shift covering a holiday
+// is split in two with the second part starting the day after the holiday.
+// All shifts following a modified shift will be moved accordingly.
+// Eg. with Christmas on Tuesday and Wednesday.
+//
+//	Shift  Days
+//	  1    Mon - Tue
+//	  2    Wed - Thu
+//	  3    Fri - Sat
+//
+// Turns in to:
+//
+//	Shift  Days
+//	  1    Monday
+//	  2    Thursday
+//	  3    Fri - Sat
+//	  4    Sun - Mon
+func (h *HolidaySkip) Modify(sc *rotang.ShiftConfig, shifts []rotang.ShiftEntry) ([]rotang.ShiftEntry, error) {
+	if sc.Holidays == "" {
+		return nil, fmt.Errorf("modifier: %q no holidays configured", h.Name())
+	}
+	if len(shifts) == 0 {
+		return shifts, nil
+	}
+	loc := sc.Location()
+	// Moving shifts can push them into the next year.
+	from, to := shifts[0].StartTime.In(loc).Year()-1, shifts[len(shifts)-1].EndTime.In(loc).Year()+1
+	hs, err := loadHolidays(sc.Holidays, from, to, loc)
+	if err != nil {
+		return nil, err
+	}
+	moveFrom := func(i int) {
+		for j := i; j < len(shifts); j++ {
+			shifts[j].StartTime, shifts[j].EndTime = addDays(shifts[j].StartTime, 1, loc), addDays(shifts[j].EndTime, 1, loc)
+		}
+	}
+	for i := 0; i < len(shifts); i++ {
+		for st := shifts[i].StartTime.In(loc); st.Before(shifts[i].EndTime); {
+			if _, ok := hs[dateOf(st)]; !ok {
+				st = st.AddDate(0, 0, 1)
+				continue
+			}
+			if st.Equal(shifts[i].StartTime) {
+				// Move the shift, and check the new start day again.
+				moveFrom(i)
+				st = shifts[i].StartTime.In(loc)
+				continue
+			}
+			// Split the shift, the second part is checked as the next shift.
+			newShift := shifts[i]
+			newShift.StartTime = st.In(shifts[i].StartTime.Location())
+			shifts[i].EndTime = newShift.StartTime
+			shifts = append(shifts[:i+1], append([]rotang.ShiftEntry{newShift}, shifts[i+1:]...)...)
+			moveFrom(i + 1)
+			break
+		}
+	}
+	return shifts, nil
+}
@@ -0,0 +1,243 @@
+package algo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+	rotang "github.com/miekg/rota"
+)
+
+func TestRegionHolidays(t *testing.T) {
+	tests := []struct {
+		region string
+		year   int
+		name   string
+		want   civilDate
+	}{
+		{region: "NL", year: 2018, name: "Eerste Paasdag", want: civilDate{2018, time.April, 1}},
+		{region: "NL", year: 2019, name: "Hemelvaartsdag", want: civilDate{2019, time.May, 30}},
+		{region: "nl", year: 2018, name: "Koningsdag", want: civilDate{2018, time.April, 27}},
+		{region: "NL", year: 2025, name: "Koningsdag", want: civilDate{2025, time.April, 26}},
+		{region: "NL", year: 2008, name: "Koningsdag", want: civilDate{2008, time.April, 30}},
+		{region: "US", year: 2018, name: "Thanksgiving Day", want: civilDate{2018, time.November, 22}},
+		{region: "US", year: 2018, name: "Memorial Day", want: civilDate{2018, time.May, 28}},
+		{region: "US", year: 2027, name: "Juneteenth", want: civilDate{2027, time.June, 19}},
+		{region: "US", year: 2027, name: "Juneteenth (observed)", want: civilDate{2027, time.June, 18}},
+		{region: "US", year: 2027, name: "Independence Day (observed)", want: civilDate{2027, time.July, 5}},
+		{region: "US", year: 2020, name: "", want: civilDate{2020, time.June, 19}},
+		{region: "GB", year: 2018, name: "Summer Bank Holiday", want: civilDate{2018, time.August, 27}},
+		{region: "DE", year: 2018, name: "Karfreitag", want: civilDate{2018, time.March, 30}},
+	}
+
+	for _, tst := range tests {
+		hs, err := regionHolidays(tst.region, tst.year, tst.year)
+		if err != nil {
+			t.Fatalf("regionHolidays(%q, %d, %d) failed: %v", tst.region, tst.year, tst.year, err)
+		}
+		if got, want := hs[tst.want], tst.name; got != want {
+			t.Errorf("regionHolidays(%q, %d, %d)[%v] = %q want: %q", tst.region, tst.year, tst.year, tst.want, got, want)
+		}
+	}
+
+	if _, err := regionHolidays("Atlantis", 2018, 2018); err == nil {
+		t.Errorf("regionHolidays(%q, _, _) succeeded, want failure", "Atlantis")
+	}
+}
+
+func TestHolidaySkipModify(t *testing.T) {
+	dir, err := ioutil.TempDir("", "holidays")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"holidays.csv": "date,name\n# Comment.\n2018-12-25,Christmas\n2018-12-26\n",
+		"holidays.ics": `BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:xmas
+DTSTART;VALUE=DATE:20181225
+DTEND;VALUE=DATE:20181227
+SUMMARY:Christmas
+END:VEVENT
+END:VCALENDAR
+`,
+		"broken.csv": "25-12-2018,Christmas\n",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	HolidayDir = dir
+	defer func() { HolidayDir = "" }()
+
+	amsTime, err := time.LoadLocation("Europe/Amsterdam")
+	if err != nil {
+		t.Fatalf("time.LoadLocation() failed: %v", err)
+	}
+	// A Monday, Christmas falls on Tuesday and Wednesday.
+	baseTime := time.Date(2018, 12, 24, 0, 0, 0, 0, amsTime)
+
+	xmasShifts := func() []rotang.ShiftEntry {
+		return []rotang.ShiftEntry{
+			{
+				// Monday, Tuesday
+				StartTime: baseTime,
+				EndTime:   baseTime.AddDate(0, 0, 2),
+			}, {
+				// Wednesday, Thursday
+				StartTime: baseTime.AddDate(0, 0, 2),
+				EndTime:   baseTime.AddDate(0, 0, 4),
+			}, {
+				// Friday, Saturday
+				StartTime: baseTime.AddDate(0, 0, 4),
+				EndTime:   baseTime.AddDate(0, 0, 6),
+			},
+		}
+	}
+	xmasWant := []rotang.ShiftEntry{
+		{
+			// Monday
+			StartTime: baseTime,
+			EndTime:   baseTime.AddDate(0, 0, 1),
+		}, {
+			// Thursday
+			StartTime: baseTime.AddDate(0, 0, 3),
+			EndTime:   baseTime.AddDate(0, 0, 4),
+		}, {
+			// Friday, Saturday
+			StartTime: baseTime.AddDate(0, 0, 4),
+			EndTime:   baseTime.AddDate(0, 0, 6),
+		}, {
+			// Sunday, Monday
+			StartTime: baseTime.AddDate(0, 0, 6),
+			EndTime:   baseTime.AddDate(0, 0, 8),
+		},
+	}
+
+	tests := []struct {
+		name   string
+		fail   bool
+		cfg    *rotang.ShiftConfig
+		shifts []rotang.ShiftEntry
+		want   []rotang.ShiftEntry
+	}{{
+		name: "No holidays configured",
+		fail: true,
+		cfg: &rotang.ShiftConfig{
+			TZ: *amsTime,
+		},
+		shifts: xmasShifts(),
+	}, {
+		name: "Unknown region",
+		fail: true,
+		cfg: &rotang.ShiftConfig{
+			TZ:       *amsTime,
+			Holidays: "Atlantis",
+		},
+		shifts: xmasShifts(),
+	}, {
+		name: "Broken file",
+		fail: true,
+		cfg: &rotang.ShiftConfig{
+			TZ:       *amsTime,
+			Holidays: "broken.csv",
+		},
+		shifts: xmasShifts(),
+	}, {
+		name: "File outside the holiday directory",
+		fail: true,
+		cfg: &rotang.ShiftConfig{
+			TZ:       *amsTime,
+			Holidays: filepath.Join(dir, "holidays.csv"),
+		},
+		shifts: xmasShifts(),
+	}, {
+		name: "No holidays in shifts",
+		cfg: &rotang.ShiftConfig{
+			TZ:       *amsTime,
+			Holidays: "NL",
+		},
+		shifts: []rotang.ShiftEntry{
+			{
+				StartTime: baseTime.AddDate(0, 0, -7),
+				EndTime:   baseTime.AddDate(0, 0, -5),
+			},
+		},
+		want: []rotang.ShiftEntry{
+			{
+				StartTime: baseTime.AddDate(0, 0, -7),
+				EndTime:   baseTime.AddDate(0, 0, -5),
+			},
+		},
+	}, {
+		name: "Christmas region",
+		cfg: &rotang.ShiftConfig{
+			TZ:       *amsTime,
+			Holidays: "NL",
+		},
+		shifts: xmasShifts(),
+		want:   xmasWant,
+	}, {
+		name: "Christmas CSV",
+		cfg: &rotang.ShiftConfig{
+			TZ:       *amsTime,
+			Holidays: "holidays.csv",
+		},
+		shifts: xmasShifts(),
+		want:   xmasWant,
+	}, {
+		name: "Christmas iCalendar",
+		cfg: &rotang.ShiftConfig{
+			TZ:       *amsTime,
+			Holidays: "holidays.ics",
+		},
+		shifts: xmasShifts(),
+		want:   xmasWant,
+	}, {
+		name: "Koningsdag start of shift",
+		cfg: &rotang.ShiftConfig{
+			TZ:       *amsTime,
+			Holidays: "NL",
+		},
+		shifts: []rotang.ShiftEntry{
+			{
+				StartTime: time.Date(2018, 4, 27, 9, 0, 0, 0, amsTime),
+				EndTime:   time.Date(2018, 4, 27, 17, 0, 0, 0, amsTime),
+			}, {
+				StartTime: time.Date(2018, 4, 28, 9, 0, 0, 0, amsTime),
+				EndTime:   time.Date(2018, 4, 28, 17, 0, 0, 0, amsTime),
+			},
+		},
+		want: []rotang.ShiftEntry{
+			{
+				StartTime: time.Date(2018, 4, 28, 9, 0, 0, 0, amsTime),
+				EndTime:   time.Date(2018, 4, 28, 17, 0, 0, 0, amsTime),
+			}, {
+				StartTime: time.Date(2018, 4, 29, 9, 0, 0, 0, amsTime),
+				EndTime:   time.Date(2018, 4, 29, 17, 0, 0, 0, amsTime),
+			},
+		},
+	},
+	}
+
+	hs := NewHolidaySkip()
+	for _, tst := range tests {
+		got, err := hs.Modify(tst.cfg, tst.shifts)
+		if got, want := (err != nil), tst.fail; got != want {
+			t.Errorf("%s: hs.Modify(_, _) = %t want: %t, err: %v", tst.name, got, want, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if diff := pretty.Compare(tst.want, got); diff != "" {
+			t.Errorf("%s: hs.Modify(_, _) differ -want +got, %s", tst.name, diff)
+		}
+	}
+}
@@ -0,0 +1,483 @@
+package algo
+
+import (
+	"math/rand"
+	"sort"
+	"time"
+
+	rotang "github.com/miekg/rota"
+)
+
+// Optimal implements a rota Generator treating scheduling as a constraint problem.
+//
+// Hard constraints are never broken; members are not scheduled when Absent, with the NoOncall preference,
+// for shifts overlapping days they opted out of, with less than MinRest between two of their shifts or
+// for more than ShiftConfig.MaxConsecutive shifts in a row.
+// Seats that can not be filled without breaking a hard constraint are left empty.
+//
+// Shifts should have members with every Shift.Coverage tag, a missing tag weighs less than an empty seat
+// but more than any spread in load.
+//
+// Within those constraints a local search minimizes the spread in number of shifts, hours oncall and
+// weekend days between members, taking the previous shifts into account.
+//
+// The search is seeded with Seed, the same seed and input produce the same shifts.
+type Optimal struct {
+	// Iterations bounds the number of local search steps.
+	Iterations int
+	// Budget optionally bounds the time spent searching, 0 means no time bound.
+	// When the Budget runs out before Iterations the result depends on the speed
+	// of the machine, and is no longer determined by the seed alone.
+	Budget time.Duration
+	// MinRest is the minimum time between two shifts of the same member.
+	// The larger of MinRest and ShiftConfig.MinRest is used.
+	MinRest time.Duration
+}
+
+var _ rotang.RotaGenerator = &Optimal{}
+
+// NewOptimal returns an instance of the Optimal generator.
+func NewOptimal() *Optimal {
+	return &Optimal{
+		Iterations: 50000,
+	}
+}
+
+// Name returns the name of the Generator.
+func (o *Optimal) Name() string {
+	return "Optimal"
+}
+
+// Generate generates shifts by searching for the most even assignment of members to shifts.
+// Shifts continue from the end of the previous shifts, and members with fewer previous shifts
+// are scheduled more.
+func (o *Optimal) Generate(sc *rotang.Configuration, start time.Time, previous []rotang.ShiftEntry, members []rotang.Member, shiftsToSchedule int) ([]rotang.ShiftEntry, error) {
+	if len(previous) > 0 {
+		previous = append([]rotang.ShiftEntry{}, previous...)
+		sort.Sort(ByStart(previous))
+		start = previous[len(previous)-1].EndTime
+		// Need to add in the skip day(s) when taking in previous shifts.
+		start = start.Add(fullDay * time.Duration(sc.Config.Shifts.Skip))
+	}
+	seed := Seed(sc)
+	rnd := NewRand(seed)
+	var deadline time.Time
+	if o.Budget > 0 {
+		deadline = time.Now().Add(o.Budget)
+	}
+
+	membersByShift := HandleShiftMembers(sc, members)
+	entriesByShift := HandleShiftEntries(sc, previous)
+	solved := make([]*problem, len(sc.Config.Shifts.Shifts))
+	for shiftIdx := range sc.Config.Shifts.Shifts {
+		p := o.newProblem(sc, start, shiftIdx, membersByShift[shiftIdx], entriesByShift[shiftIdx], shiftsToSchedule)
+		p.search(rnd, o.Iterations, deadline)
+		solved[shiftIdx] = p
+	}
+
+	var res []rotang.ShiftEntry
+	for i := 0; i < shiftsToSchedule; i++ {
+		for shiftIdx, shift := range sc.Config.Shifts.Shifts {
+			p := solved[shiftIdx]
+			sl := p.slots[i]
+			se := rotang.ShiftEntry{
+				Name:      shift.Name,
+				StartTime: sl.start,
+				EndTime:   sl.end,
+				Seed:      seed,
+			}
+			for _, m := range sl.seats {
+				if m < 0 {
+					continue
+				}
+				se.OnCall = append(se.OnCall, rotang.ShiftMember{
+					Email:     p.members[m].Email,
+					ShiftName: shift.Name,
+				})
+			}
+			se = annotateUnderstaffed(&sc.Config.Shifts, se)
+			se = annotateCoverage(se, MissingCoverage(sc, members, se))
+			if sc.Config.Shifts.Trace {
+				se.Trace = p.trace(i)
+			}
+			res = append(res, se)
+		}
+	}
+	return AssignRoles(&sc.Config.Shifts, previous, res), nil
+}
+
+// slot is a shift to be filled with members.
+type slot struct {
+	start, end time.Time
+	hours      float64
+	weekend    int
+	// seats holds the index of the member scheduled, -1 for an empty seat.
+	seats []int
+	// conflicts lists slots too close to this one to be done by the same member.
+	conflicts []int
+}
+
+// load keeps track of the shifts scheduled for a member.
+type load struct {
+	shifts  int
+	hours   float64
+	weekend int
+}
+
+// problem is the scheduling problem for a single shift of the rotation.
+type problem struct {
+	members []rotang.Member
+	slots   []slot
+	// feasible is indexed by [slot][member], and false if scheduling the member breaks a hard constraint.
+	feasible [][]bool
+	// skipped is indexed like feasible and holds the hard constraint broken.
+	skipped [][]rotang.Reason
+	// on is indexed by [member][slot] and true if the member is scheduled for the slot.
+	on [][]bool
+	// active members can be scheduled for at least one slot.
+	active []int
+	load   []load
+	// unit normalizes hours to shifts.
+	unit float64
+	// maxConsecutive limits the number of slots in a row for a member, 0 for no limit.
+	maxConsecutive int
+	// prevRun is the number of previous shifts in a row each member ended with.
+	prevRun []int
+	// coverage are the tags needed in every slot.
+	coverage []string
+}
+
+func (o *Optimal) newProblem(sc *rotang.Configuration, start time.Time, shiftIdx int, members []rotang.Member, previous []rotang.ShiftEntry, shiftsToSchedule int) *problem {
+	loc := sc.Config.Shifts.Location()
+	duration := sc.Config.Shifts.Shifts[shiftIdx].Duration
+	days := sc.Config.Shifts.Length
+
+	members = append([]rotang.Member{}, members...)
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].Email < members[j].Email
+	})
+	idx := make(map[string]int)
+	for i, m := range members {
+		idx[m.Email] = i
+	}
+
+	p := &problem{
+		members:        members,
+		load:           make([]load, len(members)),
+		on:             make([][]bool, len(members)),
+		unit:           float64(days) * duration.Hours(),
+		maxConsecutive: sc.Config.Shifts.MaxConsecutive,
+		prevRun:        make([]int, len(members)),
+		coverage:       sc.Config.Shifts.Shifts[shiftIdx].Coverage,
+	}
+	if p.unit == 0 {
+		p.unit = 1
+	}
+	minRest := o.MinRest
+	if sc.Config.Shifts.MinRest > minRest {
+		minRest = sc.Config.Shifts.MinRest
+	}
+	rs := newRest(&sc.Config.Shifts, previous)
+	for m, member := range members {
+		p.prevRun[m] = rs.run[shiftIdx][member.Email]
+	}
+	lastEnd := make([]time.Time, len(members))
+	for _, e := range previous {
+		for _, oc := range e.OnCall {
+			m, ok := idx[oc.Email]
+			if !ok {
+				continue
+			}
+			p.load[m].shifts++
+			p.load[m].hours += float64(days) * duration.Hours()
+			p.load[m].weekend += weekendDays(e.StartTime.In(loc), days, duration)
+			if e.EndTime.After(lastEnd[m]) {
+				lastEnd[m] = e.EndTime
+			}
+		}
+	}
+
+	for i := 0; i < shiftsToSchedule; i++ {
+		shiftStart, shiftEnd := ShiftStartEnd(start, i, shiftIdx, &sc.Config.Shifts)
+		sl := slot{
+			start:   shiftStart,
+			end:     shiftEnd,
+			hours:   float64(days) * duration.Hours(),
+			weekend: weekendDays(shiftStart.In(loc), days, duration),
+			seats:   make([]int, sc.Config.Shifts.ShiftMembers),
+		}
+		for j := range sl.seats {
+			sl.seats[j] = -1
+		}
+		p.slots = append(p.slots, sl)
+	}
+	for s := range p.slots {
+		for t := range p.slots {
+			if s != t && tooClose(&p.slots[s], &p.slots[t], minRest) {
+				p.slots[s].conflicts = append(p.slots[s].conflicts, t)
+			}
+		}
+	}
+
+	p.feasible = make([][]bool, len(p.slots))
+	p.skipped = make([][]rotang.Reason, len(p.slots))
+	for s := range p.slots {
+		p.feasible[s] = make([]bool, len(members))
+		p.skipped[s] = make([]rotang.Reason, len(members))
+	}
+	for m, member := range members {
+		p.on[m] = make([]bool, len(p.slots))
+		active := false
+		for s, sl := range p.slots {
+			absent := Absent(&sc.Config.Shifts, sl.start, days, duration, member)
+			switch {
+			case absent != "":
+				p.skipped[s][m] = absent
+			case !PersonalPreference(sl.start.In(loc), days, duration, member):
+				p.skipped[s][m] = rotang.SkippedPreference
+			case sl.start.Before(lastEnd[m].Add(minRest)):
+				p.skipped[s][m] = rotang.SkippedRest
+			default:
+				p.feasible[s][m] = true
+				active = true
+			}
+		}
+		if active {
+			p.active = append(p.active, m)
+		}
+	}
+	return p
+}
+
+// tooClose is true if the same member can't be scheduled for both slots.
+func tooClose(a, b *slot, minRest time.Duration) bool {
+	return a.start.Before(b.end.Add(minRest)) && b.start.Before(a.end.Add(minRest))
+}
+
+// weekendDays counts the weekend days touched by a shift, in the location of shiftStart.
+func weekendDays(shiftStart time.Time, shiftDays int, shiftDuration time.Duration) int {
+	var res int
+	for i := 0; i < shiftDays; i++ {
+		todayStart := shiftStart.Add(time.Duration(i) * fullDay)
+		todayEnd := todayStart.Add(shiftDuration)
+		for d := todayStart; d.Before(todayEnd); d = nextMidnight(d) {
+			if wd := d.Weekday(); wd == time.Saturday || wd == time.Sunday {
+				res++
+			}
+		}
+	}
+	return res
+}
+
+// trace returns the Decisions for all members for slot s. The Weight
+// is the number of shifts of the member, including previous shifts.
+func (p *problem) trace(s int) []rotang.Decision {
+	var res []rotang.Decision
+	for m, member := range p.members {
+		d := rotang.Decision{
+			Email:  member.Email,
+			Weight: p.load[m].shifts,
+		}
+		switch {
+		case p.on[m][s]:
+			d.Reason = rotang.Scheduled
+		case !p.feasible[s][m]:
+			d.Reason = p.skipped[s][m]
+		case p.tooMany(s, m):
+			d.Reason = rotang.SkippedConsecutive
+		case !p.canAssign(s, m):
+			d.Reason = rotang.SkippedRest
+		default:
+			d.Reason = rotang.Available
+		}
+		res = append(res, d)
+	}
+	return res
+}
+
+// canAssign is true if member m can be scheduled for slot s.
+func (p *problem) canAssign(s, m int) bool {
+	if !p.feasible[s][m] || p.on[m][s] {
+		return false
+	}
+	for _, t := range p.slots[s].conflicts {
+		if p.on[m][t] {
+			return false
+		}
+	}
+	return !p.tooMany(s, m)
+}
+
+// tooMany is true if scheduling member m for slot s makes for more than maxConsecutive slots in a row.
+func (p *problem) tooMany(s, m int) bool {
+	if p.maxConsecutive <= 0 {
+		return false
+	}
+	run := 1
+	t := s - 1
+	for ; t >= 0 && p.on[m][t]; t-- {
+		run++
+	}
+	if t < 0 {
+		run += p.prevRun[m]
+	}
+	for t := s + 1; t < len(p.slots) && p.on[m][t]; t++ {
+		run++
+	}
+	return run > p.maxConsecutive
+}
+
+// missing returns the coverage of slot s by the members scheduled.
+func (p *problem) missing(s int) *coverage {
+	c := newCoverage(p.coverage)
+	for _, m := range p.slots[s].seats {
+		if m >= 0 {
+			c.add(p.members[m])
+		}
+	}
+	return c
+}
+
+// set schedules member m, or nobody for m < 0, in seat k of slot s.
+func (p *problem) set(s, k, m int) {
+	sl := &p.slots[s]
+	if old := sl.seats[k]; old >= 0 {
+		p.on[old][s] = false
+		p.load[old].shifts--
+		p.load[old].hours -= sl.hours
+		p.load[old].weekend -= sl.weekend
+	}
+	sl.seats[k] = m
+	if m >= 0 {
+		p.on[m][s] = true
+		p.load[m].shifts++
+		p.load[m].hours += sl.hours
+		p.load[m].weekend += sl.weekend
+	}
+}
+
+// emptySeatCost makes filling seats more important than any spread in load,
+// and missingTagCost covering the shifts.
+const (
+	emptySeatCost  = 1e9
+	missingTagCost = 1e6
+)
+
+// cost is the sum of the squared deviations from the mean load of the active members,
+// plus a large cost for every empty seat and every tag missing from a slot.
+func (p *problem) cost() float64 {
+	var res float64
+	for s, sl := range p.slots {
+		for _, m := range sl.seats {
+			if m < 0 {
+				res += emptySeatCost
+			}
+		}
+		res += missingTagCost * float64(len(p.missing(s).missing))
+	}
+	if len(p.active) == 0 {
+		return res
+	}
+	var shifts, hours, weekend float64
+	for _, m := range p.active {
+		shifts += float64(p.load[m].shifts)
+		hours += p.load[m].hours / p.unit
+		weekend += float64(p.load[m].weekend)
+	}
+	n := float64(len(p.active))
+	shifts, hours, weekend = shifts/n, hours/n, weekend/n
+	for _, m := range p.active {
+		ds := float64(p.load[m].shifts) - shifts
+		dh := p.load[m].hours/p.unit - hours
+		dw := float64(p.load[m].weekend) - weekend
+		res += ds*ds + dh*dh + dw*dw
+	}
+	return res
+}
+
+// search fills the slots greedily, and improves on that with a local search
+// moving members between seats and swapping members of two seats. A zero
+// deadline does not bound the search in time.
+func (p *problem) search(rnd *rand.Rand, iterations int, deadline time.Time) {
+	if len(p.active) == 0 || len(p.slots) == 0 || len(p.slots[0].seats) == 0 {
+		return
+	}
+	order := rnd.Perm(len(p.active))
+	for s := range p.slots {
+		for k := range p.slots[s].seats {
+			c := p.missing(s)
+			best := -1
+			for _, i := range order {
+				m := p.active[i]
+				if !p.canAssign(s, m) {
+					continue
+				}
+				if best < 0 {
+					best = m
+					continue
+				}
+				// Members with missing tags go first, then the ones with the least load.
+				if cm, cb := c.covers(p.members[m]), c.covers(p.members[best]); cm != cb {
+					if cm > cb {
+						best = m
+					}
+					continue
+				}
+				if p.load[m].shifts < p.load[best].shifts ||
+					(p.load[m].shifts == p.load[best].shifts && p.load[m].weekend < p.load[best].weekend) {
+					best = m
+				}
+			}
+			p.set(s, k, best)
+		}
+	}
+
+	seats := len(p.slots[0].seats)
+	cur := p.cost()
+	for i := 0; i < iterations; i++ {
+		if i%256 == 0 && !deadline.IsZero() && time.Now().After(deadline) {
+			return
+		}
+		s, k := rnd.Intn(len(p.slots)), rnd.Intn(seats)
+		old := p.slots[s].seats[k]
+		if rnd.Intn(2) == 0 {
+			// Move; replace the member in the seat.
+			m := p.active[rnd.Intn(len(p.active))]
+			p.set(s, k, -1)
+			if m == old || !p.canAssign(s, m) {
+				p.set(s, k, old)
+				continue
+			}
+			p.set(s, k, m)
+			if c := p.cost(); c <= cur {
+				cur = c
+				continue
+			}
+			p.set(s, k, old)
+			continue
+		}
+		// Swap; exchange the members of two seats.
+		t, l := rnd.Intn(len(p.slots)), rnd.Intn(seats)
+		other := p.slots[t].seats[l]
+		if s == t || old < 0 || other < 0 || old == other {
+			continue
+		}
+		p.set(s, k, -1)
+		p.set(t, l, -1)
+		if !p.canAssign(s, other) || !p.canAssign(t, old) {
+			p.set(s, k, old)
+			p.set(t, l, other)
+			continue
+		}
+		p.set(s, k, other)
+		p.set(t, l, old)
+		if c := p.cost(); c <= cur {
+			cur = c
+			continue
+		}
+		p.set(s, k, -1)
+		p.set(t, l, -1)
+		p.set(s, k, old)
+		p.set(t, l, other)
+	}
+}
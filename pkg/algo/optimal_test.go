@@ -0,0 +1,262 @@
+package algo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+	rotang "github.com/miekg/rota"
+)
+
+func TestGenerateOptimal(t *testing.T) {
+	// midnight is a Wednesday.
+	monday := midnight.Add(5 * fullDay)
+
+	tests := []struct {
+		name     string
+		fail     bool
+		start    time.Time
+		length   int
+		members  []rotang.Member
+		previous []rotang.ShiftEntry
+		minRest  time.Duration
+		// cfgMinRest and maxConsecutive are set in the ShiftConfig.
+		cfgMinRest     time.Duration
+		maxConsecutive int
+		schedule       int
+		// want is the number of shifts scheduled per member.
+		want map[string]int
+		// wantEmpty is the number of empty seats.
+		wantEmpty int
+		// maxWeekend is the maximum number of weekend days per member, if set.
+		maxWeekend int
+	}{{
+		name:     "Even spread",
+		start:    monday,
+		length:   1,
+		members:  stringToMembers("ABCDE", time.UTC),
+		schedule: 20,
+		want: map[string]int{
+			"A@A.com": 4,
+			"B@B.com": 4,
+			"C@C.com": 4,
+			"D@D.com": 4,
+			"E@E.com": 4,
+		},
+	}, {
+		name:   "Hard constraints",
+		start:  monday,
+		length: 1,
+		members: []rotang.Member{
+			{
+				Email:       "a@oncall.com",
+				Preferences: []rotang.Preference{rotang.NoOncall},
+			}, {
+				Email:       "b@oncall.com",
+				Preferences: []rotang.Preference{rotang.NoWeekends},
+			}, {
+				Email: "c@oncall.com",
+				OOO: []rotang.OOO{
+					{
+						Start:    monday,
+						Duration: 7 * fullDay,
+					},
+				},
+			}, {
+				Email: "d@oncall.com",
+			},
+		},
+		schedule: 15,
+		want: map[string]int{
+			"b@oncall.com": 5,
+			"c@oncall.com": 5,
+			"d@oncall.com": 5,
+		},
+	}, {
+		name:     "Minimum rest",
+		start:    monday,
+		length:   1,
+		members:  stringToMembers("AB", time.UTC),
+		minRest:  fullDay,
+		schedule: 6,
+		want: map[string]int{
+			"A@A.com": 3,
+			"B@B.com": 3,
+		},
+	}, {
+		name:      "Not enough rest",
+		start:     monday,
+		length:    1,
+		members:   stringToMembers("A", time.UTC),
+		minRest:   fullDay,
+		schedule:  4,
+		wantEmpty: 2,
+		want: map[string]int{
+			"A@A.com": 2,
+		},
+	}, {
+		name:       "Rotation minimum rest",
+		start:      monday,
+		length:     1,
+		members:    stringToMembers("A", time.UTC),
+		cfgMinRest: fullDay,
+		schedule:   4,
+		wantEmpty:  2,
+		want: map[string]int{
+			"A@A.com": 2,
+		},
+	}, {
+		name:           "Maximum consecutive",
+		start:          monday,
+		length:         1,
+		members:        stringToMembers("A", time.UTC),
+		previous:       stringToShifts("A", "MTV All Day"),
+		maxConsecutive: 2,
+		schedule:       4,
+		wantEmpty:      1,
+		want: map[string]int{
+			"A@A.com": 3,
+		},
+	}, {
+		name:     "Previous shifts",
+		start:    monday,
+		length:   1,
+		members:  stringToMembers("ABC", time.UTC),
+		previous: stringToShifts("AAAB", "MTV All Day"),
+		schedule: 5,
+		want: map[string]int{
+			"B@B.com": 2,
+			"C@C.com": 3,
+		},
+	}, {
+		name:       "Weekend load",
+		start:      monday,
+		length:     1,
+		members:    stringToMembers("ABCD", time.UTC),
+		schedule:   16,
+		maxWeekend: 1,
+		want: map[string]int{
+			"A@A.com": 4,
+			"B@B.com": 4,
+			"C@C.com": 4,
+			"D@D.com": 4,
+		},
+	},
+	}
+
+	for _, tst := range tests {
+		var sm []rotang.ShiftMember
+		for _, m := range tst.members {
+			sm = append(sm, rotang.ShiftMember{Email: m.Email, ShiftName: "MTV All Day"})
+		}
+		cfg := &rotang.Configuration{
+			Config: rotang.Config{
+				Name: "test rota",
+				Shifts: rotang.ShiftConfig{
+					ShiftMembers:   1,
+					Length:         tst.length,
+					MinRest:        tst.cfgMinRest,
+					MaxConsecutive: tst.maxConsecutive,
+					Shifts: []rotang.Shift{
+						{
+							Name:     "MTV All Day",
+							Duration: fullDay,
+						},
+					},
+				},
+			},
+			Members: sm,
+		}
+		cfg.Config.Shifts.Seed = 42
+		o := NewOptimal()
+		o.MinRest = tst.minRest
+		previous := append([]rotang.ShiftEntry{}, tst.previous...)
+		got, err := o.Generate(cfg, tst.start, tst.previous, tst.members, tst.schedule)
+		if diff := pretty.Compare(previous, tst.previous); diff != "" {
+			t.Errorf("%s: Generate(_) modified the previous shifts -want +got, %s", tst.name, diff)
+		}
+		if got, want := (err != nil), tst.fail; got != want {
+			t.Errorf("%s: Generate(_) = %t want: %t, err: %v", tst.name, got, want, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if got, want := len(got), tst.schedule; got != want {
+			t.Errorf("%s: Generate(_) = %d shifts want: %d", tst.name, got, want)
+		}
+		counts, weekends := make(map[string]int), make(map[string]int)
+		var empty int
+		for _, s := range got {
+			if len(s.OnCall) == 0 {
+				empty++
+			}
+			for _, o := range s.OnCall {
+				counts[o.Email]++
+				weekends[o.Email] += weekendDays(s.StartTime, tst.length, fullDay)
+			}
+		}
+		if diff := pretty.Compare(tst.want, counts); diff != "" {
+			t.Errorf("%s: Generate(_) shifts per member differ -want +got, %s", tst.name, diff)
+		}
+		if got, want := empty, tst.wantEmpty; got != want {
+			t.Errorf("%s: Generate(_) = %d empty shifts want: %d", tst.name, got, want)
+		}
+		for email, w := range weekends {
+			if tst.maxWeekend > 0 && w > tst.maxWeekend {
+				t.Errorf("%s: Generate(_) = %d weekend days for %s want at most: %d", tst.name, w, email, tst.maxWeekend)
+			}
+		}
+		minRest := tst.minRest
+		if tst.cfgMinRest > minRest {
+			minRest = tst.cfgMinRest
+		}
+		checkHardConstraints(t, tst.name, cfg, tst.members, minRest, got)
+		if tst.maxConsecutive > 0 {
+			// Only used with a single member, any shift continues the run.
+			run := 0
+			for _, s := range append(append([]rotang.ShiftEntry{}, tst.previous...), got...) {
+				if len(s.OnCall) == 0 {
+					run = 0
+					continue
+				}
+				if run++; run > tst.maxConsecutive {
+					t.Errorf("%s: Generate(_) scheduled more than %d shifts in a row at %v", tst.name, tst.maxConsecutive, s.StartTime)
+				}
+			}
+		}
+
+		again, err := o.Generate(cfg, tst.start, tst.previous, tst.members, tst.schedule)
+		if err != nil {
+			t.Fatalf("%s: Generate(_) failed: %v", tst.name, err)
+		}
+		if diff := pretty.Compare(got, again); diff != "" {
+			t.Errorf("%s: Generate(_) with the same seed differ -first +second, %s", tst.name, diff)
+		}
+	}
+}
+
+func checkHardConstraints(t *testing.T, name string, cfg *rotang.Configuration, members []rotang.Member, minRest time.Duration, shifts []rotang.ShiftEntry) {
+	t.Helper()
+	byEmail := make(map[string]rotang.Member)
+	for _, m := range members {
+		byEmail[m.Email] = m
+	}
+	lastEnd := make(map[string]time.Time)
+	for _, s := range shifts {
+		for _, o := range s.OnCall {
+			m := byEmail[o.Email]
+			duration := cfg.Config.Shifts.Shifts[0].Duration
+			if PersonalOutage(s.StartTime, cfg.Config.Shifts.Length, duration, m) {
+				t.Errorf("%s: %s scheduled during OOO at %v", name, m.Email, s.StartTime)
+			}
+			if !PersonalPreference(s.StartTime, cfg.Config.Shifts.Length, duration, m) {
+				t.Errorf("%s: %s scheduled against preferences at %v", name, m.Email, s.StartTime)
+			}
+			if end, ok := lastEnd[m.Email]; ok && s.StartTime.Before(end.Add(minRest)) {
+				t.Errorf("%s: %s scheduled at %v, less than %v after %v", name, m.Email, s.StartTime, minRest, end)
+			}
+			lastEnd[m.Email] = s.EndTime
+		}
+	}
+}
@@ -23,11 +23,12 @@ func NewRandomGen() *RandomGen {
 
 // Generate generates shifts usings members at random.
 func (r *RandomGen) Generate(sc *rotang.Configuration, start time.Time, previous []rotang.ShiftEntry, members []rotang.Member, shiftsToSchedule int) ([]rotang.ShiftEntry, error) {
-	Random(members)
+	seed := Seed(sc)
+	Random(NewRand(seed), members)
 	if len(previous) > 0 {
 		start = previous[len(previous)-1].EndTime
 	}
-	return MakeShifts(sc, start, HandleShiftMembers(sc, members), shiftsToSchedule), nil
+	return setSeed(MakeShiftsAfter(sc, start, previous, HandleShiftMembers(sc, members), shiftsToSchedule), seed), nil
 }
 
 // Name returns the name of this Generator.
@@ -9,7 +9,6 @@
 package algo
 
 import (
-	"math/rand"
 	"testing"
 	"time"
 
@@ -55,54 +54,55 @@ func TestGenerateFairRandom(t *testing.T) {
 				Name: "Test Shift",
 				OnCall: []rotang.ShiftMember{
 					{
-						Email:     "I@I.com",
+						Email:     "J@J.com",
 						ShiftName: "Test Shift",
 					},
 				},
 				StartTime: midnight,
 				EndTime:   midnight.Add(5 * fullDay),
 				Comment:   "",
+				Seed:      7357,
 			}, {
 				Name: "Test Shift",
 				OnCall: []rotang.ShiftMember{
 					{
-						Email:     "C@C.com",
+						Email:     "I@I.com",
 						ShiftName: "Test Shift",
 					},
 				},
 				StartTime: midnight.Add(7 * fullDay),
 				EndTime:   midnight.Add(7*fullDay + 5*fullDay),
 				Comment:   "",
+				Seed:      7357,
 			}, {
 				Name: "Test Shift",
 				OnCall: []rotang.ShiftMember{
 					{
-						Email:     "F@F.com",
+						Email:     "H@H.com",
 						ShiftName: "Test Shift",
 					},
 				},
 				StartTime: midnight.Add(14 * fullDay),
 				EndTime:   midnight.Add(14*fullDay + 5*fullDay),
 				Comment:   "",
+				Seed:      7357,
 			}, {
 				Name: "Test Shift",
 				OnCall: []rotang.ShiftMember{
 					{
-						Email:     "A@A.com",
+						Email:     "D@D.com",
 						ShiftName: "Test Shift",
 					},
 				},
 				StartTime: midnight.Add(21 * fullDay),
 				EndTime:   midnight.Add(21*fullDay + 5*fullDay),
 				Comment:   "",
+				Seed:      7357,
 			},
 		},
 	},
 	}
 
-	// Should give the same pseudo random sequence every time.
-	rand.Seed(7357)
-
 	as := New()
 	as.Register(NewFair())
 	generator, err := as.Fetch("Fair")
@@ -112,6 +112,8 @@ func TestGenerateFairRandom(t *testing.T) {
 
 	for _, tst := range tests {
 		tst.cfg.Members = stringToShiftMembers(tst.members, tst.cfg.Config.Shifts.Shifts[0].Name)
+		// Should give the same pseudo random sequence every time.
+		tst.cfg.Config.Shifts.Seed = 7357
 		shifts, err := generator.Generate(tst.cfg, tst.start, stringToShifts(tst.previous, tst.cfg.Config.Shifts.Shifts[0].Name), stringToMembers(tst.members, mtvTime), tst.numShifts)
 		if got, want := (err != nil), tst.fail; got != want {
 			t.Errorf("%s: Generate(_) = %t want: %t, err: %v", tst.name, got, want, err)
@@ -163,46 +165,50 @@ func TestGenerateRandom(t *testing.T) {
 				Name: "Test Shift",
 				OnCall: []rotang.ShiftMember{
 					{
-						Email:     "I@I.com",
+						Email:     "J@J.com",
 						ShiftName: "Test Shift",
 					},
 				},
 				StartTime: midnight,
 				EndTime:   midnight.Add(5 * fullDay),
 				Comment:   "",
+				Seed:      7357,
 			}, {
 				Name: "Test Shift",
 				OnCall: []rotang.ShiftMember{
 					{
-						Email:     "C@C.com",
+						Email:     "I@I.com",
 						ShiftName: "Test Shift",
 					},
 				},
 				StartTime: midnight.Add(7 * fullDay),
 				EndTime:   midnight.Add(7*fullDay + 5*fullDay),
 				Comment:   "",
+				Seed:      7357,
 			}, {
 				Name: "Test Shift",
 				OnCall: []rotang.ShiftMember{
 					{
-						Email:     "F@F.com",
+						Email:     "H@H.com",
 						ShiftName: "Test Shift",
 					},
 				},
 				StartTime: midnight.Add(14 * fullDay),
 				EndTime:   midnight.Add(14*fullDay + 5*fullDay),
 				Comment:   "",
+				Seed:      7357,
 			}, {
 				Name: "Test Shift",
 				OnCall: []rotang.ShiftMember{
 					{
-						Email:     "A@A.com",
+						Email:     "D@D.com",
 						ShiftName: "Test Shift",
 					},
 				},
 				StartTime: midnight.Add(21 * fullDay),
 				EndTime:   midnight.Add(21*fullDay + 5*fullDay),
 				Comment:   "",
+				Seed:      7357,
 			},
 		}}, {
 		name: "Random with previous",
@@ -231,24 +237,26 @@ func TestGenerateRandom(t *testing.T) {
 				Name: "Test Shift",
 				OnCall: []rotang.ShiftMember{
 					{
-						Email:     "D@D.com",
+						Email:     "E@E.com",
 						ShiftName: "Test Shift",
 					},
 				},
 				StartTime: midnight.Add(2 * fullDay),                       // Shift skips two days.
 				EndTime:   midnight.Add(fullDay + 5*fullDay + time.Hour*8), // Length of the shift is 5 days.
 				Comment:   "",
+				Seed:      7357,
 			}, {
 				Name: "Test Shift",
 				OnCall: []rotang.ShiftMember{
 					{
-						Email:     "E@E.com",
+						Email:     "D@D.com",
 						ShiftName: "Test Shift",
 					},
 				},
 				StartTime: midnight.Add(9 * fullDay),
 				EndTime:   midnight.Add(8*fullDay + 5*fullDay + time.Hour*8),
 				Comment:   "",
+				Seed:      7357,
 			}, {
 				Name: "Test Shift",
 				OnCall: []rotang.ShiftMember{
@@ -260,61 +268,67 @@ func TestGenerateRandom(t *testing.T) {
 				StartTime: midnight.Add(16 * fullDay),
 				EndTime:   midnight.Add(15*fullDay + 5*fullDay + time.Hour*8),
 				Comment:   "",
+				Seed:      7357,
 			}, {
 				Name: "Test Shift",
 				OnCall: []rotang.ShiftMember{
 					{
-						Email:     "B@B.com",
+						Email:     "C@C.com",
 						ShiftName: "Test Shift",
 					},
 				},
 				StartTime: midnight.Add(23 * fullDay),
 				EndTime:   midnight.Add(22*fullDay + 5*fullDay + time.Hour*8),
 				Comment:   "",
+				Seed:      7357,
 			}, {
 				Name: "Test Shift",
 				OnCall: []rotang.ShiftMember{
 					{
-						Email:     "A@A.com",
+						Email:     "B@B.com",
 						ShiftName: "Test Shift",
 					},
 				},
 				StartTime: midnight.Add(30 * fullDay),
 				EndTime:   midnight.Add(29*fullDay + 5*fullDay + time.Hour*8),
 				Comment:   "",
+				Seed:      7357,
 			}, {
 				Name: "Test Shift",
 				OnCall: []rotang.ShiftMember{
 					{
-						Email:     "C@C.com",
+						Email:     "A@A.com",
 						ShiftName: "Test Shift",
 					},
 				},
 				StartTime: midnight.Add(37 * fullDay),
 				EndTime:   midnight.Add(36*fullDay + 5*fullDay + time.Hour*8),
 				Comment:   "",
+				Seed:      7357,
 			}, {
 				Name: "Test Shift",
 				OnCall: []rotang.ShiftMember{
 					{
-						Email:     "D@D.com",
+						Email:     "E@E.com",
 						ShiftName: "Test Shift",
 					},
 				},
 				StartTime: midnight.Add(44 * fullDay),
 				EndTime:   midnight.Add(43*fullDay + 5*fullDay + time.Hour*8),
 				Comment:   "",
+				Seed:      7357,
 			}, {
 				Name: "Test Shift",
 				OnCall: []rotang.ShiftMember{
 					{
-						Email:     "E@E.com",
+						Email:     "D@D.com",
 						ShiftName: "Test Shift",
 					},
 				},
 				StartTime: midnight.Add(51 * fullDay),
 				EndTime:   midnight.Add(50*fullDay + 5*fullDay + time.Hour*8),
 				Comment:   "",
+				Seed:      7357,
 			}, {
 				Name: "Test Shift",
 				OnCall: []rotang.ShiftMember{
@@ -326,23 +340,24 @@ func TestGenerateRandom(t *testing.T) {
 				StartTime: midnight.Add(58 * fullDay),
 				EndTime:   midnight.Add(57*fullDay + 5*fullDay + time.Hour*8),
 				Comment:   "",
+				Seed:      7357,
 			}, {
 				Name: "Test Shift",
 				OnCall: []rotang.ShiftMember{
 					{
-						Email:     "B@B.com",
+						Email:     "C@C.com",
 						ShiftName: "Test Shift",
 					},
 				},
 				StartTime: midnight.Add(65 * fullDay),
 				EndTime:   midnight.Add(64*fullDay + 5*fullDay + time.Hour*8),
 				Comment:   "",
+				Seed:      7357,
 			},
 		},
 	},
 	}
 
-	rand.Seed(7357)
 	as := New()
 	as.Register(NewRandomGen())
 	generator, err := as.Fetch("Random")
@@ -352,6 +367,8 @@ func TestGenerateRandom(t *testing.T) {
 
 	for _, tst := range tests {
 		tst.cfg.Members = stringToShiftMembers(tst.members, tst.cfg.Config.Shifts.Shifts[0].Name)
+		// Should give the same pseudo random sequence every time.
+		tst.cfg.Config.Shifts.Seed = 7357
 		shifts, err := generator.Generate(tst.cfg, tst.start, stringToShifts(tst.previous, tst.cfg.Config.Shifts.Shifts[0].Name), stringToMembers(tst.members, mtvTime), tst.numShifts)
 		if got, want := (err != nil), tst.fail; got != want {
 			t.Errorf("%s: Generate(_) = %t want: %t, err: %v", tst.name, got, want, err)
@@ -365,3 +382,61 @@ func TestGenerateRandom(t *testing.T) {
 	}
 
 }
+
+func TestRandom(t *testing.T) {
+	// Every permutation of three members should turn up about as often.
+	const runs = 60000
+	counts := make(map[string]int)
+	rnd := NewRand(7357)
+	for i := 0; i < runs; i++ {
+		members := stringToMembers("ABC", time.UTC)
+		Random(rnd, members)
+		counts[membersToString(members)]++
+	}
+	if got, want := len(counts), 6; got != want {
+		t.Fatalf("Random(_, _) = %d permutations want: %d", got, want)
+	}
+	for perm, n := range counts {
+		if n < runs/6*95/100 || n > runs/6*105/100 {
+			t.Errorf("Random(_, _) permutation %q = %d times want: ~%d", perm, n, runs/6)
+		}
+	}
+}
+
+func TestSeed(t *testing.T) {
+	cfg := &rotang.Configuration{
+		Config: rotang.Config{
+			Name: "Test Rota",
+			Shifts: rotang.ShiftConfig{
+				Length: 1,
+				Shifts: []rotang.Shift{
+					{
+						Name:     "Test Shift",
+						Duration: fullDay,
+					},
+				},
+				ShiftMembers: 1,
+			},
+		},
+		Members: stringToShiftMembers("ABCDEFGHIJ", "Test Shift"),
+	}
+
+	shifts, err := NewRandomGen().Generate(cfg, midnight, nil, stringToMembers("ABCDEFGHIJ", time.UTC), 10)
+	if err != nil {
+		t.Fatalf("Generate(_) failed: %v", err)
+	}
+	seed := shifts[0].Seed
+	if seed == 0 {
+		t.Fatalf("Generate(_) did not record the seed")
+	}
+
+	// Regenerate with the recorded seed.
+	cfg.Config.Shifts.Seed = seed
+	again, err := NewRandomGen().Generate(cfg, midnight, nil, stringToMembers("ABCDEFGHIJ", time.UTC), 10)
+	if err != nil {
+		t.Fatalf("Generate(_) failed: %v", err)
+	}
+	if diff := pretty.Compare(shifts, again); diff != "" {
+		t.Errorf("Generate(_) with seed: %d differs -want +got: %s", seed, diff)
+	}
+}
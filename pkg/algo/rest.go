@@ -0,0 +1,67 @@
+package algo
+
+import (
+	"sort"
+	"time"
+
+	rotang "github.com/miekg/rota"
+)
+
+// rest keeps track of the shifts members had, to enforce ShiftConfig.MinRest and ShiftConfig.MaxConsecutive.
+type rest struct {
+	sc      *rotang.ShiftConfig
+	shifts  map[string]int
+	lastEnd map[string]time.Time
+	// run is indexed by shift and holds the number of consecutive shifts up to now per member.
+	run []map[string]int
+}
+
+// newRest returns a rest with the previous shifts added.
+func newRest(sc *rotang.ShiftConfig, previous []rotang.ShiftEntry) *rest {
+	r := &rest{
+		sc:      sc,
+		shifts:  make(map[string]int),
+		lastEnd: make(map[string]time.Time),
+		run:     make([]map[string]int, len(sc.Shifts)),
+	}
+	for i, s := range sc.Shifts {
+		r.shifts[s.Name] = i
+		r.run[i] = make(map[string]int)
+	}
+	previous = append([]rotang.ShiftEntry{}, previous...)
+	sort.Sort(ByStart(previous))
+	for _, se := range previous {
+		r.add(se)
+	}
+	return r
+}
+
+// add records the members oncall for the shift.
+func (r *rest) add(se rotang.ShiftEntry) {
+	for _, o := range se.OnCall {
+		if se.EndTime.After(r.lastEnd[o.Email]) {
+			r.lastEnd[o.Email] = se.EndTime
+		}
+	}
+	idx, ok := r.shifts[se.Name]
+	if !ok {
+		return
+	}
+	run := make(map[string]int)
+	for _, o := range se.OnCall {
+		run[o.Email] = r.run[idx][o.Email] + 1
+	}
+	r.run[idx] = run
+}
+
+// check returns why the member can not be scheduled for the shift starting at start,
+// or an empty Reason if the member can be.
+func (r *rest) check(shiftIdx int, start time.Time, email string) rotang.Reason {
+	if end, ok := r.lastEnd[email]; ok && r.sc.MinRest > 0 && start.Before(end.Add(r.sc.MinRest)) {
+		return rotang.SkippedRest
+	}
+	if r.sc.MaxConsecutive > 0 && r.run[shiftIdx][email] >= r.sc.MaxConsecutive {
+		return rotang.SkippedConsecutive
+	}
+	return ""
+}
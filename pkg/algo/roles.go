@@ -0,0 +1,88 @@
+package algo
+
+import (
+	"sort"
+
+	rotang "github.com/miekg/rota"
+)
+
+// AssignRoles gives the members of the shifts the roles of ShiftConfig.Roles, rotating them through
+// the roles: a member moves up one role every shift, eg. last shift's secondary becomes this shift's
+// primary, and the primary moves to the back. Members new to the rotation start at the back as well.
+// The members of each shift are reordered to match the roles, the primary first.
+// Previous shifts without roles are taken to list their members in role order.
+func AssignRoles(sc *rotang.ShiftConfig, previous, shifts []rotang.ShiftEntry) []rotang.ShiftEntry {
+	if len(sc.Roles) == 0 {
+		return shifts
+	}
+	rr := newRoleRotation(sc, previous)
+	for i := range shifts {
+		rr.assign(&shifts[i])
+	}
+	return shifts
+}
+
+// roleRotation tracks the role each member had last.
+type roleRotation struct {
+	sc   *rotang.ShiftConfig
+	last map[string]int
+}
+
+func newRoleRotation(sc *rotang.ShiftConfig, previous []rotang.ShiftEntry) *roleRotation {
+	rr := &roleRotation{
+		sc:   sc,
+		last: make(map[string]int),
+	}
+	previous = append([]rotang.ShiftEntry{}, previous...)
+	sort.Sort(ByStart(previous))
+	for _, se := range previous {
+		rr.add(se)
+	}
+	return rr
+}
+
+// roleIndex returns the index in ShiftConfig.Roles of the i'th member of the shift,
+// len(ShiftConfig.Roles) for members without a role.
+func (rr *roleRotation) roleIndex(se rotang.ShiftEntry, i int) int {
+	role := se.OnCall[i].Role
+	if role == "" {
+		if i < len(rr.sc.Roles) {
+			return i
+		}
+		return len(rr.sc.Roles)
+	}
+	for j, r := range rr.sc.Roles {
+		if r == role {
+			return j
+		}
+	}
+	return len(rr.sc.Roles)
+}
+
+func (rr *roleRotation) add(se rotang.ShiftEntry) {
+	for i, o := range se.OnCall {
+		rr.last[o.Email] = rr.roleIndex(se, i)
+	}
+}
+
+// next returns the index of the role the member should get next, lower comes first.
+func (rr *roleRotation) next(email string) int {
+	idx, ok := rr.last[email]
+	if !ok || idx == 0 {
+		return len(rr.sc.Roles)
+	}
+	return idx - 1
+}
+
+func (rr *roleRotation) assign(se *rotang.ShiftEntry) {
+	sort.SliceStable(se.OnCall, func(i, j int) bool {
+		return rr.next(se.OnCall[i].Email) < rr.next(se.OnCall[j].Email)
+	})
+	for i := range se.OnCall {
+		se.OnCall[i].Role = ""
+		if i < len(rr.sc.Roles) {
+			se.OnCall[i].Role = rr.sc.Roles[i]
+		}
+	}
+	rr.add(*se)
+}
@@ -0,0 +1,123 @@
+package algo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+	rotang "github.com/miekg/rota"
+)
+
+// membersToShifts returns consecutive daily shifts from start, one per entry of in with its members oncall.
+func membersToShifts(in []string, start time.Time) []rotang.ShiftEntry {
+	var res []rotang.ShiftEntry
+	for i, m := range in {
+		res = append(res, rotang.ShiftEntry{
+			Name:      "Day",
+			StartTime: start.Add(time.Duration(i) * fullDay),
+			EndTime:   start.Add(time.Duration(i+1) * fullDay),
+			OnCall:    stringToShiftMembers(m, "Day"),
+		})
+	}
+	return res
+}
+
+func TestAssignRoles(t *testing.T) {
+	tests := []struct {
+		name     string
+		roles    []rotang.Role
+		previous []string
+		shifts   []string
+		// want has the members of each shift in role order.
+		want []string
+	}{{
+		name:   "No roles",
+		shifts: []string{"AB", "AB"},
+		want:   []string{"AB", "AB"},
+	}, {
+		name:   "Secondary becomes primary",
+		roles:  []rotang.Role{rotang.Primary, rotang.Secondary},
+		shifts: []string{"AB", "AB", "AB"},
+		want:   []string{"AB", "BA", "AB"},
+	}, {
+		name:     "Previous without roles",
+		roles:    []rotang.Role{rotang.Primary, rotang.Secondary},
+		previous: []string{"AB"},
+		shifts:   []string{"AB"},
+		want:     []string{"BA"},
+	}, {
+		name:   "Shadow moves up",
+		roles:  []rotang.Role{rotang.Primary, rotang.Secondary, rotang.Shadow},
+		shifts: []string{"ABC", "ABC", "ABC"},
+		want:   []string{"ABC", "BCA", "CAB"},
+	}, {
+		name:     "New member",
+		roles:    []rotang.Role{rotang.Primary, rotang.Secondary},
+		previous: []string{"AB"},
+		shifts:   []string{"CB", "AC"},
+		want:     []string{"BC", "CA"},
+	}, {
+		name:   "More members than roles",
+		roles:  []rotang.Role{rotang.Primary},
+		shifts: []string{"AB", "AB"},
+		want:   []string{"AB", "BA"},
+	},
+	}
+
+	for _, tst := range tests {
+		sc := &rotang.ShiftConfig{
+			Roles: tst.roles,
+		}
+		previous := membersToShifts(tst.previous, midnight.Add(-time.Duration(len(tst.previous))*fullDay))
+		shifts := AssignRoles(sc, previous, membersToShifts(tst.shifts, midnight))
+		var got []string
+		for _, s := range shifts {
+			var members []byte
+			for i, o := range s.OnCall {
+				var role rotang.Role
+				if i < len(tst.roles) {
+					role = tst.roles[i]
+				}
+				if o.Role != role {
+					t.Errorf("%s: AssignRoles(_) = %q has role: %q at %d, want: %q", tst.name, s.OnCall, o.Role, i, role)
+				}
+				members = append(members, o.Email[0])
+			}
+			got = append(got, string(members))
+		}
+		if diff := pretty.Compare(tst.want, got); diff != "" {
+			t.Errorf("%s: AssignRoles(_) differ -want +got, %s", tst.name, diff)
+		}
+	}
+}
+
+func TestMakeShiftsRoles(t *testing.T) {
+	cfg := &rotang.Configuration{
+		Config: rotang.Config{
+			Name: "Test Rota",
+			Shifts: rotang.ShiftConfig{
+				ShiftMembers: 2,
+				Length:       1,
+				Roles:        []rotang.Role{rotang.Primary, rotang.Secondary},
+				Shifts: []rotang.Shift{
+					{
+						Name:     "Day",
+						Duration: fullDay,
+					},
+				},
+			},
+		},
+	}
+	want := [][]rotang.ShiftMember{
+		{{Email: "A@A.com", ShiftName: "Day", Role: rotang.Primary}, {Email: "B@B.com", ShiftName: "Day", Role: rotang.Secondary}},
+		{{Email: "C@C.com", ShiftName: "Day", Role: rotang.Primary}, {Email: "A@A.com", ShiftName: "Day", Role: rotang.Secondary}},
+		{{Email: "B@B.com", ShiftName: "Day", Role: rotang.Primary}, {Email: "C@C.com", ShiftName: "Day", Role: rotang.Secondary}},
+	}
+	var got [][]rotang.ShiftMember
+	for _, s := range MakeShifts(cfg, midnight, [][]rotang.Member{stringToMembers("ABC", time.UTC)}, len(want)) {
+		got = append(got, s.OnCall)
+	}
+	if diff := pretty.Compare(want, got); diff != "" {
+		t.Errorf("MakeShifts(_) differ -want +got, %s", diff)
+	}
+}
@@ -0,0 +1,197 @@
+package algo
+
+import (
+	"sort"
+	"time"
+
+	rotang "github.com/miekg/rota"
+)
+
+// Shadow implements a rota Generator pairing trainees with experienced members.
+// The shifts are scheduled with another Generator, trainees listed in ShiftConfig.Trainees
+// are added to them as shadows until they did their ShadowShifts. From then on they are
+// scheduled as any other member.
+type Shadow struct {
+	base rotang.RotaGenerator
+}
+
+var _ rotang.RotaGenerator = &Shadow{}
+
+// NewShadow returns an instance of the Shadow generator scheduling with base.
+func NewShadow(base rotang.RotaGenerator) *Shadow {
+	return &Shadow{
+		base: base,
+	}
+}
+
+// Name returns the name of the Generator, Shadow followed by the name of the base Generator.
+func (s *Shadow) Name() string {
+	return "Shadow" + s.base.Name()
+}
+
+// Generate generates shifts with the base Generator and adds the trainees to them. Shadow shifts done are
+// counted from the previous shifts. A trainee shadows a different member every shift if possible, and
+// not while out of office or against their preferences. Shadows do not count towards ShiftMembers.
+func (s *Shadow) Generate(sc *rotang.Configuration, start time.Time, previous []rotang.ShiftEntry, members []rotang.Member, shiftsToSchedule int) ([]rotang.ShiftEntry, error) {
+	// A single seed for all the rounds below.
+	cfg := *sc
+	cfg.Config.Shifts.Seed = Seed(sc)
+
+	previous = append([]rotang.ShiftEntry{}, previous...)
+	sort.Sort(ByStart(previous))
+	tr := newTraining(&cfg, members, previous)
+
+	var res []rotang.ShiftEntry
+	for shiftsToSchedule > 0 {
+		// Generate up to the first trainee to finish, to have the trainee join the other members from then on.
+		n, pool := tr.round(shiftsToSchedule)
+		ss, err := s.base.Generate(&cfg, start, append(append([]rotang.ShiftEntry{}, previous...), res...), pool, n)
+		if err != nil {
+			return nil, err
+		}
+		for i := range ss {
+			tr.pair(&ss[i])
+		}
+		res = append(res, ss...)
+		shiftsToSchedule -= n
+		if len(ss) == 0 {
+			break
+		}
+	}
+	return res, nil
+}
+
+// training keeps track of the shadow shifts of the trainees.
+type training struct {
+	sc      *rotang.Configuration
+	members []rotang.Member
+	// trainees holds the ShadowShifts per trainee.
+	trainees   map[string]int
+	shiftName  map[string]string
+	durations  map[string]time.Duration
+	done       map[string]int
+	mentored   map[string]map[string]int
+	lastMentor map[string]string
+}
+
+func newTraining(sc *rotang.Configuration, members []rotang.Member, previous []rotang.ShiftEntry) *training {
+	tr := &training{
+		sc:         sc,
+		members:    members,
+		trainees:   make(map[string]int),
+		shiftName:  make(map[string]string),
+		durations:  make(map[string]time.Duration),
+		done:       make(map[string]int),
+		mentored:   make(map[string]map[string]int),
+		lastMentor: make(map[string]string),
+	}
+	for _, t := range sc.Config.Shifts.Trainees {
+		tr.trainees[t.Email] = t.ShadowShifts
+	}
+	for _, m := range sc.Members {
+		tr.shiftName[m.Email] = m.ShiftName
+	}
+	for _, s := range sc.Config.Shifts.Shifts {
+		tr.durations[s.Name] = s.Duration
+	}
+	for _, se := range previous {
+		for _, o := range se.OnCall {
+			if o.Mentor != "" {
+				tr.add(o.Email, o.Mentor)
+			}
+		}
+	}
+	return tr
+}
+
+// active is true if the member is a trainee still doing shadow shifts.
+func (tr *training) active(email string) bool {
+	target, ok := tr.trainees[email]
+	return ok && tr.done[email] < target
+}
+
+// add records a shadow shift of the trainee with mentor.
+func (tr *training) add(trainee, mentor string) {
+	if _, ok := tr.mentored[trainee]; !ok {
+		tr.mentored[trainee] = make(map[string]int)
+	}
+	tr.done[trainee]++
+	tr.mentored[trainee][mentor]++
+	tr.lastMentor[trainee] = mentor
+}
+
+// round returns the number of shifts to schedule before the first trainee could finish, and
+// the members to schedule them with.
+func (tr *training) round(shiftsToSchedule int) (int, []rotang.Member) {
+	n := shiftsToSchedule
+	var pool []rotang.Member
+	for _, m := range tr.members {
+		if !tr.active(m.Email) {
+			pool = append(pool, m)
+			continue
+		}
+		if left := tr.trainees[m.Email] - tr.done[m.Email]; left < n {
+			n = left
+		}
+	}
+	return n, pool
+}
+
+// pair adds the trainees of the shift as shadows of members oncall.
+func (tr *training) pair(se *rotang.ShiftEntry) {
+	var mentors []string
+	for _, o := range se.OnCall {
+		if o.Mentor == "" {
+			mentors = append(mentors, o.Email)
+		}
+	}
+	var trainees []rotang.Member
+	for _, m := range tr.members {
+		if tr.active(m.Email) && tr.shiftName[m.Email] == se.Name {
+			trainees = append(trainees, m)
+		}
+	}
+	// Trainees with the fewest shadow shifts go first.
+	sort.SliceStable(trainees, func(i, j int) bool {
+		return tr.done[trainees[i].Email] < tr.done[trainees[j].Email]
+	})
+	loc := tr.sc.Config.Shifts.Location()
+	for _, t := range trainees {
+		if len(mentors) == 0 {
+			break
+		}
+		if reason := Absent(&tr.sc.Config.Shifts, se.StartTime, tr.sc.Config.Shifts.Length, tr.durations[se.Name], t); reason != "" {
+			se.Trace = decide(tr.sc, se.Trace, t.Email, reason)
+			continue
+		}
+		if !PersonalPreference(se.StartTime.In(loc), tr.sc.Config.Shifts.Length, tr.durations[se.Name], t) {
+			se.Trace = decide(tr.sc, se.Trace, t.Email, rotang.SkippedPreference)
+			continue
+		}
+		// The mentor the trainee shadowed the least, preferably not the one from last time.
+		best := 0
+		for i := 1; i < len(mentors); i++ {
+			if tr.better(t.Email, mentors[i], mentors[best]) {
+				best = i
+			}
+		}
+		mentor := mentors[best]
+		mentors = append(mentors[:best], mentors[best+1:]...)
+		se.OnCall = append(se.OnCall, rotang.ShiftMember{
+			Email:     t.Email,
+			ShiftName: se.Name,
+			Role:      rotang.Shadow,
+			Mentor:    mentor,
+		})
+		se.Trace = decide(tr.sc, se.Trace, t.Email, rotang.Shadowing)
+		tr.add(t.Email, mentor)
+	}
+}
+
+// better is true if the trainee should shadow mentor a rather than mentor b.
+func (tr *training) better(trainee, a, b string) bool {
+	if ca, cb := tr.mentored[trainee][a], tr.mentored[trainee][b]; ca != cb {
+		return ca < cb
+	}
+	return tr.lastMentor[trainee] == b && tr.lastMentor[trainee] != a
+}
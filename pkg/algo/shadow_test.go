@@ -0,0 +1,116 @@
+package algo
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+	rotang "github.com/miekg/rota"
+)
+
+// shiftsToString returns the members of each shift, trainees shadowing a member as "T/M".
+func shiftsToString(shifts []rotang.ShiftEntry) []string {
+	var res []string
+	for _, s := range shifts {
+		var members []string
+		for _, o := range s.OnCall {
+			m := o.Email[:1]
+			if o.Mentor != "" {
+				m += "/" + o.Mentor[:1]
+			}
+			members = append(members, m)
+		}
+		res = append(res, strings.Join(members, " "))
+	}
+	return res
+}
+
+func TestShadow(t *testing.T) {
+	previous := []rotang.ShiftEntry{
+		{
+			Name:      "Day",
+			StartTime: midnight.Add(-2 * fullDay),
+			EndTime:   midnight.Add(-fullDay),
+			OnCall: []rotang.ShiftMember{
+				{Email: "A@A.com", ShiftName: "Day"},
+				{Email: "B@B.com", ShiftName: "Day"},
+				{Email: "T@T.com", ShiftName: "Day", Role: rotang.Shadow, Mentor: "A@A.com"},
+			},
+		}, {
+			Name:      "Day",
+			StartTime: midnight.Add(-fullDay),
+			EndTime:   midnight,
+			OnCall: []rotang.ShiftMember{
+				{Email: "C@C.com", ShiftName: "Day"},
+				{Email: "A@A.com", ShiftName: "Day"},
+				{Email: "T@T.com", ShiftName: "Day", Role: rotang.Shadow, Mentor: "C@C.com"},
+			},
+		},
+	}
+	ooo := stringToMembers("ABCT", time.UTC)
+	ooo[3].OOO = []rotang.OOO{{Start: midnight, Duration: fullDay}}
+
+	tests := []struct {
+		name     string
+		members  []rotang.Member
+		trainees []rotang.Trainee
+		previous []rotang.ShiftEntry
+		want     []string
+	}{{
+		name:     "No trainees",
+		members:  stringToMembers("ABCT", time.UTC),
+		previous: previous[:1],
+		want:     []string{"A B", "C T", "A B", "C T"},
+	}, {
+		name:     "Trainee shadows",
+		members:  stringToMembers("ABCT", time.UTC),
+		trainees: []rotang.Trainee{{Email: "T@T.com", ShadowShifts: 3}},
+		previous: previous[:1],
+		want:     []string{"A B T/B", "C A T/C", "B C", "A T"},
+	}, {
+		name:     "Shadow shifts done before",
+		members:  stringToMembers("ABCT", time.UTC),
+		trainees: []rotang.Trainee{{Email: "T@T.com", ShadowShifts: 3}},
+		previous: previous,
+		want:     []string{"B C T/B", "A B", "C T", "A B"},
+	}, {
+		name:     "Trainee out of office",
+		members:  ooo,
+		trainees: []rotang.Trainee{{Email: "T@T.com", ShadowShifts: 3}},
+		previous: previous,
+		want:     []string{"B C", "A B T/B", "C A", "T B"},
+	},
+	}
+
+	for _, tst := range tests {
+		cfg := &rotang.Configuration{
+			Config: rotang.Config{
+				Name: "Test Rota",
+				Shifts: rotang.ShiftConfig{
+					ShiftMembers: 2,
+					Length:       1,
+					Trainees:     tst.trainees,
+					Shifts: []rotang.Shift{
+						{
+							Name:     "Day",
+							Duration: fullDay,
+						},
+					},
+				},
+			},
+			Members: stringToShiftMembers("ABCT", "Day"),
+		}
+		shifts, err := NewShadow(NewFair()).Generate(cfg, midnight, tst.previous, tst.members, len(tst.want))
+		if err != nil {
+			t.Errorf("%s: Generate(_) failed: %v", tst.name, err)
+			continue
+		}
+		if diff := pretty.Compare(tst.want, shiftsToString(shifts)); diff != "" {
+			t.Errorf("%s: Generate(_) differ -want +got, %s", tst.name, diff)
+		}
+		if got := Understaffed(&cfg.Config.Shifts, shifts); len(got) != 0 {
+			t.Errorf("%s: Understaffed(_) = %v, want no understaffed shifts", tst.name, got)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package algo
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	rotang "github.com/miekg/rota"
+)
+
+// Simulation holds the outcome of scheduling with a single Generator.
+type Simulation struct {
+	Generator string
+	// Err is set if the Generator or one of the modifiers failed.
+	Err    error
+	Shifts []rotang.ShiftEntry
+	Stats  []MemberStats
+	// The spreads are the difference between the members with the most and least load,
+	// not counting members with the NoOncall preference.
+	ShiftSpread   int
+	HoursSpread   float64
+	WeekendSpread float64
+	Violations    []Violation
+}
+
+// Violation is a shift breaking a scheduling constraint.
+type Violation struct {
+	Shift     string
+	StartTime time.Time
+	// Email is empty for violations not caused by a member, eg. understaffed shifts.
+	Email  string
+	Reason string
+}
+
+func (v Violation) String() string {
+	if v.Email == "" {
+		return fmt.Sprintf("%s %s: %s", v.Shift, v.StartTime.Format(time.RFC3339), v.Reason)
+	}
+	return fmt.Sprintf("%s %s: %s %s", v.Shift, v.StartTime.Format(time.RFC3339), v.Email, v.Reason)
+}
+
+// Simulate schedules shiftsToSchedule shifts with every registered Generator, followed by the modifiers,
+// and reports the load of the members and the constraints broken for each. All generators get the same
+// members, previous shifts and seed. The result is sorted by Generator name.
+func (a *Generators) Simulate(sc *rotang.Configuration, start time.Time, previous []rotang.ShiftEntry, members []rotang.Member, shiftsToSchedule int, modifiers []string) ([]Simulation, error) {
+	var mods []rotang.ShiftModifier
+	for _, name := range modifiers {
+		m, err := a.FetchModifier(name)
+		if err != nil {
+			return nil, err
+		}
+		mods = append(mods, m)
+	}
+	cfg := *sc
+	cfg.Config.Shifts.Seed = Seed(sc)
+
+	names := a.List()
+	sort.Strings(names)
+	var res []Simulation
+	for _, name := range names {
+		sim := Simulation{Generator: name}
+		sim.Shifts, sim.Err = a.simulate(&cfg, name, start, previous, members, shiftsToSchedule, mods)
+		if sim.Err == nil {
+			sim.Err = sim.report(&cfg, previous, members)
+		}
+		res = append(res, sim)
+	}
+	return res, nil
+}
+
+func (a *Generators) simulate(sc *rotang.Configuration, name string, start time.Time, previous []rotang.ShiftEntry, members []rotang.Member, shiftsToSchedule int, mods []rotang.ShiftModifier) ([]rotang.ShiftEntry, error) {
+	g, err := a.Fetch(name)
+	if err != nil {
+		return nil, err
+	}
+	// Generators are free to reorder members and previous shifts.
+	ss, err := g.Generate(sc, start, append([]rotang.ShiftEntry{}, previous...), append([]rotang.Member{}, members...), shiftsToSchedule)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range mods {
+		if ss, err = m.Modify(&sc.Config.Shifts, ss); err != nil {
+			return nil, err
+		}
+	}
+	return ss, nil
+}
+
+// report fills in the statistics and violations of the simulated shifts.
+func (s *Simulation) report(sc *rotang.Configuration, previous []rotang.ShiftEntry, members []rotang.Member) error {
+	if len(s.Shifts) == 0 {
+		return nil
+	}
+	from, to := s.Shifts[0].StartTime, s.Shifts[0].EndTime
+	for _, se := range s.Shifts {
+		if se.StartTime.Before(from) {
+			from = se.StartTime
+		}
+		if se.EndTime.After(to) {
+			to = se.EndTime
+		}
+	}
+	var err error
+	if s.Stats, err = Stats(sc, members, s.Shifts, from, to); err != nil {
+		return err
+	}
+	// Members not wanting oncall at all would skew the spread.
+	noOncall := make(map[string]bool)
+	for _, m := range members {
+		noOncall[m.Email] = hasPreference(m, rotang.NoOncall)
+	}
+	var stats []MemberStats
+	for _, ms := range s.Stats {
+		if !noOncall[ms.Email] {
+			stats = append(stats, ms)
+		}
+	}
+	if len(stats) > 0 {
+		min, max := stats[0], stats[0]
+		for _, ms := range stats[1:] {
+			if ms.Shifts < min.Shifts {
+				min.Shifts = ms.Shifts
+			}
+			if ms.Shifts > max.Shifts {
+				max.Shifts = ms.Shifts
+			}
+			min.Hours, max.Hours = math.Min(min.Hours, ms.Hours), math.Max(max.Hours, ms.Hours)
+			min.WeekendHours, max.WeekendHours = math.Min(min.WeekendHours, ms.WeekendHours), math.Max(max.WeekendHours, ms.WeekendHours)
+		}
+		s.ShiftSpread = max.Shifts - min.Shifts
+		s.HoursSpread = max.Hours - min.Hours
+		s.WeekendSpread = max.WeekendHours - min.WeekendHours
+	}
+	s.Violations = Violations(sc, members, previous, s.Shifts)
+	return nil
+}
+
+// Violations lists the shifts with members out of office or unavailable, scheduled against their preferences,
+// without ShiftConfig.MinRest or beyond ShiftConfig.MaxConsecutive, shifts with fewer than ShiftConfig.ShiftMembers
+// members and shifts missing Shift.Coverage tags. The previous shifts count towards the rest and consecutive shifts.
+func Violations(sc *rotang.Configuration, members []rotang.Member, previous, shifts []rotang.ShiftEntry) []Violation {
+	loc := sc.Config.Shifts.Location()
+	byEmail := make(map[string]rotang.Member)
+	for _, m := range members {
+		byEmail[m.Email] = m
+	}
+	durations, shiftIdx := make(map[string]time.Duration), make(map[string]int)
+	for i, s := range sc.Config.Shifts.Shifts {
+		durations[s.Name], shiftIdx[s.Name] = s.Duration, i
+	}
+	rest := newRest(&sc.Config.Shifts, previous)
+	shifts = append([]rotang.ShiftEntry{}, shifts...)
+	sort.Sort(ByStart(shifts))
+	var res []Violation
+	for _, s := range shifts {
+		if idx, ok := shiftIdx[s.Name]; ok {
+			for _, o := range s.OnCall {
+				if r := rest.check(idx, s.StartTime, o.Email); r != "" {
+					res = append(res, Violation{Shift: s.Name, StartTime: s.StartTime, Email: o.Email, Reason: string(r)})
+				}
+			}
+		}
+		rest.add(s)
+		if n := Staffing(s); n < sc.Config.Shifts.ShiftMembers {
+			res = append(res, Violation{
+				Shift:     s.Name,
+				StartTime: s.StartTime,
+				Reason:    fmt.Sprintf("understaffed, %d of %d members", n, sc.Config.Shifts.ShiftMembers),
+			})
+		}
+		if missing := MissingCoverage(sc, members, s); len(missing) > 0 {
+			res = append(res, Violation{
+				Shift:     s.Name,
+				StartTime: s.StartTime,
+				Reason:    "missing tags: " + strings.Join(missing, ", "),
+			})
+		}
+		for _, o := range s.OnCall {
+			m, ok := byEmail[o.Email]
+			if !ok {
+				continue
+			}
+			var absent rotang.Reason
+			var pref bool
+			for _, iv := range oncallIntervals(s, durations, loc) {
+				if absent == "" {
+					absent = Absent(&sc.Config.Shifts, iv[0], 1, iv[1].Sub(iv[0]), m)
+				}
+				pref = pref || !PersonalPreference(iv[0].In(loc), 1, iv[1].Sub(iv[0]), m)
+			}
+			if absent != "" {
+				res = append(res, Violation{Shift: s.Name, StartTime: s.StartTime, Email: o.Email, Reason: string(absent)})
+			}
+			if pref {
+				res = append(res, Violation{Shift: s.Name, StartTime: s.StartTime, Email: o.Email, Reason: "against preferences"})
+			}
+		}
+	}
+	return res
+}
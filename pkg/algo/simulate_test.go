@@ -0,0 +1,247 @@
+package algo
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+	rotang "github.com/miekg/rota"
+)
+
+type failGen struct{}
+
+func (f *failGen) Generate(sc *rotang.Configuration, start time.Time, previous []rotang.ShiftEntry, members []rotang.Member, shiftsToSchedule int) ([]rotang.ShiftEntry, error) {
+	return nil, errors.New("failed")
+}
+
+func (f *failGen) Name() string {
+	return "Fail"
+}
+
+func TestSimulate(t *testing.T) {
+	// midnight is a Wednesday, the fourth shift is on a Saturday.
+	start := midnight
+
+	tests := []struct {
+		name      string
+		fail      bool
+		modifiers []string
+		members   []rotang.Member
+		// lastStart is the start of the last shift, if set.
+		lastStart time.Time
+		want      map[string]Simulation
+	}{{
+		name:      "Unknown modifier",
+		fail:      true,
+		modifiers: []string{"Unknown"},
+		members:   stringToMembers("AB", time.UTC),
+	}, {
+		name:      "Even spread",
+		members:   stringToMembers("AB", time.UTC),
+		lastStart: start.Add(3 * fullDay),
+		want: map[string]Simulation{
+			"Fail":    {Generator: "Fail"},
+			"Fair":    {Generator: "Fair", WeekendSpread: 24},
+			"Optimal": {Generator: "Optimal", WeekendSpread: 24},
+			"Random":  {Generator: "Random", WeekendSpread: 24},
+		},
+	}, {
+		name:      "Weekends skipped",
+		modifiers: []string{"WeekendSkip"},
+		members:   stringToMembers("AB", time.UTC),
+		// The Saturday shift is moved to Sunday.
+		lastStart: start.Add(4 * fullDay),
+		want: map[string]Simulation{
+			"Fail":    {Generator: "Fail"},
+			"Fair":    {Generator: "Fair", WeekendSpread: 24},
+			"Optimal": {Generator: "Optimal", WeekendSpread: 24},
+			"Random":  {Generator: "Random", WeekendSpread: 24},
+		},
+	}, {
+		name: "Constraints",
+		members: []rotang.Member{
+			{
+				Email:       "A@A.com",
+				Preferences: []rotang.Preference{rotang.NoWeekends},
+			}, {
+				Email: "B@B.com",
+				OOO: []rotang.OOO{
+					{
+						Start:    start,
+						Duration: 4 * fullDay,
+					},
+				},
+			},
+		},
+		want: map[string]Simulation{
+			"Fail": {Generator: "Fail"},
+			"Fair": {
+				Generator:     "Fair",
+				ShiftSpread:   4,
+				HoursSpread:   96,
+				WeekendSpread: 24,
+				Violations: []Violation{
+					{Shift: "Day", StartTime: start.Add(3 * fullDay), Email: "A@A.com", Reason: "against preferences"},
+				},
+			},
+			"Optimal": {
+				Generator:   "Optimal",
+				ShiftSpread: 3,
+				HoursSpread: 72,
+				Violations: []Violation{
+					{Shift: "Day", StartTime: start.Add(3 * fullDay), Reason: "understaffed, 0 of 1 members"},
+				},
+			},
+			"Random": {
+				Generator:     "Random",
+				ShiftSpread:   4,
+				HoursSpread:   96,
+				WeekendSpread: 24,
+				Violations: []Violation{
+					{Shift: "Day", StartTime: start.Add(3 * fullDay), Email: "A@A.com", Reason: "against preferences"},
+				},
+			},
+		},
+	},
+	}
+
+	as := New()
+	as.Register(NewFair())
+	as.Register(NewRandomGen())
+	as.Register(NewOptimal())
+	as.Register(&failGen{})
+	as.RegisterModifier(NewWeekendSkip())
+
+	for _, tst := range tests {
+		cfg := &rotang.Configuration{
+			Config: rotang.Config{
+				Name: "Test Rota",
+				Shifts: rotang.ShiftConfig{
+					Length:       1,
+					ShiftMembers: 1,
+					Shifts: []rotang.Shift{
+						{
+							Name:     "Day",
+							Duration: fullDay,
+						},
+					},
+				},
+			},
+		}
+		for _, m := range tst.members {
+			cfg.Members = append(cfg.Members, rotang.ShiftMember{Email: m.Email, ShiftName: "Day"})
+		}
+		res, err := as.Simulate(cfg, start, nil, tst.members, 4, tst.modifiers)
+		if got, want := (err != nil), tst.fail; got != want {
+			t.Errorf("%s: Simulate(_) = %t want: %t, err: %v", tst.name, got, want, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if got, want := len(res), len(tst.want); got != want {
+			t.Errorf("%s: Simulate(_) returned %d simulations, want: %d", tst.name, got, want)
+			continue
+		}
+		var seed int64
+		for i, sim := range res {
+			if i > 0 && res[i-1].Generator > sim.Generator {
+				t.Errorf("%s: Simulate(_) not sorted, %q before %q", tst.name, res[i-1].Generator, sim.Generator)
+			}
+			if got, want := (sim.Err != nil), sim.Generator == "Fail"; got != want {
+				t.Errorf("%s: %s Err = %v, want error: %t", tst.name, sim.Generator, sim.Err, want)
+				continue
+			}
+			if sim.Err != nil {
+				continue
+			}
+			if got, want := len(sim.Shifts), 4; got != want {
+				t.Errorf("%s: %s scheduled %d shifts, want: %d", tst.name, sim.Generator, got, want)
+				continue
+			}
+			if got, want := sim.Shifts[3].StartTime, tst.lastStart; !want.IsZero() && !got.Equal(want) {
+				t.Errorf("%s: %s last shift starts at %v, want: %v", tst.name, sim.Generator, got, want)
+			}
+			for _, s := range sim.Shifts {
+				if seed == 0 {
+					seed = s.Seed
+				}
+				if s.Seed != seed {
+					t.Errorf("%s: %s used seed %d, want: %d", tst.name, sim.Generator, s.Seed, seed)
+				}
+			}
+			want := tst.want[sim.Generator]
+			if diff := pretty.Compare(want, Simulation{
+				Generator:     sim.Generator,
+				ShiftSpread:   sim.ShiftSpread,
+				HoursSpread:   sim.HoursSpread,
+				WeekendSpread: sim.WeekendSpread,
+				Violations:    sim.Violations,
+			}); diff != "" {
+				t.Errorf("%s: %s Simulate(_) differ -want +got, %s", tst.name, sim.Generator, diff)
+			}
+		}
+	}
+}
+
+func TestViolations(t *testing.T) {
+	tests := []struct {
+		name           string
+		minRest        time.Duration
+		maxConsecutive int
+		previous       []rotang.ShiftEntry
+		shifts         []rotang.ShiftEntry
+		want           []Violation
+	}{{
+		name:   "No constraints",
+		shifts: stringToShifts("BAAA", "Day"),
+	}, {
+		name:    "Minimum rest",
+		minRest: 12 * time.Hour,
+		shifts:  stringToShifts("BAAA", "Day"),
+		want: []Violation{
+			{Shift: "Day", StartTime: midnight.Add(2 * fullDay), Email: "A@A.com", Reason: string(rotang.SkippedRest)},
+			{Shift: "Day", StartTime: midnight.Add(3 * fullDay), Email: "A@A.com", Reason: string(rotang.SkippedRest)},
+		},
+	}, {
+		name:           "Maximum consecutive",
+		maxConsecutive: 2,
+		shifts:         stringToShifts("BAAA", "Day"),
+		want: []Violation{
+			{Shift: "Day", StartTime: midnight.Add(3 * fullDay), Email: "A@A.com", Reason: string(rotang.SkippedConsecutive)},
+		},
+	}, {
+		name:           "Maximum consecutive with previous shifts",
+		maxConsecutive: 2,
+		previous:       stringToShifts("AA", "Day"),
+		shifts:         stringToShifts("BAAA", "Day")[:2],
+		want: []Violation{
+			{Shift: "Day", StartTime: midnight.Add(3 * fullDay), Email: "A@A.com", Reason: string(rotang.SkippedConsecutive)},
+		},
+	},
+	}
+
+	for _, tst := range tests {
+		cfg := &rotang.Configuration{
+			Config: rotang.Config{
+				Name: "Test Rota",
+				Shifts: rotang.ShiftConfig{
+					ShiftMembers:   1,
+					MinRest:        tst.minRest,
+					MaxConsecutive: tst.maxConsecutive,
+					Shifts: []rotang.Shift{
+						{
+							Name:     "Day",
+							Duration: fullDay,
+						},
+					},
+				},
+			},
+		}
+		got := Violations(cfg, stringToMembers("AB", time.UTC), tst.previous, tst.shifts)
+		if diff := pretty.Compare(tst.want, got); diff != "" {
+			t.Errorf("%s: Violations(_) differ -want +got, %s", tst.name, diff)
+		}
+	}
+}
@@ -25,9 +25,10 @@ func (s *SplitShift) Description() string {
 
 // Modify splits the shifts into one day shifts.
 func (s *SplitShift) Modify(sc *rotang.ShiftConfig, shifts []rotang.ShiftEntry) ([]rotang.ShiftEntry, error) {
+	loc := sc.Location()
 	for i := 0; i < len(shifts); i++ {
-		start, end := shifts[i].StartTime.In(&sc.TZ), shifts[i].EndTime.In(&sc.TZ)
-		for st := start.Add(fullDay); st.Before(end); st = st.Add(fullDay) {
+		start, end := shifts[i].StartTime.In(loc), shifts[i].EndTime.In(loc)
+		for st := start.AddDate(0, 0, 1); st.Before(end); st = st.AddDate(0, 0, 1) {
 			newShift := shifts[i]
 			shifts[i].EndTime = st
 			newShift.StartTime = st
@@ -12,6 +12,10 @@ func TestSplitShiftModify(t *testing.T) {
 
 	// A MTV Time monday.
 	baseTime := time.Date(2006, 7, 31, 0, 0, 0, 0, mtvTime)
+	amsTime, err := time.LoadLocation("Europe/Amsterdam")
+	if err != nil {
+		t.Fatalf("time.LoadLocation() failed: %v", err)
+	}
 
 	tests := []struct {
 		name   string
@@ -139,6 +143,27 @@ func TestSplitShiftModify(t *testing.T) {
 				EndTime:   baseTime.Add(3 * fullDay),
 			},
 		},
+	}, {
+		// DST starts 2018-03-25 02:00 in Amsterdam.
+		name: "Split over DST change",
+		cfg: &rotang.ShiftConfig{
+			TZ: *amsTime,
+		},
+		shifts: []rotang.ShiftEntry{
+			{
+				StartTime: time.Date(2018, 3, 24, 9, 0, 0, 0, amsTime),
+				EndTime:   time.Date(2018, 3, 26, 9, 0, 0, 0, amsTime),
+			},
+		},
+		want: []rotang.ShiftEntry{
+			{
+				StartTime: time.Date(2018, 3, 24, 9, 0, 0, 0, amsTime),
+				EndTime:   time.Date(2018, 3, 25, 9, 0, 0, 0, amsTime),
+			}, {
+				StartTime: time.Date(2018, 3, 25, 9, 0, 0, 0, amsTime),
+				EndTime:   time.Date(2018, 3, 26, 9, 0, 0, 0, amsTime),
+			},
+		},
 	},
 	}
 
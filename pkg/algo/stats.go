@@ -0,0 +1,149 @@
+package algo
+
+import (
+	"sort"
+	"time"
+
+	rotang "github.com/miekg/rota"
+)
+
+// Night hours are between NightStart and NightEnd in the member's own time zone.
+const (
+	NightStart = 22
+	NightEnd   = 7
+)
+
+// MemberStats holds the oncall load of a rotation member over a time window.
+type MemberStats struct {
+	Email string
+	// Shifts is the number of shifts overlapping the window.
+	Shifts int
+	// Hours oncall, weekend and holiday hours are calculated in the rotation
+	// time zone and night hours in the member's time zone.
+	Hours        float64
+	WeekendHours float64
+	HolidayHours float64
+	NightHours   float64
+	// LongestGap is the longest time between two consecutive shifts.
+	LongestGap time.Duration
+}
+
+// Stats calculates the oncall load of the members of the rotation for shifts in the window [from, to).
+// Holiday hours are only calculated when ShiftConfig.Holidays is set.
+func Stats(sc *rotang.Configuration, members []rotang.Member, shifts []rotang.ShiftEntry, from, to time.Time) ([]MemberStats, error) {
+	loc := sc.Config.Shifts.Location()
+	hs := holidays{}
+	if sc.Config.Shifts.Holidays != "" {
+		var err error
+		if hs, err = loadHolidays(sc.Config.Shifts.Holidays, from.In(loc).Year(), to.In(loc).Year(), loc); err != nil {
+			return nil, err
+		}
+	}
+	durations := make(map[string]time.Duration)
+	for _, s := range sc.Config.Shifts.Shifts {
+		durations[s.Name] = s.Duration
+	}
+
+	shifts = append([]rotang.ShiftEntry{}, shifts...)
+	sort.Sort(ByStart(shifts))
+
+	byEmail := make(map[string]*MemberStats)
+	mloc := make(map[string]*time.Location)
+	for _, m := range members {
+		byEmail[m.Email] = &MemberStats{Email: m.Email}
+		mloc[m.Email] = time.UTC
+		if m.TZ.String() != "" {
+			tz := m.TZ
+			mloc[m.Email] = &tz
+		}
+	}
+	lastEnd := make(map[string]time.Time)
+	for _, s := range shifts {
+		if !s.StartTime.Before(to) || !s.EndTime.After(from) {
+			continue
+		}
+		for _, o := range s.OnCall {
+			ms, ok := byEmail[o.Email]
+			if !ok {
+				continue
+			}
+			ms.Shifts++
+			if end, ok := lastEnd[o.Email]; ok && s.StartTime.Sub(end) > ms.LongestGap {
+				ms.LongestGap = s.StartTime.Sub(end)
+			}
+			lastEnd[o.Email] = s.EndTime
+			for _, iv := range oncallIntervals(s, durations, loc) {
+				if iv[0].Before(from) {
+					iv[0] = from
+				}
+				if iv[1].After(to) {
+					iv[1] = to
+				}
+				addHours(ms, iv[0], iv[1], loc, mloc[o.Email], hs)
+			}
+		}
+	}
+	var res []MemberStats
+	for _, ms := range byEmail {
+		res = append(res, *ms)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Email < res[j].Email
+	})
+	return res, nil
+}
+
+// oncallIntervals returns the oncall periods of a shift. A shift lasting
+// multiple days is only oncall for the shift duration each day.
+func oncallIntervals(s rotang.ShiftEntry, durations map[string]time.Duration, loc *time.Location) [][2]time.Time {
+	d, ok := durations[s.Name]
+	if !ok || d <= 0 || d >= fullDay {
+		return [][2]time.Time{{s.StartTime, s.EndTime}}
+	}
+	var res [][2]time.Time
+	for st := s.StartTime.In(loc); st.Before(s.EndTime); st = st.AddDate(0, 0, 1) {
+		end := st.Add(d)
+		if end.After(s.EndTime) {
+			end = s.EndTime
+		}
+		res = append(res, [2]time.Time{st, end})
+	}
+	return res
+}
+
+// addHours adds the hours of the [start, end) period to ms. The period is cut up
+// at day boundaries in the rotation time zone and at night boundaries in the member's.
+func addHours(ms *MemberStats, start, end time.Time, loc, mloc *time.Location, hs holidays) {
+	for t := start; t.Before(end); {
+		next := nextBoundary(t, loc, mloc)
+		if next.After(end) {
+			next = end
+		}
+		h := next.Sub(t).Hours()
+		ms.Hours += h
+		if wd := t.In(loc).Weekday(); wd == time.Saturday || wd == time.Sunday {
+			ms.WeekendHours += h
+		}
+		if _, ok := hs[dateOf(t.In(loc))]; ok {
+			ms.HolidayHours += h
+		}
+		if hour := t.In(mloc).Hour(); hour >= NightStart || hour < NightEnd {
+			ms.NightHours += h
+		}
+		t = next
+	}
+}
+
+// nextBoundary returns the first midnight in loc, or midnight, NightStart or NightEnd in mloc after t.
+func nextBoundary(t time.Time, loc, mloc *time.Location) time.Time {
+	res := nextMidnight(t.In(loc))
+	y, m, d := t.In(mloc).Date()
+	for _, day := range []int{d, d + 1} {
+		for _, hour := range []int{0, NightEnd, NightStart} {
+			if b := time.Date(y, m, day, hour, 0, 0, 0, mloc); b.After(t) && b.Before(res) {
+				res = b
+			}
+		}
+	}
+	return res
+}
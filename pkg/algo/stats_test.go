@@ -0,0 +1,198 @@
+package algo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+	rotang "github.com/miekg/rota"
+)
+
+func TestStats(t *testing.T) {
+	tokyoTime, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatalf("time.LoadLocation() failed: %v", err)
+	}
+	dir, err := ioutil.TempDir("", "stats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "holidays.csv"), []byte("2006-08-07,Holiday\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	HolidayDir = dir
+	defer func() { HolidayDir = "" }()
+
+	// midnight is a Wednesday.
+	saturday := midnight.Add(3 * fullDay)
+	monday := midnight.Add(5 * fullDay)
+	wednesday := midnight.Add(7 * fullDay)
+
+	members := []rotang.Member{
+		{
+			Email: "a@oncall.com",
+			TZ:    *time.UTC,
+		}, {
+			Email: "b@oncall.com",
+			TZ:    *tokyoTime,
+		}, {
+			Email: "c@oncall.com",
+		},
+	}
+	dayShift := func(start time.Time, email string) rotang.ShiftEntry {
+		return rotang.ShiftEntry{
+			Name:      "Day",
+			StartTime: start,
+			EndTime:   start.Add(fullDay),
+			OnCall: []rotang.ShiftMember{
+				{
+					Email:     email,
+					ShiftName: "Day",
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		fail     bool
+		holidays string
+		duration time.Duration
+		length   int
+		shifts   []rotang.ShiftEntry
+		from, to time.Time
+		want     []MemberStats
+	}{{
+		name:     "Full days",
+		holidays: "holidays.csv",
+		duration: fullDay,
+		length:   1,
+		shifts: []rotang.ShiftEntry{
+			dayShift(saturday, "a@oncall.com"),
+			dayShift(monday, "b@oncall.com"),
+			dayShift(wednesday, "a@oncall.com"),
+			dayShift(wednesday.Add(fullDay), "someone@else.com"),
+		},
+		from: midnight,
+		to:   wednesday.Add(7 * fullDay),
+		want: []MemberStats{
+			{
+				Email:        "a@oncall.com",
+				Shifts:       2,
+				Hours:        48,
+				WeekendHours: 24,
+				// 00:00 - 07:00 and 22:00 - 24:00 for both days.
+				NightHours: 18,
+				LongestGap: 3 * fullDay,
+			}, {
+				Email:        "b@oncall.com",
+				Shifts:       1,
+				Hours:        24,
+				HolidayHours: 24,
+				// 22:00 - 07:00 in Tokyo.
+				NightHours: 9,
+			}, {
+				Email: "c@oncall.com",
+			},
+		},
+	}, {
+		name:     "Window",
+		duration: fullDay,
+		length:   1,
+		shifts: []rotang.ShiftEntry{
+			dayShift(saturday, "a@oncall.com"),
+			dayShift(monday, "b@oncall.com"),
+			dayShift(wednesday, "a@oncall.com"),
+		},
+		from: saturday.Add(12 * time.Hour),
+		to:   wednesday,
+		want: []MemberStats{
+			{
+				Email:        "a@oncall.com",
+				Shifts:       1,
+				Hours:        12,
+				WeekendHours: 12,
+				NightHours:   2,
+			}, {
+				Email:      "b@oncall.com",
+				Shifts:     1,
+				Hours:      24,
+				NightHours: 9,
+			}, {
+				Email: "c@oncall.com",
+			},
+		},
+	}, {
+		name:     "Office hours",
+		duration: 8 * time.Hour,
+		length:   5,
+		shifts: []rotang.ShiftEntry{
+			{
+				Name:      "Day",
+				StartTime: monday.Add(9 * time.Hour),
+				EndTime:   monday.Add(4*fullDay + 17*time.Hour),
+				OnCall: []rotang.ShiftMember{
+					{
+						Email:     "a@oncall.com",
+						ShiftName: "Day",
+					},
+				},
+			},
+		},
+		from: midnight,
+		to:   wednesday.Add(7 * fullDay),
+		want: []MemberStats{
+			{
+				Email:  "a@oncall.com",
+				Shifts: 1,
+				Hours:  40,
+			}, {
+				Email: "b@oncall.com",
+			}, {
+				Email: "c@oncall.com",
+			},
+		},
+	}, {
+		name:     "Missing holiday file",
+		fail:     true,
+		holidays: "missing.csv",
+		duration: fullDay,
+		length:   1,
+		from:     midnight,
+		to:       wednesday,
+	},
+	}
+
+	for _, tst := range tests {
+		cfg := &rotang.Configuration{
+			Config: rotang.Config{
+				Name: "Test Rota",
+				Shifts: rotang.ShiftConfig{
+					Length:   tst.length,
+					Holidays: tst.holidays,
+					Shifts: []rotang.Shift{
+						{
+							Name:     "Day",
+							Duration: tst.duration,
+						},
+					},
+				},
+			},
+		}
+		got, err := Stats(cfg, members, tst.shifts, tst.from, tst.to)
+		if got, want := (err != nil), tst.fail; got != want {
+			t.Errorf("%s: Stats(_) = %t want: %t, err: %v", tst.name, got, want, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if diff := pretty.Compare(tst.want, got); diff != "" {
+			t.Errorf("%s: Stats(_) differ -want +got, %s", tst.name, diff)
+		}
+	}
+}
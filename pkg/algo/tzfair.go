@@ -3,6 +3,7 @@ package algo
 import (
 	"fmt"
 	"sort"
+	"strings"
 	"time"
 
 	rotang "github.com/miekg/rota"
@@ -29,7 +30,7 @@ func (t *TZFair) Generate(sc *rotang.Configuration, start time.Time, previous []
 	var tzMembers [][]rotang.Member
 	lastSeen := ""
 	for _, m := range members {
-		if lastSeen != m.TZ.String() {
+		if len(tzMembers) == 0 || lastSeen != m.TZ.String() {
 			tzMembers = append(tzMembers, []rotang.Member{m})
 			lastSeen = m.TZ.String()
 			continue
@@ -40,18 +41,38 @@ func (t *TZFair) Generate(sc *rotang.Configuration, start time.Time, previous []
 	// Since a pointer is used for Generate implying Generate won't change it up; better copy it.
 	scCopy := *sc
 	scCopy.Config.Shifts.ShiftMembers = 1
+	// A single member per time zone can't cover the shift, coverage is checked after merging.
+	scCopy.Config.Shifts.Shifts = make([]rotang.Shift, len(sc.Config.Shifts.Shifts))
+	for i, s := range sc.Config.Shifts.Shifts {
+		s.Coverage = nil
+		scCopy.Config.Shifts.Shifts[i] = s
+	}
 
 	fairGen := NewFair()
 
+	// Each TZ gets its own seed derived from the rotation seed, the
+	// shifts returned are those of the first TZ and record the rotation seed.
+	seed := Seed(sc)
 	var perTZShifts [][]rotang.ShiftEntry
-	for _, ms := range tzMembers {
+	for i, ms := range tzMembers {
+		scCopy.Config.Shifts.Seed = seed + int64(i)
 		shifts, err := fairGen.Generate(&scCopy, start, previous, ms, shiftsToSchedule)
 		if err != nil {
 			return nil, err
 		}
 		perTZShifts = append(perTZShifts, shifts)
 	}
-	return tzSlice(perTZShifts)
+	shifts, err := tzSlice(perTZShifts)
+	if err != nil {
+		return nil, err
+	}
+	for i := range shifts {
+		// The time zones left empty are flagged for the merged shift instead.
+		shifts[i].Comment = removeComment(shifts[i].Comment, UnderstaffedComment(0, 1))
+		shifts[i] = annotateUnderstaffed(&sc.Config.Shifts, shifts[i])
+		shifts[i] = annotateCoverage(shifts[i], MissingCoverage(sc, members, shifts[i]))
+	}
+	return AssignRoles(&sc.Config.Shifts, previous, shifts), nil
 }
 
 // Name returns the name of the Generator.
@@ -60,7 +81,7 @@ func (t *TZFair) Name() string {
 }
 
 // tzSlice turn the slices of rotang.ShiftEntry into a slice of ShiftEntry with
-// one member per TZ slice.
+// one member per TZ slice. Time zones without a member for a shift are left out.
 //
 // tzShifts:
 //
@@ -87,8 +108,20 @@ func tzSlice(tzShifts [][]rotang.ShiftEntry) ([]rotang.ShiftEntry, error) {
 	}
 	for si := range tzShifts[0] {
 		for i := 1; i < len(tzShifts); i++ {
-			tzShifts[0][si].OnCall = append(tzShifts[0][si].OnCall, tzShifts[i][si].OnCall[0])
+			tzShifts[0][si].OnCall = append(tzShifts[0][si].OnCall, tzShifts[i][si].OnCall...)
+			tzShifts[0][si].Trace = append(tzShifts[0][si].Trace, tzShifts[i][si].Trace...)
 		}
 	}
 	return tzShifts[0], nil
 }
+
+// removeComment removes c from the "; " separated comments.
+func removeComment(comments, c string) string {
+	var res []string
+	for _, cm := range strings.Split(comments, "; ") {
+		if cm != c && cm != "" {
+			res = append(res, cm)
+		}
+	}
+	return strings.Join(res, "; ")
+}
@@ -397,6 +397,91 @@ func TestGenerateTZFair(t *testing.T) {
 					EndTime:   midnight.Add(57*fullDay + 5*fullDay + time.Hour*8 + 2*fullDay),
 				},
 			},
+		}, {
+			name: "Empty TZ shift",
+			cfg: &rotang.Configuration{
+				Config: rotang.Config{
+					Name: "Test Rota",
+					Shifts: rotang.ShiftConfig{
+						Length:         1,
+						MaxConsecutive: 1,
+						Shifts: []rotang.Shift{
+							{
+								Name:     "Test Shift",
+								Duration: fullDay,
+							},
+						},
+						ShiftMembers: 2,
+						Generator:    "TZFair",
+						Seed:         7357,
+					},
+				},
+			},
+			start:     midnight,
+			numShifts: 4,
+			members: []memberTZ{{
+				members: "A",
+				TZ:      pacificLocation,
+			}, {
+				members: "BC",
+				TZ:      time.UTC,
+			},
+			},
+			want: []rotang.ShiftEntry{
+				{
+					Name: "Test Shift",
+					OnCall: []rotang.ShiftMember{
+						{
+							Email:     "A@A.com",
+							ShiftName: "Test Shift",
+						}, {
+							Email:     "B@B.com",
+							ShiftName: "Test Shift",
+						},
+					},
+					StartTime: midnight,
+					EndTime:   midnight.Add(fullDay),
+					Seed:      7357,
+				}, {
+					Name: "Test Shift",
+					OnCall: []rotang.ShiftMember{
+						{
+							Email:     "C@C.com",
+							ShiftName: "Test Shift",
+						},
+					},
+					StartTime: midnight.Add(fullDay),
+					EndTime:   midnight.Add(2 * fullDay),
+					Comment:   "only 1 of 2 members could be scheduled",
+					Seed:      7357,
+				}, {
+					Name: "Test Shift",
+					OnCall: []rotang.ShiftMember{
+						{
+							Email:     "A@A.com",
+							ShiftName: "Test Shift",
+						}, {
+							Email:     "B@B.com",
+							ShiftName: "Test Shift",
+						},
+					},
+					StartTime: midnight.Add(2 * fullDay),
+					EndTime:   midnight.Add(3 * fullDay),
+					Seed:      7357,
+				}, {
+					Name: "Test Shift",
+					OnCall: []rotang.ShiftMember{
+						{
+							Email:     "C@C.com",
+							ShiftName: "Test Shift",
+						},
+					},
+					StartTime: midnight.Add(3 * fullDay),
+					EndTime:   midnight.Add(4 * fullDay),
+					Comment:   "only 1 of 2 members could be scheduled",
+					Seed:      7357,
+				},
+			},
 		},
 	}
 
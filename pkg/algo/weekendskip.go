@@ -62,13 +62,14 @@ func (w *WeekendSkip) Description() string {
 //		6     Thu - Fri
 //		7			Mon - Tue
 func (w *WeekendSkip) Modify(sc *rotang.ShiftConfig, shifts []rotang.ShiftEntry) ([]rotang.ShiftEntry, error) {
+	loc := sc.Location()
 	for i := 0; i < len(shifts); i++ {
-		start, end := shifts[i].StartTime.In(&sc.TZ), shifts[i].EndTime.In(&sc.TZ)
-		for st := start; st.Before(end); st = st.Add(fullDay) {
+		start, end := shifts[i].StartTime.In(loc), shifts[i].EndTime.In(loc)
+		for st := start; st.Before(end); st = st.AddDate(0, 0, 1) {
 			// When splitting or moving a shift, all following shifts
-			// need to be adjusted too. Skip identifies how much to add
+			// need to be adjusted too. Skip identifies how many days to add
 			// to each shift.
-			skip := fullDay
+			skip := 1
 			switch st.Weekday() {
 			case time.Saturday:
 				// If the shift starts at a Saturday -> move it no split needed.
@@ -78,10 +79,10 @@ func (w *WeekendSkip) Modify(sc *rotang.ShiftConfig, shifts []rotang.ShiftEntry)
 				// Split the shift into two.
 				shifts[i].EndTime = st
 				newShift := shifts[i]
-				newShift.StartTime = shifts[i].StartTime.Add(skip)
-				newShift.EndTime = shifts[i].EndTime.Add(skip)
+				newShift.StartTime = addDays(shifts[i].StartTime, skip, loc)
+				newShift.EndTime = addDays(shifts[i].EndTime, skip, loc)
 
-				skip = 2 * fullDay
+				skip = 2
 				i++
 				shifts = append(shifts[:i], append([]rotang.ShiftEntry{newShift}, shifts[i:]...)...)
 			case time.Sunday:
@@ -90,9 +91,14 @@ func (w *WeekendSkip) Modify(sc *rotang.ShiftConfig, shifts []rotang.ShiftEntry)
 				continue
 			}
 			for j := i; j < len(shifts); j++ {
-				shifts[j].StartTime, shifts[j].EndTime = shifts[j].StartTime.Add(skip), shifts[j].EndTime.Add(skip)
+				shifts[j].StartTime, shifts[j].EndTime = addDays(shifts[j].StartTime, skip, loc), addDays(shifts[j].EndTime, skip, loc)
 			}
 		}
 	}
 	return shifts, nil
 }
+
+// addDays adds days to t on the wall clock of loc.
+func addDays(t time.Time, days int, loc *time.Location) time.Time {
+	return t.In(loc).AddDate(0, 0, days).In(t.Location())
+}
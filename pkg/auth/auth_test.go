@@ -0,0 +1,297 @@
+package auth
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+	rotang "github.com/miekg/rota"
+	"go.chromium.org/luci/server/router"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func hash(t *testing.T, password string) string {
+	t.Helper()
+	b, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func testContext(req *http.Request) *router.Context {
+	return &router.Context{
+		Context: context.Background(),
+		Writer:  httptest.NewRecorder(),
+		Request: req,
+	}
+}
+
+func testUsers(t *testing.T) *Users {
+	t.Helper()
+	u, err := NewUsers([]UserEntry{
+		{
+			Email:    "admin@example.com",
+			Name:     "Admin",
+			Password: hash(t, "secret"),
+		}, {
+			Email:    "oncaller@example.com",
+			Name:     "Oncaller",
+			Password: hash(t, "hunter2"),
+			Groups:   []string{"rota-access", "rota-admins"},
+		}, {
+			Email:  "nopass@example.com",
+			Groups: []string{"rota-access"},
+		},
+	}, []string{"Admin@example.com"}, "rota-admins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func TestLoadUsers(t *testing.T) {
+	tests := []struct {
+		name  string
+		fail  bool
+		users string
+		want  int
+	}{{
+		name: "Success",
+		users: `{
+			"Users": [{"Email": "a@example.com", "Groups": ["g"]}, {"Email": "b@example.com"}],
+			"Admins": ["a@example.com"]
+		}`,
+		want: 2,
+	}, {
+		name:  "Duplicate user",
+		fail:  true,
+		users: `{"Users": [{"Email": "a@example.com"}, {"Email": "A@example.com"}]}`,
+	}, {
+		name:  "Missing email",
+		fail:  true,
+		users: `{"Users": [{"Name": "a"}]}`,
+	}, {
+		name:  "Unknown field",
+		fail:  true,
+		users: `{"Admin": ["a@example.com"]}`,
+	},
+	}
+
+	dir, err := ioutil.TempDir("", "auth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, tst := range tests {
+		path := filepath.Join(dir, "users.json")
+		if err := ioutil.WriteFile(path, []byte(tst.users), 0600); err != nil {
+			t.Fatal(err)
+		}
+		u, err := LoadUsers(path)
+		if got, want := (err != nil), tst.fail; got != want {
+			t.Errorf("%s: LoadUsers(_) = %t want: %t, err: %v", tst.name, got, want, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if got, want := len(u.users), tst.want; got != want {
+			t.Errorf("%s: LoadUsers(_) = %d users want: %d", tst.name, got, want)
+		}
+	}
+}
+
+func TestUsersAuthorizer(t *testing.T) {
+	tests := []struct {
+		name   string
+		usr    *rotang.User
+		group  string
+		admin  bool
+		member bool
+	}{{
+		name:  "Listed admin",
+		usr:   &rotang.User{Email: "admin@example.com"},
+		group: "rota-access",
+		admin: true,
+	}, {
+		name:   "Admin group",
+		usr:    &rotang.User{Email: "oncaller@example.com"},
+		group:  "rota-access",
+		admin:  true,
+		member: true,
+	}, {
+		name:   "Group from authenticator",
+		usr:    &rotang.User{Email: "proxied@example.com", Groups: []string{"rota-access"}},
+		group:  "rota-access",
+		member: true,
+	}, {
+		name:  "Unknown user",
+		usr:   &rotang.User{Email: "unknown@example.com"},
+		group: "rota-access",
+	}, {
+		name:  "No user",
+		group: "rota-access",
+	},
+	}
+
+	u := testUsers(t)
+	ctx := testContext(httptest.NewRequest("GET", "/", nil))
+	for _, tst := range tests {
+		admin, err := u.IsAdmin(ctx, tst.usr)
+		if err != nil {
+			t.Fatalf("%s: IsAdmin(ctx, _) failed: %v", tst.name, err)
+		}
+		if got, want := admin, tst.admin; got != want {
+			t.Errorf("%s: IsAdmin(ctx, _) = %t want: %t", tst.name, got, want)
+		}
+		member, err := u.IsMember(ctx, tst.usr, tst.group)
+		if err != nil {
+			t.Fatalf("%s: IsMember(ctx, _, %q) failed: %v", tst.name, tst.group, err)
+		}
+		if got, want := member, tst.member; got != want {
+			t.Errorf("%s: IsMember(ctx, _, %q) = %t want: %t", tst.name, tst.group, got, want)
+		}
+	}
+}
+
+func TestBasic(t *testing.T) {
+	tests := []struct {
+		name     string
+		user     string
+		password string
+		noAuth   bool
+		want     *rotang.User
+	}{{
+		name:     "Success",
+		user:     "oncaller@example.com",
+		password: "hunter2",
+		want: &rotang.User{
+			Email:  "oncaller@example.com",
+			Name:   "Oncaller",
+			Groups: []string{"rota-access", "rota-admins"},
+		},
+	}, {
+		name:     "Wrong password",
+		user:     "oncaller@example.com",
+		password: "hunter3",
+	}, {
+		name:     "No password set",
+		user:     "nopass@example.com",
+		password: "",
+	}, {
+		name:     "Unknown user",
+		user:     "unknown@example.com",
+		password: "hunter2",
+	}, {
+		name:   "No basic auth",
+		noAuth: true,
+	},
+	}
+
+	b := NewBasic(testUsers(t), "")
+	for _, tst := range tests {
+		req := httptest.NewRequest("GET", "/", nil)
+		if !tst.noAuth {
+			req.SetBasicAuth(tst.user, tst.password)
+		}
+		usr, err := b.User(testContext(req))
+		if err != nil {
+			t.Fatalf("%s: User(ctx) failed: %v", tst.name, err)
+		}
+		if diff := pretty.Compare(tst.want, usr); diff != "" {
+			t.Errorf("%s: User(ctx) differ -want +got, %s", tst.name, diff)
+		}
+	}
+
+	ctx := testContext(httptest.NewRequest("GET", "/", nil))
+	b.Login(ctx)
+	recorder := ctx.Writer.(*httptest.ResponseRecorder)
+	if got, want := recorder.Code, http.StatusUnauthorized; got != want {
+		t.Errorf("Login(ctx) = %d want: %d", got, want)
+	}
+	if got, want := recorder.Header().Get("WWW-Authenticate"), `Basic realm="rota", charset="UTF-8"`; got != want {
+		t.Errorf("Login(ctx) WWW-Authenticate = %q want: %q", got, want)
+	}
+}
+
+func TestProxy(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAddr string
+		header     http.Header
+		want       *rotang.User
+	}{{
+		name:       "Success",
+		remoteAddr: "10.0.0.2:4321",
+		header: http.Header{
+			"X-Forwarded-Email":  {"oncaller@example.com"},
+			"X-Forwarded-User":   {"Oncaller"},
+			"X-Forwarded-Groups": {"rota-access, rota-admins,"},
+		},
+		want: &rotang.User{
+			Email:  "oncaller@example.com",
+			Name:   "Oncaller",
+			Groups: []string{"rota-access", "rota-admins"},
+		},
+	}, {
+		name:       "Trusted address",
+		remoteAddr: "[::1]:4321",
+		header: http.Header{
+			"X-Forwarded-Email": {"oncaller@example.com"},
+		},
+		want: &rotang.User{
+			Email: "oncaller@example.com",
+		},
+	}, {
+		name:       "Untrusted proxy",
+		remoteAddr: "192.168.1.1:4321",
+		header: http.Header{
+			"X-Forwarded-Email": {"oncaller@example.com"},
+		},
+	}, {
+		name:       "No email",
+		remoteAddr: "10.0.0.2:4321",
+		header: http.Header{
+			"X-Forwarded-User": {"Oncaller"},
+		},
+	},
+	}
+
+	p, err := NewProxy([]string{"10.0.0.0/24", "::1"})
+	if err != nil {
+		t.Fatalf("NewProxy(_) failed: %v", err)
+	}
+	for _, tst := range tests {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = tst.remoteAddr
+		req.Header = tst.header
+		usr, err := p.User(testContext(req))
+		if err != nil {
+			t.Fatalf("%s: User(ctx) failed: %v", tst.name, err)
+		}
+		if diff := pretty.Compare(tst.want, usr); diff != "" {
+			t.Errorf("%s: User(ctx) differ -want +got, %s", tst.name, diff)
+		}
+	}
+
+	p.LoginURL = "https://sso.example.com/oauth2/start"
+	ctx := testContext(httptest.NewRequest("GET", "/oncall?name=test", nil))
+	p.Login(ctx)
+	recorder := ctx.Writer.(*httptest.ResponseRecorder)
+	if got, want := recorder.Header().Get("Location"), "https://sso.example.com/oauth2/start?rd=%2Foncall%3Fname%3Dtest"; got != want {
+		t.Errorf("Login(ctx) Location = %q want: %q", got, want)
+	}
+
+	for _, trusted := range [][]string{nil, {"not-an-ip"}, {"10.0.0.0/33"}} {
+		if _, err := NewProxy(trusted); err == nil {
+			t.Errorf("NewProxy(%v) succeeded, want failure", trusted)
+		}
+	}
+}
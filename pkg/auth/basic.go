@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+
+	rotang "github.com/miekg/rota"
+	"go.chromium.org/luci/server/router"
+)
+
+// Basic authenticates users with HTTP basic auth, checking the passwords against the users file.
+type Basic struct {
+	users *Users
+	realm string
+}
+
+var _ rotang.Authenticator = &Basic{}
+
+// NewBasic creates a new Basic authenticator.
+func NewBasic(users *Users, realm string) *Basic {
+	if realm == "" {
+		realm = "rota"
+	}
+	return &Basic{
+		users: users,
+		realm: realm,
+	}
+}
+
+// User returns the user matching the basic auth credentials of the request.
+func (b *Basic) User(ctx *router.Context) (*rotang.User, error) {
+	email, password, ok := ctx.Request.BasicAuth()
+	if !ok {
+		return nil, nil
+	}
+	return b.users.Authenticate(email, password), nil
+}
+
+// Login asks the browser for credentials.
+func (b *Basic) Login(ctx *router.Context) {
+	ctx.Writer.Header().Set("WWW-Authenticate", fmt.Sprintf("Basic realm=%q, charset=\"UTF-8\"", b.realm))
+	http.Error(ctx.Writer, "not logged in", http.StatusUnauthorized)
+}
@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	rotang "github.com/miekg/rota"
+	"go.chromium.org/luci/server/router"
+)
+
+// Default headers set by SSO proxies such as oauth2-proxy.
+const (
+	DefaultEmailHeader  = "X-Forwarded-Email"
+	DefaultNameHeader   = "X-Forwarded-User"
+	DefaultGroupsHeader = "X-Forwarded-Groups"
+)
+
+// Proxy authenticates users with headers set by a trusted reverse proxy.
+// Headers are only used for requests coming from the trusted networks.
+type Proxy struct {
+	// EmailHeader, NameHeader and GroupsHeader name the headers to use, the
+	// Default headers are used if not set. Groups are comma separated.
+	EmailHeader  string
+	NameHeader   string
+	GroupsHeader string
+	// LoginURL is where unauthenticated users are redirected, the original
+	// URL is passed in the rd query parameter.
+	LoginURL string
+
+	trusted []*net.IPNet
+}
+
+var _ rotang.Authenticator = &Proxy{}
+
+// NewProxy creates a new Proxy authenticator trusting the provided addresses
+// or networks in CIDR notation.
+func NewProxy(trusted []string) (*Proxy, error) {
+	if len(trusted) == 0 {
+		return nil, fmt.Errorf("no trusted proxies set")
+	}
+	p := &Proxy{}
+	for _, t := range trusted {
+		if !strings.Contains(t, "/") {
+			ip := net.ParseIP(t)
+			if ip == nil {
+				return nil, fmt.Errorf("trusted proxy: %q not an IP address", t)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			p.trusted = append(p.trusted, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(t)
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxy: %v", err)
+		}
+		p.trusted = append(p.trusted, n)
+	}
+	return p, nil
+}
+
+func (p *Proxy) isTrusted(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, n := range p.trusted {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func header(r *http.Request, name, def string) string {
+	if name == "" {
+		name = def
+	}
+	return strings.TrimSpace(r.Header.Get(name))
+}
+
+// User returns the user set in the proxy headers.
+func (p *Proxy) User(ctx *router.Context) (*rotang.User, error) {
+	r := ctx.Request
+	if !p.isTrusted(r.RemoteAddr) {
+		return nil, nil
+	}
+	email := header(r, p.EmailHeader, DefaultEmailHeader)
+	if email == "" {
+		return nil, nil
+	}
+	usr := &rotang.User{
+		Email: email,
+		Name:  header(r, p.NameHeader, DefaultNameHeader),
+	}
+	for _, g := range strings.Split(header(r, p.GroupsHeader, DefaultGroupsHeader), ",") {
+		if g = strings.TrimSpace(g); g != "" {
+			usr.Groups = append(usr.Groups, g)
+		}
+	}
+	return usr, nil
+}
+
+// Login redirects to the LoginURL, or denies access if it's not set.
+func (p *Proxy) Login(ctx *router.Context) {
+	if p.LoginURL == "" {
+		http.Error(ctx.Writer, "not logged in", http.StatusForbidden)
+		return
+	}
+	u, err := url.Parse(p.LoginURL)
+	if err != nil {
+		http.Error(ctx.Writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	q := u.Query()
+	q.Set("rd", ctx.Request.URL.RequestURI())
+	u.RawQuery = q.Encode()
+	http.Redirect(ctx.Writer, ctx.Request, u.String(), http.StatusFound)
+}
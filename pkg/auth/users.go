@@ -0,0 +1,144 @@
+// Package auth implements authentication and authorization for running rota
+// outside of AppEngine, eg. behind HTTP basic auth or an SSO proxy.
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	rotang "github.com/miekg/rota"
+	"go.chromium.org/luci/server/router"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// UserEntry is a user in the users file.
+type UserEntry struct {
+	Email string
+	Name  string
+	// Password is a bcrypt hash of the password, users without a password can't use basic auth.
+	Password string
+	Groups   []string
+}
+
+// Users is a static users file, stored as JSON.
+//
+// It's used as the password database for Basic, and implements the
+// Authorizer interface using the configured admins and groups.
+type Users struct {
+	Users []UserEntry
+	// Admins lists the e-mail addresses of the admins.
+	Admins []string
+	// AdminGroup makes all members of the group admins.
+	AdminGroup string
+
+	users map[string]*UserEntry
+}
+
+var _ rotang.Authorizer = &Users{}
+
+// LoadUsers reads the users file.
+func LoadUsers(path string) (*Users, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var u Users
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&u); err != nil {
+		return nil, fmt.Errorf("parsing %q failed: %v", path, err)
+	}
+	if err := u.index(); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// NewUsers creates a Users from the provided entries.
+func NewUsers(entries []UserEntry, admins []string, adminGroup string) (*Users, error) {
+	u := &Users{
+		Users:      entries,
+		Admins:     admins,
+		AdminGroup: adminGroup,
+	}
+	if err := u.index(); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+func (u *Users) index() error {
+	u.users = make(map[string]*UserEntry)
+	for i := range u.Users {
+		e := &u.Users[i]
+		if e.Email == "" {
+			return fmt.Errorf("user %d: Email not set", i)
+		}
+		key := strings.ToLower(e.Email)
+		if _, ok := u.users[key]; ok {
+			return fmt.Errorf("user: %q listed more than once", e.Email)
+		}
+		u.users[key] = e
+	}
+	return nil
+}
+
+// dummyHash is checked for unknown users, so they take as long as known users
+// and the response time does not tell which users exist.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("rota"), bcrypt.DefaultCost)
+
+// Authenticate checks the password of the user, nil is returned if the user
+// does not exist or the password does not match.
+func (u *Users) Authenticate(email, password string) *rotang.User {
+	e, ok := u.users[strings.ToLower(email)]
+	if !ok || e.Password == "" {
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return nil
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(e.Password), []byte(password)); err != nil {
+		return nil
+	}
+	return &rotang.User{
+		Email:  e.Email,
+		Name:   e.Name,
+		Groups: e.Groups,
+	}
+}
+
+// IsAdmin is true if the user is listed in Admins or a member of the AdminGroup.
+func (u *Users) IsAdmin(ctx *router.Context, usr *rotang.User) (bool, error) {
+	if usr == nil {
+		return false, nil
+	}
+	for _, a := range u.Admins {
+		if strings.EqualFold(a, usr.Email) {
+			return true, nil
+		}
+	}
+	if u.AdminGroup == "" {
+		return false, nil
+	}
+	return u.IsMember(ctx, usr, u.AdminGroup)
+}
+
+// IsMember is true if the group is listed for the user in the users file, or
+// part of the groups set by the Authenticator.
+func (u *Users) IsMember(_ *router.Context, usr *rotang.User, group string) (bool, error) {
+	if usr == nil {
+		return false, nil
+	}
+	groups := usr.Groups
+	if e, ok := u.users[strings.ToLower(usr.Email)]; ok {
+		groups = append(append([]string{}, groups...), e.Groups...)
+	}
+	for _, g := range groups {
+		if g == group {
+			return true, nil
+		}
+	}
+	return false, nil
+}
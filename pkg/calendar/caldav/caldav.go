@@ -0,0 +1,331 @@
+// Package caldav implements a rotang.Calenderer storing events in CalDAV (RFC 4791) collections,
+// eg. Nextcloud or Radicale calendars.
+//
+// Every rotation stores its events in the collection set in Config.CalDAV, one VEVENT per
+// resource. The EvtID of a shift is the UID of its event, new events are stored at the
+// href `<UID>.ics` in the collection. Events created by other clients are found by UID.
+//
+// Rotations are configured by their owners, so only collections under the base URL of the
+// Calendar are used; the credentials are never sent elsewhere.
+package caldav
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	rotang "github.com/miekg/rota"
+	"github.com/miekg/rota/pkg/calendar/ics"
+	"github.com/miekg/rota/pkg/ical"
+	"go.chromium.org/luci/server/router"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Calendar talks to CalDAV servers.
+type Calendar struct {
+	client   *http.Client
+	base     *url.URL
+	username string
+	password string
+}
+
+var _ rotang.Calenderer = &Calendar{}
+
+const prodID = "-//miekg//rota//EN"
+
+// defaultTimeout bounds the requests of a Calendar created without a client.
+const defaultTimeout = 30 * time.Second
+
+// New returns a Calendar using the collections under base, the requests use basic auth when username is set.
+// A nil client is replaced by one with a timeout.
+func New(client *http.Client, base, username, password string) (*Calendar, error) {
+	u, err := url.Parse(base)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "CalDAV base needs to be a http(s) URL: %q", base)
+	}
+	u.Path, u.RawPath = path.Clean("/"+u.Path), ""
+	if u.Path != "/" {
+		u.Path += "/"
+	}
+	if client == nil {
+		client = &http.Client{Timeout: defaultTimeout}
+	}
+	return &Calendar{
+		client:   client,
+		base:     u,
+		username: username,
+		password: password,
+	}, nil
+}
+
+// CreateEvent creates events for the shifts, shifts without an EvtID get the UID of their event.
+func (c *Calendar) CreateEvent(ctx *router.Context, cfg *rotang.Configuration, shifts []rotang.ShiftEntry, updateCal bool) ([]rotang.ShiftEntry, error) {
+	collection, err := c.collectionURL(cfg)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]rotang.ShiftEntry, len(shifts))
+	for i, s := range shifts {
+		if s.EvtID == "" {
+			s.EvtID = ics.UID(cfg, &s)
+		}
+		res[i] = s
+		if !updateCal {
+			continue
+		}
+		if err := c.put(ctx, cfg, collection.ResolveReference(eventHref(s.EvtID)), &s); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// UpdateEvent updates the event of the shift, creating it if it does not exist.
+func (c *Calendar) UpdateEvent(ctx *router.Context, cfg *rotang.Configuration, updated *rotang.ShiftEntry) (*rotang.ShiftEntry, error) {
+	if updated.EvtID == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "shift: %v has no EvtID", updated)
+	}
+	href, _, err := c.find(ctx, cfg, updated.EvtID)
+	switch {
+	case status.Code(err) == codes.NotFound:
+		collection, err := c.collectionURL(cfg)
+		if err != nil {
+			return nil, err
+		}
+		href = collection.ResolveReference(eventHref(updated.EvtID))
+	case err != nil:
+		return nil, err
+	}
+	if err := c.put(ctx, cfg, href, updated); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// DeleteEvent deletes the event of the shift, deleting an event that does not exist is not an error.
+func (c *Calendar) DeleteEvent(ctx *router.Context, cfg *rotang.Configuration, shift *rotang.ShiftEntry) error {
+	href, _, err := c.find(ctx, cfg, shift.EvtID)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil
+		}
+		return err
+	}
+	resp, err := c.do(ctx, "DELETE", href, nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	}
+	return status.Errorf(codes.Internal, "DELETE %s failed: %s", href, resp.Status)
+}
+
+// Event returns the shift stored in the event with the EvtID of shift. The mentors of members still oncall,
+// and their roles when the event has none, are taken from shift.
+func (c *Calendar) Event(ctx *router.Context, cfg *rotang.Configuration, shift *rotang.ShiftEntry) (*rotang.ShiftEntry, error) {
+	if shift.EvtID == "" {
+		return nil, status.Errorf(codes.NotFound, "shift: %v has no EvtID", shift)
+	}
+	_, evt, err := c.find(ctx, cfg, shift.EvtID)
+	if err != nil {
+		return nil, err
+	}
+	res := ics.EventToShift(*evt)
+	ics.MergeOnCall(&res, shift)
+	return &res, nil
+}
+
+// Events returns the shifts in the events ending after from and starting before to.
+func (c *Calendar) Events(ctx *router.Context, cfg *rotang.Configuration, from, to time.Time) ([]rotang.ShiftEntry, error) {
+	events, err := c.report(ctx, cfg, fmt.Sprintf(`<C:time-range start="%s" end="%s"/>`, formatUTC(from), formatUTC(to)))
+	if err != nil {
+		return nil, err
+	}
+	var res []rotang.ShiftEntry
+	for _, e := range events {
+		res = append(res, ics.EventToShift(e.event))
+	}
+	return res, nil
+}
+
+// TrooperOncall is not supported, troopers are read from the legacy calendar.
+func (c *Calendar) TrooperOncall(_ *router.Context, _, _ string, _ time.Time) ([]string, error) {
+	return nil, status.Errorf(codes.Unimplemented, "trooper calendar not supported by CalDAV")
+}
+
+// TrooperShifts is not supported, troopers are read from the legacy calendar.
+func (c *Calendar) TrooperShifts(_ *router.Context, _, _ string, _, _ time.Time) ([]rotang.ShiftEntry, error) {
+	return nil, status.Errorf(codes.Unimplemented, "trooper calendar not supported by CalDAV")
+}
+
+// collectionURL returns Config.CalDAV, ending in a slash to resolve the event hrefs against.
+func (c *Calendar) collectionURL(cfg *rotang.Configuration) (*url.URL, error) {
+	if cfg.Config.CalDAV == "" {
+		return nil, status.Errorf(codes.FailedPrecondition, "no CalDAV collection set for rota: %q", cfg.Config.Name)
+	}
+	u, err := url.Parse(cfg.Config.CalDAV)
+	if err != nil {
+		return nil, err
+	}
+	// Resolving removes the dot segments.
+	u = c.base.ResolveReference(u)
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+	if !c.allowed(u) {
+		return nil, status.Errorf(codes.PermissionDenied, "CalDAV collection: %q not under: %q", cfg.Config.CalDAV, c.base)
+	}
+	return u, nil
+}
+
+// allowed is true for URLs under the base URL.
+func (c *Calendar) allowed(u *url.URL) bool {
+	if u.Scheme != c.base.Scheme || !strings.EqualFold(u.Host, c.base.Host) || u.User != nil {
+		return false
+	}
+	return strings.HasPrefix(path.Clean(u.Path)+"/", c.base.Path)
+}
+
+func eventHref(uid string) *url.URL {
+	return &url.URL{Path: uid + ".ics", RawPath: url.PathEscape(uid) + ".ics"}
+}
+
+func formatUTC(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// put stores the shift as the event at href.
+func (c *Calendar) put(ctx *router.Context, cfg *rotang.Configuration, href *url.URL, shift *rotang.ShiftEntry) error {
+	var buf bytes.Buffer
+	if err := ical.Write(&buf, &ical.Calendar{
+		ProdID:   prodID,
+		Location: cfg.Config.Shifts.Location(),
+		Stamp:    time.Now(),
+		Events:   []ical.Event{ics.ShiftToEvent(cfg, shift)},
+	}); err != nil {
+		return err
+	}
+	resp, err := c.do(ctx, "PUT", href, &buf, map[string]string{"Content-Type": "text/calendar; charset=utf-8"})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusNoContent:
+		return nil
+	}
+	return status.Errorf(codes.Internal, "PUT %s failed: %s", href, resp.Status)
+}
+
+// find returns the href and event with the UID, NotFound if the collection has no such event.
+func (c *Calendar) find(ctx *router.Context, cfg *rotang.Configuration, uid string) (*url.URL, *ical.Event, error) {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(uid))
+	events, err := c.report(ctx, cfg, `<C:prop-filter name="UID"><C:text-match collation="i;octet">`+buf.String()+`</C:text-match></C:prop-filter>`)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, e := range events {
+		if e.event.UID == uid {
+			return e.href, &e.event, nil
+		}
+	}
+	return nil, nil, status.Errorf(codes.NotFound, "event: %q not found", uid)
+}
+
+type hrefEvent struct {
+	href  *url.URL
+	event ical.Event
+}
+
+// multistatus is the response to a REPORT, only the parts used are decoded.
+type multistatus struct {
+	Responses []struct {
+		Href     string `xml:"DAV: href"`
+		Propstat []struct {
+			Prop struct {
+				CalendarData string `xml:"urn:ietf:params:xml:ns:caldav calendar-data"`
+			} `xml:"DAV: prop"`
+		} `xml:"DAV: propstat"`
+	} `xml:"DAV: response"`
+}
+
+// report runs a calendar-query REPORT on the collection of the rota, returning the events matching filter.
+func (c *Calendar) report(ctx *router.Context, cfg *rotang.Configuration, filter string) ([]hrefEvent, error) {
+	collection, err := c.collectionURL(cfg)
+	if err != nil {
+		return nil, err
+	}
+	body := `<?xml version="1.0" encoding="utf-8"?>
+<C:calendar-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+  <D:prop><D:getetag/><C:calendar-data/></D:prop>
+  <C:filter>
+    <C:comp-filter name="VCALENDAR">
+      <C:comp-filter name="VEVENT">` + filter + `</C:comp-filter>
+    </C:comp-filter>
+  </C:filter>
+</C:calendar-query>
+`
+	resp, err := c.do(ctx, "REPORT", collection, strings.NewReader(body), map[string]string{
+		"Content-Type": "application/xml; charset=utf-8",
+		"Depth":        "1",
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusMultiStatus {
+		return nil, status.Errorf(codes.Internal, "REPORT %s failed: %s", collection, resp.Status)
+	}
+	var ms multistatus
+	if err := xml.NewDecoder(resp.Body).Decode(&ms); err != nil {
+		return nil, err
+	}
+	var res []hrefEvent
+	for _, r := range ms.Responses {
+		href, err := collection.Parse(r.Href)
+		if err != nil {
+			return nil, err
+		}
+		for _, ps := range r.Propstat {
+			if ps.Prop.CalendarData == "" {
+				continue
+			}
+			events, err := ical.Parse(strings.NewReader(ps.Prop.CalendarData), cfg.Config.Shifts.Location())
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", href, err)
+			}
+			for _, e := range events {
+				res = append(res, hrefEvent{href: href, event: e})
+			}
+		}
+	}
+	return res, nil
+}
+
+func (c *Calendar) do(ctx *router.Context, method string, u *url.URL, body io.Reader, header map[string]string) (*http.Response, error) {
+	if !c.allowed(u) {
+		return nil, status.Errorf(codes.PermissionDenied, "%s %s: not under: %q", method, u, c.base)
+	}
+	req, err := http.NewRequestWithContext(ctx.Context, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	return c.client.Do(req)
+}
@@ -0,0 +1,305 @@
+package caldav
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+	rotang "github.com/miekg/rota"
+	"github.com/miekg/rota/pkg/ical"
+	"go.chromium.org/luci/server/router"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var midnight = time.Date(2006, 4, 2, 0, 0, 0, 0, time.UTC)
+
+const fullDay = 24 * time.Hour
+
+// fakeDAV is a CalDAV stand-in, storing the resources PUT in a collection and answering
+// calendar-query REPORTs with a time-range or UID filter.
+type fakeDAV struct {
+	mu        sync.Mutex
+	resources map[string]string
+}
+
+var (
+	timeRangeRe = regexp.MustCompile(`time-range start="([^"]+)" end="([^"]+)"`)
+	uidRe       = regexp.MustCompile(`<C:text-match[^>]*>([^<]*)</C:text-match>`)
+)
+
+func (f *fakeDAV) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if user, pass, _ := r.BasicAuth(); user != "rota" || pass != "secret" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case "PUT":
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		code := http.StatusCreated
+		if _, ok := f.resources[r.URL.EscapedPath()]; ok {
+			code = http.StatusNoContent
+		}
+		f.resources[r.URL.EscapedPath()] = string(b)
+		w.WriteHeader(code)
+	case "DELETE":
+		if _, ok := f.resources[r.URL.EscapedPath()]; !ok {
+			http.NotFound(w, r)
+			return
+		}
+		delete(f.resources, r.URL.EscapedPath())
+		w.WriteHeader(http.StatusNoContent)
+	case "REPORT":
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.report(w, r.URL.EscapedPath(), string(b))
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *fakeDAV) report(w http.ResponseWriter, collection, query string) {
+	var hrefs []string
+	for href := range f.resources {
+		if strings.HasPrefix(href, collection) {
+			hrefs = append(hrefs, href)
+		}
+	}
+	sort.Strings(hrefs)
+	var responses []string
+	for _, href := range hrefs {
+		events, err := ical.Parse(strings.NewReader(f.resources[href]), time.UTC)
+		if err != nil || len(events) != 1 {
+			http.Error(w, fmt.Sprintf("broken resource: %s", href), http.StatusInternalServerError)
+			return
+		}
+		e := events[0]
+		if m := timeRangeRe.FindStringSubmatch(query); m != nil {
+			start, _ := time.Parse("20060102T150405Z", m[1])
+			end, _ := time.Parse("20060102T150405Z", m[2])
+			if !e.End.After(start) || !e.Start.Before(end) {
+				continue
+			}
+		}
+		if m := uidRe.FindStringSubmatch(query); m != nil && m[1] != e.UID {
+			continue
+		}
+		var data strings.Builder
+		xml.EscapeText(&data, []byte(f.resources[href]))
+		responses = append(responses, `<D:response><D:href>`+href+`</D:href><D:propstat><D:prop><C:calendar-data>`+
+			data.String()+`</C:calendar-data></D:prop><D:status>HTTP/1.1 200 OK</D:status></D:propstat></D:response>`)
+	}
+	w.WriteHeader(http.StatusMultiStatus)
+	fmt.Fprint(w, `<?xml version="1.0" encoding="utf-8"?><D:multistatus xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">`+
+		strings.Join(responses, "")+`</D:multistatus>`)
+}
+
+func testShifts() []rotang.ShiftEntry {
+	return []rotang.ShiftEntry{
+		{
+			Name: "MTV All Day",
+			OnCall: []rotang.ShiftMember{
+				{Email: "oncaller1@oncall.com", ShiftName: "MTV All Day"},
+				{Email: "oncaller2@oncall.com", ShiftName: "MTV All Day"},
+			},
+			StartTime: midnight,
+			EndTime:   midnight.Add(fullDay),
+		}, {
+			Name: "MTV All Day",
+			OnCall: []rotang.ShiftMember{
+				{Email: "oncaller2@oncall.com", ShiftName: "MTV All Day"},
+			},
+			StartTime: midnight.Add(fullDay),
+			EndTime:   midnight.Add(2 * fullDay),
+			Comment:   "Second shift",
+		},
+	}
+}
+
+func TestCalendar(t *testing.T) {
+	dav := &fakeDAV{resources: make(map[string]string)}
+	srv := httptest.NewServer(dav)
+	defer srv.Close()
+
+	ctx := &router.Context{Context: context.Background()}
+	c, err := New(srv.Client(), srv.URL+"/calendars", "rota", "secret")
+	if err != nil {
+		t.Fatalf("New(_, %q, _, _) failed: %v", srv.URL+"/calendars", err)
+	}
+	wrong, err := New(srv.Client(), srv.URL+"/calendars", "rota", "wrong")
+	if err != nil {
+		t.Fatalf("New(_, %q, _, _) failed: %v", srv.URL+"/calendars", err)
+	}
+	cfg := &rotang.Configuration{
+		Config: rotang.Config{
+			Name:   "Test Rota",
+			CalDAV: srv.URL + "/calendars/rota/test",
+		},
+	}
+
+	if _, err := c.CreateEvent(ctx, &rotang.Configuration{}, testShifts(), true); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("CreateEvent(ctx, _, _, true) without collection = %v want: %v", err, codes.FailedPrecondition)
+	}
+	if _, err := wrong.CreateEvent(ctx, cfg, testShifts(), true); err == nil {
+		t.Fatalf("CreateEvent(ctx, _, _, true) with the wrong password succeeded")
+	}
+
+	shifts, err := c.CreateEvent(ctx, cfg, testShifts(), true)
+	if err != nil {
+		t.Fatalf("CreateEvent(ctx, _, _, true) failed: %v", err)
+	}
+	if _, ok := dav.resources["/calendars/rota/test/"+url.PathEscape(shifts[0].EvtID)+".ics"]; !ok {
+		t.Fatalf("CreateEvent(ctx, _, _, true) did not store: %q, resources: %v", shifts[0].EvtID, dav.resources)
+	}
+	got, err := c.Events(ctx, cfg, midnight, midnight.Add(7*fullDay))
+	if err != nil {
+		t.Fatalf("Events(ctx, _, _, _) failed: %v", err)
+	}
+	compare(t, "Events", shifts, got)
+
+	// Events created by other clients are stored under their own href.
+	external := shifts[1]
+	delete(dav.resources, "/calendars/rota/test/"+url.PathEscape(external.EvtID)+".ics")
+	external.EvtID = "external-uid"
+	if err := c.put(ctx, cfg, mustParse(t, srv.URL+"/calendars/rota/test/other.ics"), &external); err != nil {
+		t.Fatalf("put(ctx, _, _, _) failed: %v", err)
+	}
+
+	updated := external
+	updated.OnCall = []rotang.ShiftMember{{Email: "oncaller1@oncall.com", ShiftName: "MTV All Day", Role: rotang.Primary}}
+	if _, err := c.UpdateEvent(ctx, cfg, &updated); err != nil {
+		t.Fatalf("UpdateEvent(ctx, _, _) failed: %v", err)
+	}
+	if _, ok := dav.resources["/calendars/rota/test/external-uid.ics"]; ok {
+		t.Fatalf("UpdateEvent(ctx, _, _) created a new resource instead of updating other.ics")
+	}
+	// The roles are read from the calendar.
+	evt, err := c.Event(ctx, cfg, &rotang.ShiftEntry{EvtID: updated.EvtID})
+	if err != nil {
+		t.Fatalf("Event(ctx, _, _) failed: %v", err)
+	}
+	compare(t, "Event", []rotang.ShiftEntry{updated}, []rotang.ShiftEntry{*evt})
+
+	if err := c.DeleteEvent(ctx, cfg, &updated); err != nil {
+		t.Fatalf("DeleteEvent(ctx, _, _) failed: %v", err)
+	}
+	if err := c.DeleteEvent(ctx, cfg, &updated); err != nil {
+		t.Fatalf("DeleteEvent(ctx, _, _) of a deleted event failed: %v", err)
+	}
+	if _, err := c.Event(ctx, cfg, &updated); status.Code(err) != codes.NotFound {
+		t.Fatalf("Event(ctx, _, _) of a deleted event = %v want: %v", err, codes.NotFound)
+	}
+	if got, err = c.Events(ctx, cfg, midnight, midnight.Add(7*fullDay)); err != nil {
+		t.Fatalf("Events(ctx, _, _, _) failed: %v", err)
+	}
+	compare(t, "Events after delete", shifts[:1], got)
+}
+
+func TestCollections(t *testing.T) {
+	dav := &fakeDAV{resources: make(map[string]string)}
+	srv := httptest.NewServer(dav)
+	defer srv.Close()
+	// other records the requests, no credentials may be sent to it.
+	var requests int
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	}))
+	defer other.Close()
+
+	ctx := &router.Context{Context: context.Background()}
+	c, err := New(nil, srv.URL+"/calendars/", "rota", "secret")
+	if err != nil {
+		t.Fatalf("New(nil, %q, _, _) failed: %v", srv.URL+"/calendars/", err)
+	}
+	if c.client == http.DefaultClient || c.client.Timeout == 0 {
+		t.Errorf("New(nil, _, _, _) client without a timeout")
+	}
+
+	tests := []struct {
+		name       string
+		collection string
+		code       codes.Code
+	}{{
+		name:       "Under base",
+		collection: srv.URL + "/calendars/rota/test",
+		code:       codes.OK,
+	}, {
+		name:       "Other host",
+		collection: other.URL + "/calendars/rota/test",
+		code:       codes.PermissionDenied,
+	}, {
+		name:       "Outside base",
+		collection: srv.URL + "/other/test",
+		code:       codes.PermissionDenied,
+	}, {
+		name:       "Base prefix",
+		collection: srv.URL + "/calendars-other/test",
+		code:       codes.PermissionDenied,
+	}, {
+		name:       "Dot segments",
+		collection: srv.URL + "/calendars/../other/test",
+		code:       codes.PermissionDenied,
+	}, {
+		name:       "Other scheme",
+		collection: strings.Replace(srv.URL, "http:", "https:", 1) + "/calendars/rota/test",
+		code:       codes.PermissionDenied,
+	},
+	}
+
+	for _, tst := range tests {
+		cfg := &rotang.Configuration{Config: rotang.Config{Name: "Test Rota", CalDAV: tst.collection}}
+		if _, err := c.CreateEvent(ctx, cfg, testShifts(), true); status.Code(err) != tst.code {
+			t.Errorf("%s: CreateEvent(ctx, _, _, true) = %v want: %v", tst.name, err, tst.code)
+		}
+	}
+	if requests != 0 {
+		t.Errorf("CreateEvent(ctx, _, _, true) sent %d requests to a collection outside the base URL", requests)
+	}
+
+	for _, base := range []string{"", "calendars", "ftp://dav.example.com/"} {
+		if _, err := New(nil, base, "rota", "secret"); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("New(nil, %q, _, _) = %v want: %v", base, err, codes.InvalidArgument)
+		}
+	}
+}
+
+func mustParse(t *testing.T, s string) *url.URL {
+	t.Helper()
+	u, err := url.Parse(s)
+	if err != nil {
+		t.Fatalf("url.Parse(%q) failed: %v", s, err)
+	}
+	return u
+}
+
+// compare compares the shifts, ignoring the location of the times.
+func compare(t *testing.T, name string, want, got []rotang.ShiftEntry) {
+	t.Helper()
+	for i := range got {
+		got[i].StartTime, got[i].EndTime = got[i].StartTime.UTC(), got[i].EndTime.UTC()
+	}
+	if diff := pretty.Compare(want, got); diff != "" {
+		t.Fatalf("%s: differ -want +got, %s", name, diff)
+	}
+}
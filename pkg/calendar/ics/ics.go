@@ -0,0 +1,346 @@
+// Package ics implements a rotang.Calenderer storing events in a local directory of
+// iCalendar (.ics) files, one file per calendar. Rotations can share a calendar, the
+// events of a rotation have the rota name in their categories.
+//
+// The files can be subscribed to or imported by calendar clients, and edits to them are
+// picked up like edits to any other calendar. It allows rotations to run, and be tested,
+// without access to a calendar service.
+package ics
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	rotang "github.com/miekg/rota"
+	"github.com/miekg/rota/pkg/ical"
+	"go.chromium.org/luci/server/router"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Calendar stores events in .ics files.
+type Calendar struct {
+	dir string
+	// mu serializes the read-modify-write of the files.
+	mu sync.Mutex
+}
+
+var _ rotang.Calenderer = &Calendar{}
+
+const prodID = "-//miekg//rota//EN"
+
+// New returns a Calendar storing the .ics files in dir, dir is created if it does not exist.
+func New(dir string) (*Calendar, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &Calendar{dir: dir}, nil
+}
+
+// CreateEvent creates events for the shifts, shifts without an EvtID get the UID of their event.
+func (c *Calendar) CreateEvent(_ *router.Context, cfg *rotang.Configuration, shifts []rotang.ShiftEntry, updateCal bool) ([]rotang.ShiftEntry, error) {
+	res := make([]rotang.ShiftEntry, len(shifts))
+	for i, s := range shifts {
+		if s.EvtID == "" {
+			s.EvtID = UID(cfg, &s)
+		}
+		res[i] = s
+	}
+	if !updateCal {
+		return res, nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	events, err := c.read(calendarID(cfg), cfg.Config.Shifts.Location())
+	if err != nil {
+		return nil, err
+	}
+	for i := range res {
+		events = setEvent(events, ShiftToEvent(cfg, &res[i]))
+	}
+	if err := c.write(cfg, events); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// UpdateEvent updates the event of the shift, creating it if it does not exist.
+func (c *Calendar) UpdateEvent(_ *router.Context, cfg *rotang.Configuration, updated *rotang.ShiftEntry) (*rotang.ShiftEntry, error) {
+	if updated.EvtID == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "shift: %v has no EvtID", updated)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	events, err := c.read(calendarID(cfg), cfg.Config.Shifts.Location())
+	if err != nil {
+		return nil, err
+	}
+	if err := c.write(cfg, setEvent(events, ShiftToEvent(cfg, updated))); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// DeleteEvent deletes the event of the shift, deleting an event that does not exist is not an error.
+func (c *Calendar) DeleteEvent(_ *router.Context, cfg *rotang.Configuration, shift *rotang.ShiftEntry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	events, err := c.read(calendarID(cfg), cfg.Config.Shifts.Location())
+	if err != nil {
+		return err
+	}
+	var res []ical.Event
+	for _, e := range events {
+		if e.UID != shift.EvtID {
+			res = append(res, e)
+		}
+	}
+	if len(res) == len(events) {
+		return nil
+	}
+	return c.write(cfg, res)
+}
+
+// Event returns the shift stored in the event with the EvtID of shift. The mentors of members still oncall,
+// and their roles when the event has none, are taken from shift.
+func (c *Calendar) Event(_ *router.Context, cfg *rotang.Configuration, shift *rotang.ShiftEntry) (*rotang.ShiftEntry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	events, err := c.read(calendarID(cfg), cfg.Config.Shifts.Location())
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range events {
+		if shift.EvtID == "" || e.UID != shift.EvtID {
+			continue
+		}
+		res := EventToShift(e)
+		MergeOnCall(&res, shift)
+		return &res, nil
+	}
+	return nil, status.Errorf(codes.NotFound, "event: %q not found", shift.EvtID)
+}
+
+// Events returns the shifts of the rotation in the events ending after from and starting before to.
+func (c *Calendar) Events(_ *router.Context, cfg *rotang.Configuration, from, to time.Time) ([]rotang.ShiftEntry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	events, err := c.read(calendarID(cfg), cfg.Config.Shifts.Location())
+	if err != nil {
+		return nil, err
+	}
+	var res []rotang.ShiftEntry
+	for _, e := range events {
+		if e.End.After(from) && e.Start.Before(to) && inRota(cfg, e) {
+			res = append(res, EventToShift(e))
+		}
+	}
+	return res, nil
+}
+
+// TrooperOncall returns the troopers oncall at the time at, see TrooperShifts.
+func (c *Calendar) TrooperOncall(ctx *router.Context, calendarID, match string, at time.Time) ([]string, error) {
+	shifts, err := c.TrooperShifts(ctx, calendarID, match, at, at.Add(time.Nanosecond))
+	if err != nil {
+		return nil, err
+	}
+	var res []string
+	for _, s := range shifts {
+		for _, o := range s.OnCall {
+			res = append(res, o.Email)
+		}
+	}
+	return res, nil
+}
+
+// TrooperShifts returns the trooper shifts between from and to, read from the calendarID file. Troopers
+// are listed in the event summary after match, separated by commas, eg. `CCI-Trooper: primary, secondary`.
+func (c *Calendar) TrooperShifts(_ *router.Context, calendarID, match string, from, to time.Time) ([]rotang.ShiftEntry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	events, err := c.read(calendarID, time.UTC)
+	if err != nil {
+		return nil, err
+	}
+	var res []rotang.ShiftEntry
+	for _, e := range events {
+		if !e.End.After(from) || !e.Start.Before(to) || !strings.HasPrefix(e.Summary, match) {
+			continue
+		}
+		s := rotang.ShiftEntry{
+			Name:      match,
+			StartTime: e.Start,
+			EndTime:   e.End,
+			EvtID:     e.UID,
+		}
+		for _, t := range strings.Split(strings.TrimPrefix(e.Summary, match), ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				s.OnCall = append(s.OnCall, rotang.ShiftMember{Email: t, ShiftName: match})
+			}
+		}
+		res = append(res, s)
+	}
+	return res, nil
+}
+
+// inRota is true if the event belongs to the rotation, see ShiftToEvent.
+func inRota(cfg *rotang.Configuration, e ical.Event) bool {
+	// The first category is the shift name.
+	for i, c := range e.Categories {
+		if i > 0 && c == cfg.Config.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// calendarID returns Config.Calendar, or the rota name when that is not set.
+func calendarID(cfg *rotang.Configuration) string {
+	if cfg.Config.Calendar != "" {
+		return cfg.Config.Calendar
+	}
+	return cfg.Config.Name
+}
+
+func (c *Calendar) path(id string) string {
+	return filepath.Join(c.dir, url.PathEscape(id)+".ics")
+}
+
+// read returns the events sorted by start time, a calendar without a file has no events.
+func (c *Calendar) read(id string, loc *time.Location) ([]ical.Event, error) {
+	f, err := os.Open(c.path(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	events, err := ical.Parse(f, loc)
+	if err != nil {
+		return nil, fmt.Errorf("calendar: %q: %v", id, err)
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Start.Before(events[j].Start)
+	})
+	return events, nil
+}
+
+// write replaces the calendar file, the new file is renamed into place to not leave a partial file behind.
+func (c *Calendar) write(cfg *rotang.Configuration, events []ical.Event) error {
+	f, err := os.CreateTemp(c.dir, "tmp-*.ics")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if err := ical.Write(f, &ical.Calendar{
+		ProdID:   prodID,
+		Name:     calendarID(cfg),
+		Location: cfg.Config.Shifts.Location(),
+		Stamp:    time.Now(),
+		Events:   events,
+	}); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), c.path(calendarID(cfg)))
+}
+
+// setEvent replaces the event with the same UID, or adds it.
+func setEvent(events []ical.Event, evt ical.Event) []ical.Event {
+	for i := range events {
+		if events[i].UID == evt.UID {
+			events[i] = evt
+			return events
+		}
+	}
+	return append(events, evt)
+}
+
+// UID returns the UID for a new event of the shift, the shifts of a rota with the same name all start at different times.
+func UID(cfg *rotang.Configuration, shift *rotang.ShiftEntry) string {
+	return fmt.Sprintf("%s-%s-%s@rota", shift.StartTime.UTC().Format("20060102T150405Z"), url.PathEscape(cfg.Config.Name), url.PathEscape(shift.Name))
+}
+
+// ShiftToEvent returns the event for the shift. The shift name and the rota name are stored in the event
+// categories and the members oncall as attendees, with their role as the ROLE parameter.
+func ShiftToEvent(cfg *rotang.Configuration, shift *rotang.ShiftEntry) ical.Event {
+	summary := cfg.Config.Name + " " + shift.Name
+	var (
+		oncall    []string
+		attendees []ical.Attendee
+	)
+	for _, o := range shift.OnCall {
+		oncall = append(oncall, o.String())
+		attendees = append(attendees, ical.Attendee{Email: o.Email, Role: string(o.Role)})
+	}
+	if len(oncall) > 0 {
+		summary += ": " + strings.Join(oncall, ", ")
+	}
+	return ical.Event{
+		UID:         shift.EvtID,
+		Summary:     summary,
+		Description: shift.Comment,
+		Start:       shift.StartTime,
+		End:         shift.EndTime,
+		Categories:  []string{shift.Name, cfg.Config.Name},
+		Attendees:   attendees,
+	}
+}
+
+// participationRoles are the ROLE values of RFC 5545, set by other calendar clients.
+var participationRoles = map[string]bool{
+	"CHAIR":           true,
+	"REQ-PARTICIPANT": true,
+	"OPT-PARTICIPANT": true,
+	"NON-PARTICIPANT": true,
+}
+
+// EventToShift returns the shift stored in the event, the shift name is the first of the categories.
+// The ROLE values of RFC 5545 are not rota roles and are left out.
+func EventToShift(e ical.Event) rotang.ShiftEntry {
+	var name string
+	if len(e.Categories) > 0 {
+		name = e.Categories[0]
+	}
+	res := rotang.ShiftEntry{
+		Name:      name,
+		StartTime: e.Start,
+		EndTime:   e.End,
+		Comment:   e.Description,
+		EvtID:     e.UID,
+	}
+	for _, a := range e.Attendees {
+		m := rotang.ShiftMember{Email: a.Email, ShiftName: name}
+		if !participationRoles[strings.ToUpper(a.Role)] {
+			m.Role = rotang.Role(a.Role)
+		}
+		res.OnCall = append(res.OnCall, m)
+	}
+	return res
+}
+
+// MergeOnCall copies the mentors of the members in shift to the same members in res, and their
+// roles when res has none.
+func MergeOnCall(res, shift *rotang.ShiftEntry) {
+	for i, o := range res.OnCall {
+		for _, so := range shift.OnCall {
+			if so.Email != o.Email {
+				continue
+			}
+			if o.Role == "" {
+				res.OnCall[i].Role = so.Role
+			}
+			res.OnCall[i].Mentor = so.Mentor
+		}
+	}
+}
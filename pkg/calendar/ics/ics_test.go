@@ -0,0 +1,291 @@
+package ics
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+	rotang "github.com/miekg/rota"
+	"go.chromium.org/luci/server/router"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var midnight = time.Date(2006, 4, 2, 0, 0, 0, 0, time.UTC)
+
+const fullDay = 24 * time.Hour
+
+func testShifts() []rotang.ShiftEntry {
+	return []rotang.ShiftEntry{
+		{
+			Name: "MTV All Day",
+			OnCall: []rotang.ShiftMember{
+				{Email: "oncaller1@oncall.com", ShiftName: "MTV All Day"},
+				{Email: "oncaller2@oncall.com", ShiftName: "MTV All Day"},
+			},
+			StartTime: midnight,
+			EndTime:   midnight.Add(fullDay),
+		}, {
+			Name: "MTV All Day",
+			OnCall: []rotang.ShiftMember{
+				{Email: "oncaller2@oncall.com", ShiftName: "MTV All Day"},
+			},
+			StartTime: midnight.Add(fullDay),
+			EndTime:   midnight.Add(2 * fullDay),
+			Comment:   "Split, the second shift",
+		},
+	}
+}
+
+func TestCalendar(t *testing.T) {
+	ctx := &router.Context{Context: context.Background()}
+	c, err := New(t.TempDir())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	amsTime, err := time.LoadLocation("Europe/Amsterdam")
+	if err != nil {
+		t.Fatalf("time.LoadLocation() failed: %v", err)
+	}
+	cfg := &rotang.Configuration{
+		Config: rotang.Config{
+			Name: "Test Rota",
+			Shifts: rotang.ShiftConfig{
+				TZ: *amsTime,
+			},
+		},
+	}
+
+	// Not updating the calendar only sets the EvtID.
+	shifts, err := c.CreateEvent(ctx, cfg, testShifts(), false)
+	if err != nil {
+		t.Fatalf("CreateEvent(ctx, _, _, false) failed: %v", err)
+	}
+	if _, err := c.Event(ctx, cfg, &shifts[0]); status.Code(err) != codes.NotFound {
+		t.Fatalf("Event(ctx, _, %v) = %v want: %v", shifts[0], err, codes.NotFound)
+	}
+	// The rota and member feeds use the same UID for shifts without an event.
+	if got, want := shifts[0].EvtID, "20060402T000000Z-Test%20Rota-MTV%20All%20Day@rota"; got != want {
+		t.Errorf("CreateEvent(ctx, _, _, false) EvtID = %q want: %q", got, want)
+	}
+
+	if shifts, err = c.CreateEvent(ctx, cfg, testShifts(), true); err != nil {
+		t.Fatalf("CreateEvent(ctx, _, _, true) failed: %v", err)
+	}
+	for _, s := range shifts {
+		if s.EvtID == "" {
+			t.Fatalf("CreateEvent(ctx, _, _, true) = %v, EvtID not set", s)
+		}
+	}
+	got, err := c.Events(ctx, cfg, midnight, midnight.Add(7*fullDay))
+	if err != nil {
+		t.Fatalf("Events(ctx, _, _, _) failed: %v", err)
+	}
+	compare(t, "Events", shifts, got)
+
+	updated := shifts[1]
+	updated.OnCall = []rotang.ShiftMember{{Email: "oncaller1@oncall.com", ShiftName: "MTV All Day", Role: rotang.Primary}}
+	if _, err := c.UpdateEvent(ctx, cfg, &updated); err != nil {
+		t.Fatalf("UpdateEvent(ctx, _, _) failed: %v", err)
+	}
+	// The roles are read from the calendar.
+	evt, err := c.Event(ctx, cfg, &rotang.ShiftEntry{EvtID: updated.EvtID})
+	if err != nil {
+		t.Fatalf("Event(ctx, _, _) failed: %v", err)
+	}
+	compare(t, "Event", []rotang.ShiftEntry{updated}, []rotang.ShiftEntry{*evt})
+
+	if err := c.DeleteEvent(ctx, cfg, &shifts[0]); err != nil {
+		t.Fatalf("DeleteEvent(ctx, _, _) failed: %v", err)
+	}
+	if err := c.DeleteEvent(ctx, cfg, &shifts[0]); err != nil {
+		t.Fatalf("DeleteEvent(ctx, _, _) of a deleted event failed: %v", err)
+	}
+	if got, err = c.Events(ctx, cfg, midnight, midnight.Add(7*fullDay)); err != nil {
+		t.Fatalf("Events(ctx, _, _, _) failed: %v", err)
+	}
+	compare(t, "Events after delete", []rotang.ShiftEntry{updated}, got)
+
+	// A new Calendar reads the events from the files.
+	c2, err := New(c.dir)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if got, err = c2.Events(ctx, cfg, midnight.Add(fullDay), midnight.Add(fullDay+time.Hour)); err != nil {
+		t.Fatalf("Events(ctx, _, _, _) failed: %v", err)
+	}
+	compare(t, "Events from file", []rotang.ShiftEntry{updated}, got)
+}
+
+func TestSharedCalendar(t *testing.T) {
+	ctx := &router.Context{Context: context.Background()}
+	c, err := New(t.TempDir())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	cfg1 := &rotang.Configuration{
+		Config: rotang.Config{
+			Name:     "Test Rota",
+			Calendar: "shared",
+		},
+	}
+	cfg2 := &rotang.Configuration{
+		Config: rotang.Config{
+			Name:     "Other Rota",
+			Calendar: "shared",
+		},
+	}
+
+	shifts1, err := c.CreateEvent(ctx, cfg1, testShifts(), true)
+	if err != nil {
+		t.Fatalf("CreateEvent(ctx, %q, _, true) failed: %v", cfg1.Config.Name, err)
+	}
+	shifts2, err := c.CreateEvent(ctx, cfg2, testShifts()[:1], true)
+	if err != nil {
+		t.Fatalf("CreateEvent(ctx, %q, _, true) failed: %v", cfg2.Config.Name, err)
+	}
+
+	for _, tst := range []struct {
+		cfg  *rotang.Configuration
+		want []rotang.ShiftEntry
+	}{{cfg1, shifts1}, {cfg2, shifts2}} {
+		got, err := c.Events(ctx, tst.cfg, midnight, midnight.Add(7*fullDay))
+		if err != nil {
+			t.Fatalf("Events(ctx, %q, _, _) failed: %v", tst.cfg.Config.Name, err)
+		}
+		compare(t, "Events of "+tst.cfg.Config.Name, tst.want, got)
+	}
+
+	// The calendar keeps its name whichever rota wrote it last.
+	b, err := os.ReadFile(c.path("shared"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "X-WR-CALNAME:shared") {
+		t.Errorf("calendar file: %q does not contain X-WR-CALNAME:shared", b)
+	}
+}
+
+func TestTrooperShifts(t *testing.T) {
+	ctx := &router.Context{Context: context.Background()}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "troopers.ics"), []byte(`BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:1
+DTSTART:20060402T000000Z
+DTEND:20060403T000000Z
+SUMMARY:CCI-Trooper: primary, secondary
+END:VEVENT
+BEGIN:VEVENT
+UID:2
+DTSTART:20060402T000000Z
+DTEND:20060403T000000Z
+SUMMARY:Something else
+END:VEVENT
+BEGIN:VEVENT
+UID:3
+DTSTART:20060403T000000Z
+DTEND:20060404T000000Z
+SUMMARY:CCI-Trooper: other
+END:VEVENT
+END:VCALENDAR
+`), 0644); err != nil {
+		t.Fatalf("os.WriteFile() failed: %v", err)
+	}
+	c, err := New(dir)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		calendar string
+		from, to time.Time
+		want     []string
+	}{{
+		name:     "First day",
+		calendar: "troopers",
+		from:     midnight,
+		to:       midnight.Add(fullDay),
+		want:     []string{"primary", "secondary"},
+	}, {
+		name:     "Both days",
+		calendar: "troopers",
+		from:     midnight.Add(12 * time.Hour),
+		to:       midnight.Add(36 * time.Hour),
+		want:     []string{"primary", "secondary", "other"},
+	}, {
+		name:     "Calendar without file",
+		calendar: "unknown",
+		from:     midnight,
+		to:       midnight.Add(fullDay),
+	},
+	}
+
+	for _, tst := range tests {
+		shifts, err := c.TrooperShifts(ctx, tst.calendar, "CCI-Trooper:", tst.from, tst.to)
+		if err != nil {
+			t.Errorf("%s: TrooperShifts(ctx, %q, _, _, _) failed: %v", tst.name, tst.calendar, err)
+			continue
+		}
+		var got []string
+		for _, s := range shifts {
+			for _, o := range s.OnCall {
+				got = append(got, o.Email)
+			}
+		}
+		if diff := pretty.Compare(tst.want, got); diff != "" {
+			t.Errorf("%s: TrooperShifts(ctx, %q, _, _, _) differ -want +got, %s", tst.name, tst.calendar, diff)
+		}
+	}
+
+	oncall, err := c.TrooperOncall(ctx, "troopers", "CCI-Trooper:", midnight.Add(30*time.Hour))
+	if err != nil {
+		t.Fatalf("TrooperOncall(ctx, _, _, _) failed: %v", err)
+	}
+	if diff := pretty.Compare([]string{"other"}, oncall); diff != "" {
+		t.Errorf("TrooperOncall(ctx, _, _, _) differ -want +got, %s", diff)
+	}
+}
+
+func TestEventToShift(t *testing.T) {
+	cfg := &rotang.Configuration{Config: rotang.Config{Name: "Test Rota"}}
+	shift := rotang.ShiftEntry{
+		Name: "MTV All Day",
+		OnCall: []rotang.ShiftMember{
+			{Email: "oncaller1@oncall.com", ShiftName: "MTV All Day", Role: rotang.Primary},
+			{Email: "oncaller2@oncall.com", ShiftName: "MTV All Day"},
+		},
+		StartTime: midnight,
+		EndTime:   midnight.Add(fullDay),
+		EvtID:     "1@rota",
+	}
+	evt := ShiftToEvent(cfg, &shift)
+	if want := "Test Rota MTV All Day: oncaller1@oncall.com (primary), oncaller2@oncall.com"; evt.Summary != want {
+		t.Errorf("ShiftToEvent(_, _).Summary = %q want: %q", evt.Summary, want)
+	}
+	if diff := pretty.Compare(shift, EventToShift(evt)); diff != "" {
+		t.Errorf("EventToShift(_) differ -want +got, %s", diff)
+	}
+
+	// Roles set by other calendar clients are not rota roles.
+	evt.Attendees[1].Role = "REQ-PARTICIPANT"
+	if got := EventToShift(evt).OnCall[1].Role; got != "" {
+		t.Errorf("EventToShift(_) with ROLE=REQ-PARTICIPANT = %q want: %q", got, "")
+	}
+}
+
+// compare compares the shifts, ignoring the location of the times.
+func compare(t *testing.T, name string, want, got []rotang.ShiftEntry) {
+	t.Helper()
+	for i := range got {
+		got[i].StartTime, got[i].EndTime = got[i].StartTime.UTC(), got[i].EndTime.UTC()
+	}
+	if diff := pretty.Compare(want, got); diff != "" {
+		t.Fatalf("%s: differ -want +got, %s", name, diff)
+	}
+}
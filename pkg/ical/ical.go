@@ -0,0 +1,288 @@
+// Package ical reads and writes events in the iCalendar (RFC 5545) format.
+//
+// Only the parts of the format used by rota are supported; VEVENT components with
+// their UID, SUMMARY, DESCRIPTION, DTSTART, DTEND, DURATION, STATUS, TRANSP,
+// CATEGORIES, ATTENDEE and X-MICROSOFT-CDO-BUSYSTATUS properties.
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event is a VEVENT component.
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	Start       time.Time
+	// End is exclusive.
+	End time.Time
+	// AllDay is set for events using dates instead of date-times.
+	AllDay bool
+	// Status is the STATUS of the event, eg. CONFIRMED or CANCELLED.
+	Status string
+	// Transparent is set for events not blocking time, TRANSP:TRANSPARENT.
+	Transparent bool
+	// BusyStatus is the X-MICROSOFT-CDO-BUSYSTATUS of the event, eg. BUSY or OOF.
+	BusyStatus string
+	Categories []string
+	Attendees  []Attendee
+}
+
+// Attendee is an ATTENDEE of an event.
+type Attendee struct {
+	Email string
+	// Role is the ROLE parameter, eg. REQ-PARTICIPANT.
+	Role string
+}
+
+// Busy is true if the event shows the time as busy or out of office.
+func (e *Event) Busy() bool {
+	if e.Transparent || e.Status == "CANCELLED" {
+		return false
+	}
+	switch e.BusyStatus {
+	case "", "BUSY", "OOF":
+		return true
+	}
+	return false
+}
+
+// property is a content line, eg. `DTSTART;TZID=Europe/Amsterdam:20181225T090000`.
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// Parse reads the events from r. Floating times, and dates, are interpreted in loc.
+func Parse(r io.Reader, loc *time.Location) ([]Event, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		res      []Event
+		evt      *Event
+		duration time.Duration
+		// nested counts the components, eg. VALARM, open inside the event.
+		nested int
+	)
+	for i, l := range lines {
+		if l == "" {
+			continue
+		}
+		p, err := parseLine(l)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+1, err)
+		}
+		switch {
+		case evt != nil && p.name == "BEGIN":
+			nested++
+		case evt != nil && p.name == "END" && nested > 0:
+			nested--
+		case nested > 0:
+			// Properties of components inside the event are ignored.
+		case p.name == "BEGIN" && strings.EqualFold(p.value, "VEVENT"):
+			evt, duration = &Event{}, 0
+		case p.name == "END" && strings.EqualFold(p.value, "VEVENT"):
+			if evt == nil {
+				return nil, fmt.Errorf("line %d: END:VEVENT without BEGIN", i+1)
+			}
+			if evt.Start.IsZero() {
+				return nil, fmt.Errorf("line %d: event %q has no DTSTART", i+1, evt.UID)
+			}
+			if evt.End.IsZero() {
+				switch {
+				case duration != 0:
+					evt.End = evt.Start.Add(duration)
+				case evt.AllDay:
+					evt.End = evt.Start.AddDate(0, 0, 1)
+				default:
+					evt.End = evt.Start
+				}
+			}
+			res = append(res, *evt)
+			evt = nil
+		case evt == nil:
+			// Properties outside of events are ignored.
+		case p.name == "UID":
+			evt.UID = p.value
+		case p.name == "SUMMARY":
+			evt.Summary = unescape(p.value)
+		case p.name == "DESCRIPTION":
+			evt.Description = unescape(p.value)
+		case p.name == "STATUS":
+			evt.Status = strings.ToUpper(p.value)
+		case p.name == "TRANSP":
+			evt.Transparent = strings.EqualFold(p.value, "TRANSPARENT")
+		case p.name == "CATEGORIES":
+			evt.Categories = append(evt.Categories, splitList(p.value)...)
+		case p.name == "ATTENDEE":
+			v := p.value
+			if len(v) > len("mailto:") && strings.EqualFold(v[:len("mailto:")], "mailto:") {
+				v = v[len("mailto:"):]
+			}
+			evt.Attendees = append(evt.Attendees, Attendee{Email: v, Role: p.params["ROLE"]})
+		case p.name == "X-MICROSOFT-CDO-BUSYSTATUS":
+			evt.BusyStatus = strings.ToUpper(p.value)
+		case p.name == "DTSTART":
+			if evt.Start, evt.AllDay, err = parseTime(p, loc); err != nil {
+				return nil, fmt.Errorf("line %d: %v", i+1, err)
+			}
+		case p.name == "DTEND":
+			if evt.End, _, err = parseTime(p, loc); err != nil {
+				return nil, fmt.Errorf("line %d: %v", i+1, err)
+			}
+		case p.name == "DURATION":
+			if duration, err = parseDuration(p.value); err != nil {
+				return nil, fmt.Errorf("line %d: %v", i+1, err)
+			}
+		}
+	}
+	if evt != nil {
+		return nil, fmt.Errorf("event %q not terminated", evt.UID)
+	}
+	return res, nil
+}
+
+// unfold joins folded content lines, continuation lines start with a space or tab.
+func unfold(r io.Reader) ([]string, error) {
+	var res []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		l := strings.TrimRight(scanner.Text(), "\r")
+		if len(l) > 0 && (l[0] == ' ' || l[0] == '\t') && len(res) > 0 {
+			res[len(res)-1] += l[1:]
+			continue
+		}
+		res = append(res, l)
+	}
+	return res, scanner.Err()
+}
+
+func parseLine(l string) (*property, error) {
+	// The value starts after the first colon not inside a quoted parameter value.
+	quoted, colon := false, -1
+	for i, c := range l {
+		if c == '"' {
+			quoted = !quoted
+		}
+		if c == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return nil, fmt.Errorf("no value in: %q", l)
+	}
+	parts := strings.Split(l[:colon], ";")
+	p := &property{
+		name:   strings.ToUpper(parts[0]),
+		params: make(map[string]string),
+		value:  l[colon+1:],
+	}
+	for _, param := range parts[1:] {
+		kv := strings.SplitN(param, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("malformed parameter: %q", param)
+		}
+		p.params[strings.ToUpper(kv[0])] = strings.Trim(kv[1], `"`)
+	}
+	return p, nil
+}
+
+const (
+	dateFormat     = "20060102"
+	dateTimeFormat = "20060102T150405"
+)
+
+// parseTime parses the date or date-time value of p. Times with a TZID not known to the
+// time package, eg. the Windows names used by Outlook, are interpreted in loc.
+func parseTime(p *property, loc *time.Location) (time.Time, bool, error) {
+	if tzid, ok := p.params["TZID"]; ok {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+	if strings.EqualFold(p.params["VALUE"], "DATE") || len(p.value) == len(dateFormat) {
+		t, err := time.ParseInLocation(dateFormat, p.value, loc)
+		return t, true, err
+	}
+	if strings.HasSuffix(p.value, "Z") {
+		t, err := time.Parse(dateTimeFormat, strings.TrimSuffix(p.value, "Z"))
+		return t, false, err
+	}
+	t, err := time.ParseInLocation(dateTimeFormat, p.value, loc)
+	return t, false, err
+}
+
+// parseDuration parses durations like `P1D`, `PT8H` or `P1W`.
+func parseDuration(s string) (time.Duration, error) {
+	orig := s
+	neg := false
+	switch {
+	case strings.HasPrefix(s, "-"):
+		neg, s = true, s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+	if !strings.HasPrefix(s, "P") {
+		return 0, fmt.Errorf("malformed duration: %q", orig)
+	}
+	s = s[1:]
+	var res time.Duration
+	inTime := false
+	for len(s) > 0 {
+		if s[0] == 'T' {
+			inTime, s = true, s[1:]
+			continue
+		}
+		i := strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+		if i <= 0 {
+			return 0, fmt.Errorf("malformed duration: %q", orig)
+		}
+		n, err := strconv.Atoi(s[:i])
+		if err != nil {
+			return 0, err
+		}
+		unit := map[bool]map[byte]time.Duration{
+			false: {'W': 7 * 24 * time.Hour, 'D': 24 * time.Hour},
+			true:  {'H': time.Hour, 'M': time.Minute, 'S': time.Second},
+		}[inTime][s[i]]
+		if unit == 0 {
+			return 0, fmt.Errorf("malformed duration: %q", orig)
+		}
+		res += time.Duration(n) * unit
+		s = s[i+1:]
+	}
+	if neg {
+		res = -res
+	}
+	return res, nil
+}
+
+// splitList splits a list of text values on the unescaped commas.
+func splitList(s string) []string {
+	var res []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case ',':
+			res = append(res, unescape(s[start:i]))
+			start = i + 1
+		}
+	}
+	return append(res, unescape(s[start:]))
+}
+
+func unescape(s string) string {
+	return strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(s)
+}
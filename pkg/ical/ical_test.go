@@ -0,0 +1,268 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestParse(t *testing.T) {
+	amsTime, err := time.LoadLocation("Europe/Amsterdam")
+	if err != nil {
+		t.Fatalf("time.LoadLocation() failed: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		fail bool
+		ics  string
+		want []Event
+	}{{
+		name: "All day events",
+		ics: `BEGIN:VCALENDAR
+VERSION:2.0
+PRODID:-//Test//EN
+BEGIN:VEVENT
+UID:xmas@example.com
+DTSTART;VALUE=DATE:20181225
+DTEND;VALUE=DATE:20181227
+SUMMARY:Kerstmis
+END:VEVENT
+BEGIN:VEVENT
+UID:koningsdag@example.com
+DTSTART;VALUE=DATE:20180427
+SUMMARY:Koningsdag
+END:VEVENT
+END:VCALENDAR
+`,
+		want: []Event{
+			{
+				UID:     "xmas@example.com",
+				Summary: "Kerstmis",
+				Start:   time.Date(2018, 12, 25, 0, 0, 0, 0, time.UTC),
+				End:     time.Date(2018, 12, 27, 0, 0, 0, 0, time.UTC),
+				AllDay:  true,
+			}, {
+				UID:     "koningsdag@example.com",
+				Summary: "Koningsdag",
+				Start:   time.Date(2018, 4, 27, 0, 0, 0, 0, time.UTC),
+				End:     time.Date(2018, 4, 28, 0, 0, 0, 0, time.UTC),
+				AllDay:  true,
+			},
+		},
+	}, {
+		name: "Date times",
+		ics: "BEGIN:VCALENDAR\r\n" +
+			"BEGIN:VEVENT\r\n" +
+			"UID:1\r\n" +
+			"DTSTART;TZID=Europe/Amsterdam:20181224T090000\r\n" +
+			"DURATION:PT8H\r\n" +
+			"SUMMARY:Folded\r\n" +
+			"  summary\\, escaped\r\n" +
+			"DESCRIPTION:Line 1\\nLine 2\r\n" +
+			"END:VEVENT\r\n" +
+			"BEGIN:VEVENT\r\n" +
+			"UID:2\r\n" +
+			"DTSTART:20181224T090000Z\r\n" +
+			"DTEND:20181224T170000Z\r\n" +
+			"END:VEVENT\r\n" +
+			"END:VCALENDAR\r\n",
+		want: []Event{
+			{
+				UID:         "1",
+				Summary:     "Folded summary, escaped",
+				Description: "Line 1\nLine 2",
+				Start:       time.Date(2018, 12, 24, 9, 0, 0, 0, amsTime),
+				End:         time.Date(2018, 12, 24, 17, 0, 0, 0, amsTime),
+			}, {
+				UID:   "2",
+				Start: time.Date(2018, 12, 24, 9, 0, 0, 0, time.UTC),
+				End:   time.Date(2018, 12, 24, 17, 0, 0, 0, time.UTC),
+			},
+		},
+	}, {
+		name: "Busy status",
+		ics: `BEGIN:VEVENT
+UID:1
+DTSTART:20181224T090000Z
+DTEND:20181224T170000Z
+STATUS:cancelled
+TRANSP:TRANSPARENT
+X-MICROSOFT-CDO-BUSYSTATUS:oof
+END:VEVENT
+`,
+		want: []Event{
+			{
+				UID:         "1",
+				Start:       time.Date(2018, 12, 24, 9, 0, 0, 0, time.UTC),
+				End:         time.Date(2018, 12, 24, 17, 0, 0, 0, time.UTC),
+				Status:      "CANCELLED",
+				Transparent: true,
+				BusyStatus:  "OOF",
+			},
+		},
+	}, {
+		name: "Categories and attendees",
+		ics: `BEGIN:VEVENT
+UID:1
+DTSTART:20181224T090000Z
+DTEND:20181224T170000Z
+CATEGORIES:MTV All Day,Escaped\, comma
+CATEGORIES:Oncall
+ATTENDEE;CN=Test:MAILTO:test@example.com
+ATTENDEE;ROLE=CHAIR:mailto:other@example.com
+END:VEVENT
+`,
+		want: []Event{
+			{
+				UID:        "1",
+				Start:      time.Date(2018, 12, 24, 9, 0, 0, 0, time.UTC),
+				End:        time.Date(2018, 12, 24, 17, 0, 0, 0, time.UTC),
+				Categories: []string{"MTV All Day", "Escaped, comma", "Oncall"},
+				Attendees:  []Attendee{{Email: "test@example.com"}, {Email: "other@example.com", Role: "CHAIR"}},
+			},
+		},
+	}, {
+		name: "Alarms",
+		ics: `BEGIN:VEVENT
+UID:1
+DTSTART:20181224T090000Z
+DESCRIPTION:Event
+BEGIN:VALARM
+ACTION:EMAIL
+TRIGGER:-PT15M
+DURATION:PT5M
+DESCRIPTION:Reminder
+ATTENDEE:mailto:alarm@example.com
+END:VALARM
+END:VEVENT
+`,
+		want: []Event{
+			{
+				UID:         "1",
+				Description: "Event",
+				Start:       time.Date(2018, 12, 24, 9, 0, 0, 0, time.UTC),
+				End:         time.Date(2018, 12, 24, 9, 0, 0, 0, time.UTC),
+			},
+		},
+	}, {
+		name: "Unknown TZID",
+		ics: `BEGIN:VEVENT
+UID:1
+DTSTART;TZID=W. Europe Standard Time:20181224T090000
+DTEND;TZID=W. Europe Standard Time:20181224T170000
+END:VEVENT
+`,
+		want: []Event{
+			{
+				UID:   "1",
+				Start: time.Date(2018, 12, 24, 9, 0, 0, 0, time.UTC),
+				End:   time.Date(2018, 12, 24, 17, 0, 0, 0, time.UTC),
+			},
+		},
+	}, {
+		name: "Missing DTSTART",
+		fail: true,
+		ics: `BEGIN:VEVENT
+UID:1
+END:VEVENT
+`,
+	}, {
+		name: "Not terminated",
+		fail: true,
+		ics: `BEGIN:VEVENT
+DTSTART;VALUE=DATE:20181225
+`,
+	}, {
+		name: "Bad date",
+		fail: true,
+		ics: `BEGIN:VEVENT
+DTSTART;VALUE=DATE:2018-12-25
+END:VEVENT
+`,
+	}, {
+		name: "Bad duration",
+		fail: true,
+		ics: `BEGIN:VEVENT
+DTSTART:20181224T090000Z
+DURATION:8H
+END:VEVENT
+`,
+	},
+	}
+
+	for _, tst := range tests {
+		got, err := Parse(strings.NewReader(tst.ics), time.UTC)
+		if got, want := (err != nil), tst.fail; got != want {
+			t.Errorf("%s: Parse(_) = %t want: %t, err: %v", tst.name, got, want, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if len(got) != len(tst.want) {
+			t.Errorf("%s: Parse(_) = %d events want: %d", tst.name, len(got), len(tst.want))
+			continue
+		}
+		for i := range got {
+			if !got[i].Start.Equal(tst.want[i].Start) || !got[i].End.Equal(tst.want[i].End) {
+				t.Errorf("%s: Parse(_) event %d = %v - %v want: %v - %v", tst.name, i, got[i].Start, got[i].End, tst.want[i].Start, tst.want[i].End)
+			}
+			got[i].Start, got[i].End = tst.want[i].Start, tst.want[i].End
+		}
+		if diff := pretty.Compare(tst.want, got); diff != "" {
+			t.Errorf("%s: Parse(_) differ -want +got, %s", tst.name, diff)
+		}
+	}
+}
+
+func TestBusy(t *testing.T) {
+	tests := []struct {
+		name string
+		evt  Event
+		busy bool
+	}{
+		{name: "Default", evt: Event{}, busy: true},
+		{name: "Out of office", evt: Event{BusyStatus: "OOF"}, busy: true},
+		{name: "Transparent", evt: Event{Transparent: true, BusyStatus: "OOF"}},
+		{name: "Cancelled", evt: Event{Status: "CANCELLED"}},
+		{name: "Free", evt: Event{BusyStatus: "FREE"}},
+		{name: "Tentative", evt: Event{BusyStatus: "TENTATIVE"}},
+	}
+
+	for _, tst := range tests {
+		if got := tst.evt.Busy(); got != tst.busy {
+			t.Errorf("%s: Busy() = %t want: %t", tst.name, got, tst.busy)
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in   string
+		fail bool
+		want time.Duration
+	}{
+		{in: "P1D", want: 24 * time.Hour},
+		{in: "PT8H30M", want: 8*time.Hour + 30*time.Minute},
+		{in: "P1W", want: 7 * 24 * time.Hour},
+		{in: "-PT15M", want: -15 * time.Minute},
+		{in: "P1DT1S", want: 24*time.Hour + time.Second},
+		{in: "PT1D", fail: true},
+		{in: "1D", fail: true},
+		{in: "P", want: 0},
+	}
+
+	for _, tst := range tests {
+		got, err := parseDuration(tst.in)
+		if (err != nil) != tst.fail {
+			t.Errorf("parseDuration(%q) = %v, want fail: %t", tst.in, err, tst.fail)
+			continue
+		}
+		if got != tst.want {
+			t.Errorf("parseDuration(%q) = %v want: %v", tst.in, got, tst.want)
+		}
+	}
+}
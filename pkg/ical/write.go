@@ -0,0 +1,178 @@
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Calendar is a VCALENDAR object.
+type Calendar struct {
+	// ProdID identifies the product creating the calendar.
+	ProdID string
+	// Name is shown by most clients as the name of the calendar, X-WR-CALNAME.
+	Name string
+	// Location is the time zone the event times are written in, with a VTIMEZONE
+	// component describing it. Times are written in UTC when not set.
+	Location *time.Location
+	// Stamp is used as the DTSTAMP of the events.
+	Stamp  time.Time
+	Events []Event
+}
+
+// maxLine is the maximum length of a content line in octets, longer lines are folded.
+const maxLine = 75
+
+// Write writes the calendar to w as iCalendar data.
+func Write(w io.Writer, cal *Calendar) error {
+	bw := bufio.NewWriter(w)
+	loc := cal.Location
+	if loc == nil || loc.String() == "UTC" {
+		loc = time.UTC
+	}
+	writeLine(bw, "BEGIN:VCALENDAR")
+	writeLine(bw, "VERSION:2.0")
+	writeLine(bw, "PRODID:"+escape(cal.ProdID))
+	writeLine(bw, "CALSCALE:GREGORIAN")
+	if cal.Name != "" {
+		writeLine(bw, "X-WR-CALNAME:"+escape(cal.Name))
+	}
+	if loc != time.UTC && len(cal.Events) > 0 {
+		writeLine(bw, "X-WR-TIMEZONE:"+loc.String())
+		from, to := cal.Events[0].Start, cal.Events[0].End
+		for _, e := range cal.Events {
+			if e.Start.Before(from) {
+				from = e.Start
+			}
+			if e.End.After(to) {
+				to = e.End
+			}
+		}
+		writeTimezone(bw, loc, from, to)
+	}
+	for _, e := range cal.Events {
+		writeLine(bw, "BEGIN:VEVENT")
+		writeLine(bw, "UID:"+e.UID)
+		writeLine(bw, "DTSTAMP:"+cal.Stamp.UTC().Format(dateTimeFormat)+"Z")
+		writeLine(bw, formatTime("DTSTART", e.Start, e.AllDay, loc))
+		writeLine(bw, formatTime("DTEND", e.End, e.AllDay, loc))
+		if e.Summary != "" {
+			writeLine(bw, "SUMMARY:"+escape(e.Summary))
+		}
+		if e.Description != "" {
+			writeLine(bw, "DESCRIPTION:"+escape(e.Description))
+		}
+		if e.Status != "" {
+			writeLine(bw, "STATUS:"+e.Status)
+		}
+		if e.Transparent {
+			writeLine(bw, "TRANSP:TRANSPARENT")
+		}
+		if e.BusyStatus != "" {
+			writeLine(bw, "X-MICROSOFT-CDO-BUSYSTATUS:"+e.BusyStatus)
+		}
+		if len(e.Categories) > 0 {
+			var categories []string
+			for _, c := range e.Categories {
+				categories = append(categories, escape(c))
+			}
+			writeLine(bw, "CATEGORIES:"+strings.Join(categories, ","))
+		}
+		for _, a := range e.Attendees {
+			if a.Role != "" {
+				writeLine(bw, "ATTENDEE;ROLE="+paramValue(a.Role)+":mailto:"+a.Email)
+				continue
+			}
+			writeLine(bw, "ATTENDEE:mailto:"+a.Email)
+		}
+		writeLine(bw, "END:VEVENT")
+	}
+	writeLine(bw, "END:VCALENDAR")
+	return bw.Flush()
+}
+
+// writeTimezone writes a VTIMEZONE component for loc, with the transitions between from and to.
+func writeTimezone(w *bufio.Writer, loc *time.Location, from, to time.Time) {
+	writeLine(w, "BEGIN:VTIMEZONE")
+	writeLine(w, "TZID:"+loc.String())
+	for t := from; ; {
+		name, offset := t.In(loc).Zone()
+		start, end := t.In(loc).ZoneBounds()
+		prevOffset := offset
+		if !start.IsZero() {
+			_, prevOffset = start.Add(-time.Second).In(loc).Zone()
+		}
+		kind := "STANDARD"
+		if t.In(loc).IsDST() {
+			kind = "DAYLIGHT"
+		}
+		writeLine(w, "BEGIN:"+kind)
+		// The onset is in the local time in effect before it.
+		onset := "19700101T000000"
+		if !start.IsZero() {
+			onset = start.In(time.FixedZone("", prevOffset)).Format(dateTimeFormat)
+		}
+		writeLine(w, "DTSTART:"+onset)
+		writeLine(w, "TZOFFSETFROM:"+formatOffset(prevOffset))
+		writeLine(w, "TZOFFSETTO:"+formatOffset(offset))
+		writeLine(w, "TZNAME:"+name)
+		writeLine(w, "END:"+kind)
+		if end.IsZero() || !end.Before(to) {
+			break
+		}
+		t = end
+	}
+	writeLine(w, "END:VTIMEZONE")
+}
+
+func formatTime(name string, t time.Time, allDay bool, loc *time.Location) string {
+	switch {
+	case allDay:
+		return name + ";VALUE=DATE:" + t.In(loc).Format(dateFormat)
+	case loc == time.UTC:
+		return name + ":" + t.UTC().Format(dateTimeFormat) + "Z"
+	}
+	return name + ";TZID=" + loc.String() + ":" + t.In(loc).Format(dateTimeFormat)
+}
+
+// formatOffset formats an offset in seconds east of UTC like `+0100`.
+func formatOffset(offset int) string {
+	sign := "+"
+	if offset < 0 {
+		sign, offset = "-", -offset
+	}
+	return fmt.Sprintf("%s%02d%02d", sign, offset/3600, offset%3600/60)
+}
+
+// writeLine writes a content line, folding it when longer than maxLine octets.
+// Errors are returned by the Flush of w.
+func writeLine(w *bufio.Writer, l string) {
+	limit := maxLine
+	for len(l) > limit {
+		i := limit
+		// Don't split up multi-octet characters.
+		for i > 0 && !utf8.RuneStart(l[i]) {
+			i--
+		}
+		w.WriteString(l[:i] + "\r\n ")
+		l = l[i:]
+		// Continuation lines start with a space.
+		limit = maxLine - 1
+	}
+	w.WriteString(l + "\r\n")
+}
+
+func escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// paramValue quotes parameter values containing a colon, semicolon or comma.
+func paramValue(s string) string {
+	if strings.ContainsAny(s, ":;,") {
+		return `"` + strings.ReplaceAll(s, `"`, "") + `"`
+	}
+	return s
+}
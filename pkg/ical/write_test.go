@@ -0,0 +1,127 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestWrite(t *testing.T) {
+	amsTime, err := time.LoadLocation("Europe/Amsterdam")
+	if err != nil {
+		t.Fatalf("time.LoadLocation() failed: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		cal  *Calendar
+		// contains are lines, after unfolding, the output should have.
+		contains []string
+	}{{
+		name: "UTC",
+		cal: &Calendar{
+			ProdID: "-//Test//EN",
+			Name:   "Test Rota",
+			Stamp:  time.Date(2018, 12, 1, 0, 0, 0, 0, time.UTC),
+			Events: []Event{
+				{
+					UID:         "1@test",
+					Summary:     "Oncall, primary",
+					Description: "Line 1\nLine 2; " + strings.Repeat("long ", 40),
+					Start:       time.Date(2018, 12, 24, 9, 0, 0, 0, time.UTC),
+					End:         time.Date(2018, 12, 24, 17, 0, 0, 0, time.UTC),
+					Categories:  []string{"MTV All Day", "Escaped, comma"},
+					Attendees:   []Attendee{{Email: "test@example.com"}, {Email: "other@example.com", Role: "primary"}},
+				},
+			},
+		},
+		contains: []string{
+			"X-WR-CALNAME:Test Rota",
+			"DTSTAMP:20181201T000000Z",
+			"DTSTART:20181224T090000Z",
+			`SUMMARY:Oncall\, primary`,
+			`CATEGORIES:MTV All Day,Escaped\, comma`,
+			"ATTENDEE:mailto:test@example.com",
+			"ATTENDEE;ROLE=primary:mailto:other@example.com",
+		},
+	}, {
+		name: "Time zone",
+		cal: &Calendar{
+			ProdID:   "-//Test//EN",
+			Location: amsTime,
+			Events: []Event{
+				{
+					UID:   "1@test",
+					Start: time.Date(2018, 3, 20, 9, 0, 0, 0, amsTime),
+					End:   time.Date(2018, 3, 20, 17, 0, 0, 0, amsTime),
+				}, {
+					UID:    "2@test",
+					Start:  time.Date(2018, 4, 27, 0, 0, 0, 0, amsTime),
+					End:    time.Date(2018, 4, 28, 0, 0, 0, 0, amsTime),
+					AllDay: true,
+				},
+			},
+		},
+		contains: []string{
+			"TZID:Europe/Amsterdam",
+			"BEGIN:STANDARD",
+			"DTSTART:20171029T030000",
+			"TZOFFSETFROM:+0200",
+			"TZOFFSETTO:+0100",
+			"BEGIN:DAYLIGHT",
+			"DTSTART:20180325T020000",
+			"TZNAME:CEST",
+			"DTSTART;TZID=Europe/Amsterdam:20180320T090000",
+			"DTSTART;VALUE=DATE:20180427",
+		},
+	},
+	}
+
+	for _, tst := range tests {
+		var buf bytes.Buffer
+		if err := Write(&buf, tst.cal); err != nil {
+			t.Errorf("%s: Write(_) failed: %v", tst.name, err)
+			continue
+		}
+		lines, err := unfold(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatalf("%s: unfold(_) failed: %v", tst.name, err)
+		}
+		for _, l := range strings.Split(buf.String(), "\r\n") {
+			if len(l) > maxLine {
+				t.Errorf("%s: Write(_) line: %q longer than %d octets", tst.name, l, maxLine)
+			}
+		}
+		has := make(map[string]bool)
+		for _, l := range lines {
+			has[l] = true
+		}
+		for _, want := range tst.contains {
+			if !has[want] {
+				t.Errorf("%s: Write(_) missing line: %q, got:\n%s", tst.name, want, buf.String())
+			}
+		}
+
+		loc := time.UTC
+		if tst.cal.Location != nil {
+			loc = tst.cal.Location
+		}
+		got, err := Parse(&buf, loc)
+		if err != nil {
+			t.Errorf("%s: Parse(Write(_)) failed: %v", tst.name, err)
+			continue
+		}
+		for i := range got {
+			if !got[i].Start.Equal(tst.cal.Events[i].Start) || !got[i].End.Equal(tst.cal.Events[i].End) {
+				t.Errorf("%s: Parse(Write(_)) event %d = %v - %v want: %v - %v", tst.name, i, got[i].Start, got[i].End, tst.cal.Events[i].Start, tst.cal.Events[i].End)
+			}
+			got[i].Start, got[i].End = tst.cal.Events[i].Start, tst.cal.Events[i].End
+		}
+		if diff := pretty.Compare(tst.cal.Events, got); diff != "" {
+			t.Errorf("%s: Parse(Write(_)) differ -want +got, %s", tst.name, diff)
+		}
+	}
+}
@@ -0,0 +1,675 @@
+// Package bolt implements a persistent store for rotations, members and shifts.
+// All state is kept in a single local file using an embedded bbolt database.
+package bolt
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"sort"
+	"time"
+
+	rotang "github.com/miekg/rota"
+	"go.etcd.io/bbolt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Store is a bbolt backed implementation of the rotang storer interfaces.
+type Store struct {
+	db *bbolt.DB
+}
+
+var (
+	_ rotang.MemberStorer = &Store{}
+	_ rotang.ShiftStorer  = &Store{}
+	_ rotang.ConfigStorer = &Store{}
+)
+
+// Top level buckets.
+var (
+	metaBucket   = []byte("meta")
+	memberBucket = []byte("members")
+	rotaBucket   = []byte("rotas")
+	shiftBucket  = []byte("shifts")
+)
+
+// Every rota has a bucket in shiftBucket holding the shifts keyed by StartTime,
+// an index keyed by EndTime+StartTime and the longest shift stored.
+var (
+	startBucket = []byte("start")
+	endBucket   = []byte("end")
+	spanKey     = []byte("span")
+	versionKey  = []byte("version")
+)
+
+// New opens, or creates, the database in the file at path and runs any pending schema migrations.
+func New(path string) (*Store, error) {
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the underlying database file.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// member is the stored representation of a rotang.Member.
+// time.Location does not survive JSON encoding so the TZ name is stored separately.
+type member struct {
+	Member rotang.Member
+	TZ     string
+}
+
+// config is the stored representation of a rotang.Configuration.
+type config struct {
+	Configuration rotang.Configuration
+	TZ            string
+}
+
+// CreateMember adds a new member to the store.
+func (s *Store) CreateMember(ctx context.Context, m *rotang.Member) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if m == nil {
+		return status.Errorf(codes.InvalidArgument, "member must be set")
+	}
+	if m.Email == "" {
+		return status.Errorf(codes.InvalidArgument, "member Email must be set")
+	}
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(memberBucket)
+		if b.Get([]byte(m.Email)) != nil {
+			return status.Errorf(codes.AlreadyExists, "member: %q already exists", m.Email)
+		}
+		return putMember(b, m)
+	})
+}
+
+// Member fetches the member with the provided email.
+func (s *Store) Member(ctx context.Context, email string) (*rotang.Member, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var res *rotang.Member
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		v := tx.Bucket(memberBucket).Get([]byte(email))
+		if v == nil {
+			return status.Errorf(codes.NotFound, "member: %q not found", email)
+		}
+		m, err := decodeMember(v)
+		res = m
+		return err
+	})
+	return res, err
+}
+
+// AllMembers returns all members sorted by Email.
+func (s *Store) AllMembers(ctx context.Context) ([]rotang.Member, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var res []rotang.Member
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(memberBucket).ForEach(func(_, v []byte) error {
+			m, err := decodeMember(v)
+			if err != nil {
+				return err
+			}
+			res = append(res, *m)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(res) == 0 {
+		return nil, status.Errorf(codes.NotFound, "no members found")
+	}
+	return res, nil
+}
+
+// DeleteMember removes the member from the store.
+func (s *Store) DeleteMember(ctx context.Context, email string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(memberBucket)
+		if b.Get([]byte(email)) == nil {
+			return status.Errorf(codes.NotFound, "member: %q not found", email)
+		}
+		return b.Delete([]byte(email))
+	})
+}
+
+// UpdateMember replaces an existing member.
+func (s *Store) UpdateMember(ctx context.Context, m *rotang.Member) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if m == nil {
+		return status.Errorf(codes.InvalidArgument, "member must be set")
+	}
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(memberBucket)
+		if b.Get([]byte(m.Email)) == nil {
+			return status.Errorf(codes.NotFound, "member: %q not found", m.Email)
+		}
+		return putMember(b, m)
+	})
+}
+
+// CreateRotaConfig stores a new rota configuration.
+// All members of the rotation must already exist in the store.
+func (s *Store) CreateRotaConfig(ctx context.Context, rotation *rotang.Configuration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if rotation == nil {
+		return status.Errorf(codes.InvalidArgument, "rota configuration must be set")
+	}
+	if rotation.Config.Name == "" {
+		return status.Errorf(codes.InvalidArgument, "rota Name must be set")
+	}
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		if tx.Bucket(rotaBucket).Get([]byte(rotation.Config.Name)) != nil {
+			return status.Errorf(codes.AlreadyExists, "rota: %q already exists", rotation.Config.Name)
+		}
+		return putConfig(tx, rotation)
+	})
+}
+
+// UpdateRotaConfig replaces an existing rota configuration.
+func (s *Store) UpdateRotaConfig(ctx context.Context, rotation *rotang.Configuration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if rotation == nil {
+		return status.Errorf(codes.InvalidArgument, "rota configuration must be set")
+	}
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		if tx.Bucket(rotaBucket).Get([]byte(rotation.Config.Name)) == nil {
+			return status.Errorf(codes.NotFound, "rota: %q not found", rotation.Config.Name)
+		}
+		return putConfig(tx, rotation)
+	})
+}
+
+// RotaConfig returns the named rota configuration.
+// An empty name returns all configurations sorted by name.
+func (s *Store) RotaConfig(ctx context.Context, name string) ([]*rotang.Configuration, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var res []*rotang.Configuration
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(rotaBucket)
+		if name != "" {
+			v := b.Get([]byte(name))
+			if v == nil {
+				return status.Errorf(codes.NotFound, "rota: %q not found", name)
+			}
+			cfg, err := decodeConfig(v)
+			res = append(res, cfg)
+			return err
+		}
+		return b.ForEach(func(_, v []byte) error {
+			cfg, err := decodeConfig(v)
+			if err != nil {
+				return err
+			}
+			res = append(res, cfg)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(res) == 0 {
+		return nil, status.Errorf(codes.NotFound, "no rotas found")
+	}
+	return res, nil
+}
+
+// DeleteRotaConfig removes the rota configuration.
+// Shifts stored for the rota are kept, use DeleteAllShifts to remove them.
+func (s *Store) DeleteRotaConfig(ctx context.Context, name string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(rotaBucket)
+		if b.Get([]byte(name)) == nil {
+			return status.Errorf(codes.NotFound, "rota: %q not found", name)
+		}
+		return b.Delete([]byte(name))
+	})
+}
+
+// AddRotaMember adds a member to the rotation.
+func (s *Store) AddRotaMember(ctx context.Context, rota string, m *rotang.ShiftMember) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.updateConfig(rota, func(cfg *rotang.Configuration) error {
+		for _, sm := range cfg.Members {
+			if sm.Email == m.Email {
+				return status.Errorf(codes.AlreadyExists, "member: %q already in rota: %q", m.Email, rota)
+			}
+		}
+		cfg.Members = append(cfg.Members, *m)
+		return nil
+	})
+}
+
+// DeleteRotaMember removes a member from the rotation.
+func (s *Store) DeleteRotaMember(ctx context.Context, rota, email string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.updateConfig(rota, func(cfg *rotang.Configuration) error {
+		for i, sm := range cfg.Members {
+			if sm.Email == email {
+				cfg.Members = append(cfg.Members[:i], cfg.Members[i+1:]...)
+				return nil
+			}
+		}
+		return status.Errorf(codes.NotFound, "member: %q not in rota: %q", email, rota)
+	})
+}
+
+// EnableRota enables the rotation.
+func (s *Store) EnableRota(ctx context.Context, rota string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.updateConfig(rota, func(cfg *rotang.Configuration) error {
+		cfg.Config.Enabled = true
+		return nil
+	})
+}
+
+// DisableRota disables the rotation.
+func (s *Store) DisableRota(ctx context.Context, rota string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.updateConfig(rota, func(cfg *rotang.Configuration) error {
+		cfg.Config.Enabled = false
+		return nil
+	})
+}
+
+// RotaEnabled returns the enabled state of the rotation.
+func (s *Store) RotaEnabled(ctx context.Context, rota string) (bool, error) {
+	cfgs, err := s.RotaConfig(ctx, rota)
+	if err != nil {
+		return false, err
+	}
+	return cfgs[0].Config.Enabled, nil
+}
+
+// MemberOf returns the names of the rotations the member is part of, sorted by name.
+func (s *Store) MemberOf(ctx context.Context, email string) ([]string, error) {
+	cfgs, err := s.RotaConfig(ctx, "")
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var res []string
+	for _, cfg := range cfgs {
+		for _, m := range cfg.Members {
+			if m.Email == email {
+				res = append(res, cfg.Config.Name)
+				break
+			}
+		}
+	}
+	return res, nil
+}
+
+// updateConfig runs f on the stored configuration and writes back the result.
+func (s *Store) updateConfig(rota string, f func(cfg *rotang.Configuration) error) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		v := tx.Bucket(rotaBucket).Get([]byte(rota))
+		if v == nil {
+			return status.Errorf(codes.NotFound, "rota: %q not found", rota)
+		}
+		cfg, err := decodeConfig(v)
+		if err != nil {
+			return err
+		}
+		if err := f(cfg); err != nil {
+			return err
+		}
+		return putConfig(tx, cfg)
+	})
+}
+
+// AddShifts adds shifts to the rota.
+func (s *Store) AddShifts(ctx context.Context, rota string, entries []rotang.ShiftEntry) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		rb, err := tx.Bucket(shiftBucket).CreateBucketIfNotExists([]byte(rota))
+		if err != nil {
+			return err
+		}
+		sb, eb := rb.Bucket(startBucket), rb.Bucket(endBucket)
+		if sb == nil {
+			if sb, err = rb.CreateBucket(startBucket); err != nil {
+				return err
+			}
+			if eb, err = rb.CreateBucket(endBucket); err != nil {
+				return err
+			}
+		}
+		span := decodeSpan(rb.Get(spanKey))
+		for _, e := range entries {
+			if sb.Get(timeKey(e.StartTime)) != nil {
+				return status.Errorf(codes.AlreadyExists, "shift starting: %v already exists for rota: %q", e.StartTime, rota)
+			}
+			if err := putShift(sb, eb, &e); err != nil {
+				return err
+			}
+			if d := e.EndTime.Sub(e.StartTime); d > span {
+				span = d
+			}
+		}
+		return rb.Put(spanKey, encodeSpan(span))
+	})
+}
+
+// AllShifts returns all shifts for the rota sorted by StartTime.
+func (s *Store) AllShifts(ctx context.Context, rota string) ([]rotang.ShiftEntry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var res []rotang.ShiftEntry
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		sb, _ := shiftBuckets(tx, rota)
+		if sb == nil {
+			return nil
+		}
+		return sb.ForEach(func(_, v []byte) error {
+			var e rotang.ShiftEntry
+			if err := json.Unmarshal(v, &e); err != nil {
+				return err
+			}
+			res = append(res, e)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(res) == 0 {
+		return nil, status.Errorf(codes.NotFound, "no shifts found for rota: %q", rota)
+	}
+	return res, nil
+}
+
+// ShiftsFromTo returns the shifts ending after from and starting before to, sorted by StartTime.
+// A zero to returns all shifts ending after from.
+//
+// The EndTime index is used to find the first shift ending after from, the longest stored shift
+// bounds how far the index needs to be scanned before no more shifts can start before to.
+func (s *Store) ShiftsFromTo(ctx context.Context, rota string, from, to time.Time) ([]rotang.ShiftEntry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var res []rotang.ShiftEntry
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		sb, eb := shiftBuckets(tx, rota)
+		if sb == nil {
+			return nil
+		}
+		var stop []byte
+		if !to.IsZero() {
+			stop = timeKey(to.Add(decodeSpan(tx.Bucket(shiftBucket).Bucket([]byte(rota)).Get(spanKey))))
+		}
+		// Seek to the first shift ending after from.
+		c := eb.Cursor()
+		for k, _ := c.Seek(timeKey(from.Add(1))); k != nil; k, _ = c.Next() {
+			if stop != nil && bytes.Compare(k[:8], stop) > 0 {
+				break
+			}
+			if stop != nil && bytes.Compare(k[8:], timeKey(to)) >= 0 {
+				continue
+			}
+			var e rotang.ShiftEntry
+			if err := json.Unmarshal(sb.Get(k[8:]), &e); err != nil {
+				return err
+			}
+			res = append(res, e)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(res) == 0 {
+		return nil, status.Errorf(codes.NotFound, "no shifts found for rota: %q", rota)
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].StartTime.Before(res[j].StartTime)
+	})
+	return res, nil
+}
+
+// Shift returns the shift starting at start.
+func (s *Store) Shift(ctx context.Context, rota string, start time.Time) (*rotang.ShiftEntry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var res rotang.ShiftEntry
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		sb, _ := shiftBuckets(tx, rota)
+		if sb == nil {
+			return status.Errorf(codes.NotFound, "shift starting: %v not found for rota: %q", start, rota)
+		}
+		v := sb.Get(timeKey(start))
+		if v == nil {
+			return status.Errorf(codes.NotFound, "shift starting: %v not found for rota: %q", start, rota)
+		}
+		return json.Unmarshal(v, &res)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// Oncall returns the shift in progress at the at time.
+func (s *Store) Oncall(ctx context.Context, at time.Time, rota string) (*rotang.ShiftEntry, error) {
+	shifts, err := s.ShiftsFromTo(ctx, rota, at, at.Add(1))
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, status.Errorf(codes.NotFound, "nobody oncall for rota: %q at: %v", rota, at)
+		}
+		return nil, err
+	}
+	return &shifts[0], nil
+}
+
+// DeleteAllShifts removes all shifts for the rota.
+func (s *Store) DeleteAllShifts(ctx context.Context, rota string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		err := tx.Bucket(shiftBucket).DeleteBucket([]byte(rota))
+		if err == bbolt.ErrBucketNotFound {
+			return nil
+		}
+		return err
+	})
+}
+
+// DeleteShift removes the shift starting at start.
+func (s *Store) DeleteShift(ctx context.Context, rota string, start time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		sb, eb := shiftBuckets(tx, rota)
+		if sb == nil {
+			return status.Errorf(codes.NotFound, "shift starting: %v not found for rota: %q", start, rota)
+		}
+		return deleteShift(sb, eb, rota, start)
+	})
+}
+
+// UpdateShift replaces the shift with the same StartTime.
+func (s *Store) UpdateShift(ctx context.Context, rota string, shift *rotang.ShiftEntry) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		sb, eb := shiftBuckets(tx, rota)
+		if sb == nil {
+			return status.Errorf(codes.NotFound, "shift starting: %v not found for rota: %q", shift.StartTime, rota)
+		}
+		if err := deleteShift(sb, eb, rota, shift.StartTime); err != nil {
+			return err
+		}
+		if err := putShift(sb, eb, shift); err != nil {
+			return err
+		}
+		rb := tx.Bucket(shiftBucket).Bucket([]byte(rota))
+		if d := shift.EndTime.Sub(shift.StartTime); d > decodeSpan(rb.Get(spanKey)) {
+			return rb.Put(spanKey, encodeSpan(d))
+		}
+		return nil
+	})
+}
+
+// shiftBuckets returns the StartTime and EndTime buckets for the rota, nil if no shifts were ever added.
+func shiftBuckets(tx *bbolt.Tx, rota string) (*bbolt.Bucket, *bbolt.Bucket) {
+	rb := tx.Bucket(shiftBucket).Bucket([]byte(rota))
+	if rb == nil {
+		return nil, nil
+	}
+	return rb.Bucket(startBucket), rb.Bucket(endBucket)
+}
+
+func putShift(sb, eb *bbolt.Bucket, e *rotang.ShiftEntry) error {
+	v, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	start := timeKey(e.StartTime)
+	if err := sb.Put(start, v); err != nil {
+		return err
+	}
+	return eb.Put(append(timeKey(e.EndTime), start...), nil)
+}
+
+func deleteShift(sb, eb *bbolt.Bucket, rota string, start time.Time) error {
+	key := timeKey(start)
+	v := sb.Get(key)
+	if v == nil {
+		return status.Errorf(codes.NotFound, "shift starting: %v not found for rota: %q", start, rota)
+	}
+	var e rotang.ShiftEntry
+	if err := json.Unmarshal(v, &e); err != nil {
+		return err
+	}
+	if err := eb.Delete(append(timeKey(e.EndTime), key...)); err != nil {
+		return err
+	}
+	return sb.Delete(key)
+}
+
+// timeKey encodes t so the byte order of keys matches the time order.
+// Flipping the sign bit keeps times before 1970 sorted before the ones after.
+func timeKey(t time.Time) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(t.UnixNano())^(1<<63))
+	return b
+}
+
+func encodeSpan(d time.Duration) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(d))
+	return b
+}
+
+func decodeSpan(b []byte) time.Duration {
+	if len(b) != 8 {
+		return 0
+	}
+	return time.Duration(binary.BigEndian.Uint64(b))
+}
+
+func putMember(b *bbolt.Bucket, m *rotang.Member) error {
+	v, err := json.Marshal(&member{
+		Member: *m,
+		TZ:     m.TZ.String(),
+	})
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(m.Email), v)
+}
+
+func decodeMember(v []byte) (*rotang.Member, error) {
+	var m member
+	if err := json.Unmarshal(v, &m); err != nil {
+		return nil, err
+	}
+	tz, err := time.LoadLocation(m.TZ)
+	if err != nil {
+		return nil, err
+	}
+	m.Member.TZ = *tz
+	return &m.Member, nil
+}
+
+// putConfig stores the configuration, checking all rota members exist.
+func putConfig(tx *bbolt.Tx, cfg *rotang.Configuration) error {
+	mb := tx.Bucket(memberBucket)
+	for _, m := range cfg.Members {
+		if mb.Get([]byte(m.Email)) == nil {
+			return status.Errorf(codes.NotFound, "rota member: %q not found", m.Email)
+		}
+	}
+	v, err := json.Marshal(&config{
+		Configuration: *cfg,
+		TZ:            cfg.Config.Shifts.TZ.String(),
+	})
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(rotaBucket).Put([]byte(cfg.Config.Name), v)
+}
+
+func decodeConfig(v []byte) (*rotang.Configuration, error) {
+	var c config
+	if err := json.Unmarshal(v, &c); err != nil {
+		return nil, err
+	}
+	// An empty TZ is kept empty, the handlers pick the default time zone.
+	if c.TZ == "" {
+		return &c.Configuration, nil
+	}
+	tz, err := time.LoadLocation(c.TZ)
+	if err != nil {
+		return nil, err
+	}
+	c.Configuration.Config.Shifts.TZ = *tz
+	return &c.Configuration, nil
+}
@@ -0,0 +1,377 @@
+package bolt
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+	rotang "github.com/miekg/rota"
+	"go.etcd.io/bbolt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var midnight = time.Date(2006, 8, 2, 0, 0, 0, 0, time.UTC)
+
+const fullDay = 24 * time.Hour
+
+func testStore(t *testing.T) (*Store, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "rota.db")
+	s, err := New(path)
+	if err != nil {
+		t.Fatalf("New(%q) failed: %v", path, err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s, path
+}
+
+func testShifts() []rotang.ShiftEntry {
+	var res []rotang.ShiftEntry
+	for i, m := range []string{"a@a.com", "b@b.com", "c@c.com"} {
+		res = append(res, rotang.ShiftEntry{
+			Name:      "MTV all day",
+			OnCall:    []rotang.ShiftMember{{Email: m, ShiftName: "MTV all day"}},
+			StartTime: midnight.Add(time.Duration(i) * fullDay),
+			EndTime:   midnight.Add(time.Duration(i+1) * fullDay),
+		})
+	}
+	return res
+}
+
+func TestPersistence(t *testing.T) {
+	ctx := context.Background()
+	s, path := testStore(t)
+
+	tz, err := time.LoadLocation("Europe/Amsterdam")
+	if err != nil {
+		t.Fatalf("time.LoadLocation() failed: %v", err)
+	}
+	m := rotang.Member{
+		Name:  "Test Namesson",
+		Email: "a@a.com",
+		TZ:    *tz,
+		OOO: []rotang.OOO{
+			{Start: midnight, Duration: fullDay, Comment: "Vacation"},
+		},
+		Preferences: []rotang.Preference{rotang.NoWeekends},
+	}
+	cfg := rotang.Configuration{
+		Config: rotang.Config{
+			Name:   "Test Rota",
+			Owners: []string{"owner@owner.com"},
+			Shifts: rotang.ShiftConfig{
+				StartTime: midnight,
+				Length:    5,
+				TZ:        *tz,
+				Shifts: []rotang.Shift{
+					{Name: "MTV all day", Duration: fullDay},
+				},
+			},
+		},
+		Members: []rotang.ShiftMember{
+			{Email: "a@a.com", ShiftName: "MTV all day"},
+		},
+	}
+	shifts := testShifts()
+
+	if err := s.CreateRotaConfig(ctx, &cfg); status.Code(err) != codes.NotFound {
+		t.Fatalf("CreateRotaConfig() = %v, want code: %v", err, codes.NotFound)
+	}
+	if err := s.CreateMember(ctx, &m); err != nil {
+		t.Fatalf("CreateMember() failed: %v", err)
+	}
+	if err := s.CreateRotaConfig(ctx, &cfg); err != nil {
+		t.Fatalf("CreateRotaConfig() failed: %v", err)
+	}
+	if err := s.AddShifts(ctx, cfg.Config.Name, shifts); err != nil {
+		t.Fatalf("AddShifts() failed: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close() failed: %v", err)
+	}
+
+	s, err = New(path)
+	if err != nil {
+		t.Fatalf("New(%q) failed: %v", path, err)
+	}
+	defer s.Close()
+
+	gotMember, err := s.Member(ctx, m.Email)
+	if err != nil {
+		t.Fatalf("Member() failed: %v", err)
+	}
+	if diff := pretty.Compare(m, gotMember); diff != "" {
+		t.Errorf("Member() differ -want +got, %s", diff)
+	}
+	gotCfg, err := s.RotaConfig(ctx, cfg.Config.Name)
+	if err != nil {
+		t.Fatalf("RotaConfig() failed: %v", err)
+	}
+	if diff := pretty.Compare([]*rotang.Configuration{&cfg}, gotCfg); diff != "" {
+		t.Errorf("RotaConfig() differ -want +got, %s", diff)
+	}
+	gotShifts, err := s.AllShifts(ctx, cfg.Config.Name)
+	if err != nil {
+		t.Fatalf("AllShifts() failed: %v", err)
+	}
+	if diff := pretty.Compare(shifts, gotShifts); diff != "" {
+		t.Errorf("AllShifts() differ -want +got, %s", diff)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	s, path := testStore(t)
+	s.Close()
+
+	db, err := bbolt.Open(path, 0600, nil)
+	if err != nil {
+		t.Fatalf("bbolt.Open() failed: %v", err)
+	}
+	if err := db.View(func(tx *bbolt.Tx) error {
+		if got, want := schemaVersion(tx.Bucket(metaBucket)), len(migrations); got != want {
+			t.Errorf("schemaVersion() = %d, want: %d", got, want)
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("db.View() failed: %v", err)
+	}
+	// Pretend the database was written by a newer version.
+	if err := db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(metaBucket).Put(versionKey, []byte{0, 0, 0, 0, 0, 0, 1, 0})
+	}); err != nil {
+		t.Fatalf("db.Update() failed: %v", err)
+	}
+	db.Close()
+
+	if _, err := New(path); err == nil {
+		t.Errorf("New(%q) succeeded with a newer schema version", path)
+	}
+}
+
+func TestShiftsFromTo(t *testing.T) {
+	ctx := context.Background()
+	s, _ := testStore(t)
+	shifts := testShifts()
+	long := rotang.ShiftEntry{
+		Name:      "Long shift",
+		StartTime: midnight.Add(-10 * fullDay),
+		EndTime:   midnight.Add(10 * fullDay),
+	}
+	if err := s.AddShifts(ctx, "Test Rota", shifts); err != nil {
+		t.Fatalf("AddShifts() failed: %v", err)
+	}
+	if err := s.AddShifts(ctx, "Long Rota", append([]rotang.ShiftEntry{long}, shifts...)); err != nil {
+		t.Fatalf("AddShifts() failed: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		rota     string
+		from, to time.Time
+		fail     bool
+		want     []rotang.ShiftEntry
+	}{{
+		name: "All shifts",
+		rota: "Test Rota",
+		want: shifts,
+	}, {
+		name: "Open ended",
+		rota: "Test Rota",
+		from: midnight.Add(fullDay + time.Hour),
+		want: shifts[1:],
+	}, {
+		name: "Shift in progress",
+		rota: "Test Rota",
+		from: midnight.Add(4 * time.Hour),
+		to:   midnight.Add(fullDay),
+		want: shifts[:1],
+	}, {
+		name: "From equal to EndTime",
+		rota: "Test Rota",
+		from: midnight.Add(fullDay),
+		to:   midnight.Add(2 * fullDay),
+		want: shifts[1:2],
+	}, {
+		name: "After all shifts",
+		rota: "Test Rota",
+		from: midnight.Add(3 * fullDay),
+		fail: true,
+	}, {
+		name: "Unknown rota",
+		rota: "Not a rota",
+		fail: true,
+	}, {
+		name: "Long shift overlapping",
+		rota: "Long Rota",
+		from: midnight.Add(fullDay),
+		to:   midnight.Add(2 * fullDay),
+		want: []rotang.ShiftEntry{long, shifts[1]},
+	}}
+
+	for _, tst := range tests {
+		got, err := s.ShiftsFromTo(ctx, tst.rota, tst.from, tst.to)
+		if got, want := (err != nil), tst.fail; got != want {
+			t.Errorf("%s: s.ShiftsFromTo() = %t want: %t, err: %v", tst.name, got, want, err)
+			continue
+		}
+		if diff := pretty.Compare(tst.want, got); diff != "" {
+			t.Errorf("%s: s.ShiftsFromTo() differ -want +got, %s", tst.name, diff)
+		}
+	}
+}
+
+func TestShifts(t *testing.T) {
+	ctx := context.Background()
+	s, _ := testStore(t)
+	shifts := testShifts()
+	if err := s.AddShifts(ctx, "Test Rota", []rotang.ShiftEntry{shifts[2], shifts[0]}); err != nil {
+		t.Fatalf("AddShifts() failed: %v", err)
+	}
+	if err := s.AddShifts(ctx, "Test Rota", shifts[1:2]); err != nil {
+		t.Fatalf("AddShifts() failed: %v", err)
+	}
+	if err := s.AddShifts(ctx, "Test Rota", shifts[:1]); status.Code(err) != codes.AlreadyExists {
+		t.Fatalf("AddShifts() = %v, want code: %v", err, codes.AlreadyExists)
+	}
+
+	oc, err := s.Oncall(ctx, midnight.Add(fullDay), "Test Rota")
+	if err != nil {
+		t.Fatalf("Oncall() failed: %v", err)
+	}
+	if diff := pretty.Compare(shifts[1], oc); diff != "" {
+		t.Errorf("Oncall() differ -want +got, %s", diff)
+	}
+	if _, err := s.Oncall(ctx, midnight.Add(-time.Hour), "Test Rota"); status.Code(err) != codes.NotFound {
+		t.Errorf("Oncall() = %v, want code: %v", err, codes.NotFound)
+	}
+
+	updated := shifts[1]
+	updated.OnCall = []rotang.ShiftMember{{Email: "d@d.com", ShiftName: "MTV all day"}}
+	updated.EndTime = updated.EndTime.Add(time.Hour)
+	updated.EvtID = "1234"
+	if err := s.UpdateShift(ctx, "Test Rota", &updated); err != nil {
+		t.Fatalf("UpdateShift() failed: %v", err)
+	}
+	got, err := s.ShiftsFromTo(ctx, "Test Rota", midnight.Add(2*fullDay), midnight.Add(2*fullDay+time.Minute))
+	if err != nil {
+		t.Fatalf("ShiftsFromTo() failed: %v", err)
+	}
+	if diff := pretty.Compare([]rotang.ShiftEntry{updated, shifts[2]}, got); diff != "" {
+		t.Errorf("ShiftsFromTo() differ -want +got, %s", diff)
+	}
+
+	if err := s.DeleteShift(ctx, "Test Rota", updated.StartTime); err != nil {
+		t.Fatalf("DeleteShift() failed: %v", err)
+	}
+	if _, err := s.Shift(ctx, "Test Rota", updated.StartTime); status.Code(err) != codes.NotFound {
+		t.Errorf("Shift() = %v, want code: %v", err, codes.NotFound)
+	}
+	if err := s.UpdateShift(ctx, "Test Rota", &updated); status.Code(err) != codes.NotFound {
+		t.Errorf("UpdateShift() = %v, want code: %v", err, codes.NotFound)
+	}
+
+	if err := s.DeleteAllShifts(ctx, "Test Rota"); err != nil {
+		t.Fatalf("DeleteAllShifts() failed: %v", err)
+	}
+	if _, err := s.AllShifts(ctx, "Test Rota"); status.Code(err) != codes.NotFound {
+		t.Errorf("AllShifts() = %v, want code: %v", err, codes.NotFound)
+	}
+}
+
+func TestRotaMembers(t *testing.T) {
+	ctx := context.Background()
+	s, _ := testStore(t)
+	for _, e := range []string{"a@a.com", "b@b.com"} {
+		if err := s.CreateMember(ctx, &rotang.Member{Email: e}); err != nil {
+			t.Fatalf("CreateMember() failed: %v", err)
+		}
+	}
+	for _, name := range []string{"B Rota", "A Rota"} {
+		if err := s.CreateRotaConfig(ctx, &rotang.Configuration{
+			Config:  rotang.Config{Name: name},
+			Members: []rotang.ShiftMember{{Email: "a@a.com"}},
+		}); err != nil {
+			t.Fatalf("CreateRotaConfig() failed: %v", err)
+		}
+	}
+	if err := s.AddRotaMember(ctx, "B Rota", &rotang.ShiftMember{Email: "b@b.com"}); err != nil {
+		t.Fatalf("AddRotaMember() failed: %v", err)
+	}
+	if err := s.AddRotaMember(ctx, "B Rota", &rotang.ShiftMember{Email: "b@b.com"}); status.Code(err) != codes.AlreadyExists {
+		t.Fatalf("AddRotaMember() = %v, want code: %v", err, codes.AlreadyExists)
+	}
+	if err := s.AddRotaMember(ctx, "B Rota", &rotang.ShiftMember{Email: "c@c.com"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("AddRotaMember() = %v, want code: %v", err, codes.NotFound)
+	}
+
+	for _, tst := range []struct {
+		email string
+		want  []string
+	}{
+		{email: "a@a.com", want: []string{"A Rota", "B Rota"}},
+		{email: "b@b.com", want: []string{"B Rota"}},
+		{email: "c@c.com"},
+	} {
+		got, err := s.MemberOf(ctx, tst.email)
+		if err != nil {
+			t.Fatalf("MemberOf(_, %q) failed: %v", tst.email, err)
+		}
+		if diff := pretty.Compare(tst.want, got); diff != "" {
+			t.Errorf("MemberOf(_, %q) differ -want +got, %s", tst.email, diff)
+		}
+	}
+
+	if err := s.DeleteRotaMember(ctx, "B Rota", "b@b.com"); err != nil {
+		t.Fatalf("DeleteRotaMember() failed: %v", err)
+	}
+	if err := s.EnableRota(ctx, "A Rota"); err != nil {
+		t.Fatalf("EnableRota() failed: %v", err)
+	}
+	if enabled, err := s.RotaEnabled(ctx, "A Rota"); err != nil || !enabled {
+		t.Errorf("RotaEnabled() = %t, %v want: %t", enabled, err, true)
+	}
+	if err := s.DisableRota(ctx, "A Rota"); err != nil {
+		t.Fatalf("DisableRota() failed: %v", err)
+	}
+	if enabled, err := s.RotaEnabled(ctx, "A Rota"); err != nil || enabled {
+		t.Errorf("RotaEnabled() = %t, %v want: %t", enabled, err, false)
+	}
+	if err := s.DeleteRotaConfig(ctx, "A Rota"); err != nil {
+		t.Fatalf("DeleteRotaConfig() failed: %v", err)
+	}
+	if _, err := s.RotaConfig(ctx, "A Rota"); status.Code(err) != codes.NotFound {
+		t.Errorf("RotaConfig() = %v, want code: %v", err, codes.NotFound)
+	}
+}
+
+func TestNilArguments(t *testing.T) {
+	ctx := context.Background()
+	s, _ := testStore(t)
+
+	tests := []struct {
+		name string
+		call func() error
+	}{{
+		name: "CreateMember",
+		call: func() error { return s.CreateMember(ctx, nil) },
+	}, {
+		name: "UpdateMember",
+		call: func() error { return s.UpdateMember(ctx, nil) },
+	}, {
+		name: "CreateRotaConfig",
+		call: func() error { return s.CreateRotaConfig(ctx, nil) },
+	}, {
+		name: "UpdateRotaConfig",
+		call: func() error { return s.UpdateRotaConfig(ctx, nil) },
+	},
+	}
+
+	for _, tst := range tests {
+		if err := tst.call(); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("%s(ctx, nil) = %v, want code: %v", tst.name, err, codes.InvalidArgument)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package bolt
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"go.etcd.io/bbolt"
+)
+
+// migrations contains the schema migrations, migrations[i] moves the schema from version i to i+1.
+// New migrations are appended to the end, existing ones must never change.
+var migrations = []func(tx *bbolt.Tx) error{
+	// Initial schema.
+	func(tx *bbolt.Tx) error {
+		for _, b := range [][]byte{memberBucket, rotaBucket, shiftBucket} {
+			if _, err := tx.CreateBucketIfNotExists(b); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+// migrate brings the database schema up to the latest version.
+func migrate(db *bbolt.DB) error {
+	return db.Update(func(tx *bbolt.Tx) error {
+		meta, err := tx.CreateBucketIfNotExists(metaBucket)
+		if err != nil {
+			return err
+		}
+		version := schemaVersion(meta)
+		if version > len(migrations) {
+			return fmt.Errorf("database schema version: %d is newer than supported version: %d", version, len(migrations))
+		}
+		for ; version < len(migrations); version++ {
+			if err := migrations[version](tx); err != nil {
+				return fmt.Errorf("migration to schema version: %d failed: %v", version+1, err)
+			}
+		}
+		b := make([]byte, 8)
+		binary.BigEndian.PutUint64(b, uint64(version))
+		return meta.Put(versionKey, b)
+	})
+}
+
+func schemaVersion(meta *bbolt.Bucket) int {
+	v := meta.Get(versionKey)
+	if len(v) != 8 {
+		return 0
+	}
+	return int(binary.BigEndian.Uint64(v))
+}
@@ -0,0 +1,525 @@
+// Package memory implements an in-memory store for rotations, members and shifts.
+// The store is safe for concurrent use and is meant for local development and tests.
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	rotang "github.com/miekg/rota"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Store is an in-memory implementation of the rotang storer interfaces.
+type Store struct {
+	mu      sync.RWMutex
+	members map[string]rotang.Member
+	configs map[string]rotang.Configuration
+	// shifts are kept sorted by StartTime per rota.
+	shifts map[string][]rotang.ShiftEntry
+}
+
+var (
+	_ rotang.MemberStorer = &Store{}
+	_ rotang.ShiftStorer  = &Store{}
+	_ rotang.ConfigStorer = &Store{}
+)
+
+// New creates a new empty Store.
+func New() *Store {
+	return &Store{
+		members: make(map[string]rotang.Member),
+		configs: make(map[string]rotang.Configuration),
+		shifts:  make(map[string][]rotang.ShiftEntry),
+	}
+}
+
+// CreateMember adds a new member to the store.
+func (s *Store) CreateMember(ctx context.Context, member *rotang.Member) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if member == nil {
+		return status.Errorf(codes.InvalidArgument, "member must be set")
+	}
+	if member.Email == "" {
+		return status.Errorf(codes.InvalidArgument, "member Email must be set")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.members[member.Email]; ok {
+		return status.Errorf(codes.AlreadyExists, "member: %q already exists", member.Email)
+	}
+	s.members[member.Email] = storedMember(member)
+	return nil
+}
+
+// Member fetches the member with the provided email.
+func (s *Store) Member(ctx context.Context, email string) (*rotang.Member, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	m, ok := s.members[email]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "member: %q not found", email)
+	}
+	res := copyMember(&m)
+	return &res, nil
+}
+
+// AllMembers returns all members sorted by Email.
+func (s *Store) AllMembers(ctx context.Context) ([]rotang.Member, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.members) == 0 {
+		return nil, status.Errorf(codes.NotFound, "no members found")
+	}
+	var res []rotang.Member
+	for _, m := range s.members {
+		res = append(res, copyMember(&m))
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Email < res[j].Email
+	})
+	return res, nil
+}
+
+// DeleteMember removes the member from the store.
+func (s *Store) DeleteMember(ctx context.Context, email string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.members[email]; !ok {
+		return status.Errorf(codes.NotFound, "member: %q not found", email)
+	}
+	delete(s.members, email)
+	return nil
+}
+
+// UpdateMember replaces an existing member.
+func (s *Store) UpdateMember(ctx context.Context, member *rotang.Member) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if member == nil {
+		return status.Errorf(codes.InvalidArgument, "member must be set")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.members[member.Email]; !ok {
+		return status.Errorf(codes.NotFound, "member: %q not found", member.Email)
+	}
+	s.members[member.Email] = storedMember(member)
+	return nil
+}
+
+// CreateRotaConfig stores a new rota configuration.
+// All members of the rotation must already exist in the store.
+func (s *Store) CreateRotaConfig(ctx context.Context, rotation *rotang.Configuration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if rotation == nil {
+		return status.Errorf(codes.InvalidArgument, "rota configuration must be set")
+	}
+	if rotation.Config.Name == "" {
+		return status.Errorf(codes.InvalidArgument, "rota Name must be set")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.configs[rotation.Config.Name]; ok {
+		return status.Errorf(codes.AlreadyExists, "rota: %q already exists", rotation.Config.Name)
+	}
+	for _, m := range rotation.Members {
+		if _, ok := s.members[m.Email]; !ok {
+			return status.Errorf(codes.NotFound, "rota member: %q not found", m.Email)
+		}
+	}
+	s.configs[rotation.Config.Name] = storedConfiguration(rotation)
+	return nil
+}
+
+// UpdateRotaConfig replaces an existing rota configuration.
+func (s *Store) UpdateRotaConfig(ctx context.Context, rotation *rotang.Configuration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if rotation == nil {
+		return status.Errorf(codes.InvalidArgument, "rota configuration must be set")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.configs[rotation.Config.Name]; !ok {
+		return status.Errorf(codes.NotFound, "rota: %q not found", rotation.Config.Name)
+	}
+	for _, m := range rotation.Members {
+		if _, ok := s.members[m.Email]; !ok {
+			return status.Errorf(codes.NotFound, "rota member: %q not found", m.Email)
+		}
+	}
+	s.configs[rotation.Config.Name] = storedConfiguration(rotation)
+	return nil
+}
+
+// RotaConfig returns the named rota configuration.
+// An empty name returns all configurations sorted by name.
+func (s *Store) RotaConfig(ctx context.Context, name string) ([]*rotang.Configuration, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if name != "" {
+		cfg, ok := s.configs[name]
+		if !ok {
+			return nil, status.Errorf(codes.NotFound, "rota: %q not found", name)
+		}
+		res := copyConfiguration(&cfg)
+		return []*rotang.Configuration{&res}, nil
+	}
+	if len(s.configs) == 0 {
+		return nil, status.Errorf(codes.NotFound, "no rotas found")
+	}
+	var res []*rotang.Configuration
+	for _, cfg := range s.configs {
+		c := copyConfiguration(&cfg)
+		res = append(res, &c)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Config.Name < res[j].Config.Name
+	})
+	return res, nil
+}
+
+// DeleteRotaConfig removes the rota configuration.
+// Shifts stored for the rota are kept, use DeleteAllShifts to remove them.
+func (s *Store) DeleteRotaConfig(ctx context.Context, name string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.configs[name]; !ok {
+		return status.Errorf(codes.NotFound, "rota: %q not found", name)
+	}
+	delete(s.configs, name)
+	return nil
+}
+
+// AddRotaMember adds a member to the rotation.
+func (s *Store) AddRotaMember(ctx context.Context, rota string, member *rotang.ShiftMember) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cfg, ok := s.configs[rota]
+	if !ok {
+		return status.Errorf(codes.NotFound, "rota: %q not found", rota)
+	}
+	if _, ok := s.members[member.Email]; !ok {
+		return status.Errorf(codes.NotFound, "member: %q not found", member.Email)
+	}
+	for _, m := range cfg.Members {
+		if m.Email == member.Email {
+			return status.Errorf(codes.AlreadyExists, "member: %q already in rota: %q", member.Email, rota)
+		}
+	}
+	cfg.Members = append(cfg.Members, *member)
+	s.configs[rota] = cfg
+	return nil
+}
+
+// DeleteRotaMember removes a member from the rotation.
+func (s *Store) DeleteRotaMember(ctx context.Context, rota, email string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cfg, ok := s.configs[rota]
+	if !ok {
+		return status.Errorf(codes.NotFound, "rota: %q not found", rota)
+	}
+	for i, m := range cfg.Members {
+		if m.Email == email {
+			cfg.Members = append(cfg.Members[:i:i], cfg.Members[i+1:]...)
+			s.configs[rota] = cfg
+			return nil
+		}
+	}
+	return status.Errorf(codes.NotFound, "member: %q not in rota: %q", email, rota)
+}
+
+// EnableRota enables the rotation.
+func (s *Store) EnableRota(ctx context.Context, rota string) error {
+	return s.setEnabled(ctx, rota, true)
+}
+
+// DisableRota disables the rotation.
+func (s *Store) DisableRota(ctx context.Context, rota string) error {
+	return s.setEnabled(ctx, rota, false)
+}
+
+func (s *Store) setEnabled(ctx context.Context, rota string, enabled bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cfg, ok := s.configs[rota]
+	if !ok {
+		return status.Errorf(codes.NotFound, "rota: %q not found", rota)
+	}
+	cfg.Config.Enabled = enabled
+	s.configs[rota] = cfg
+	return nil
+}
+
+// RotaEnabled returns the enabled state of the rotation.
+func (s *Store) RotaEnabled(ctx context.Context, rota string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	cfg, ok := s.configs[rota]
+	if !ok {
+		return false, status.Errorf(codes.NotFound, "rota: %q not found", rota)
+	}
+	return cfg.Config.Enabled, nil
+}
+
+// MemberOf returns the names of the rotations the member is part of, sorted by name.
+func (s *Store) MemberOf(ctx context.Context, email string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var res []string
+	for name, cfg := range s.configs {
+		for _, m := range cfg.Members {
+			if m.Email == email {
+				res = append(res, name)
+				break
+			}
+		}
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
+// AddShifts adds shifts to the rota.
+func (s *Store) AddShifts(ctx context.Context, rota string, entries []rotang.ShiftEntry) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored := s.shifts[rota]
+	// The stored shifts are sorted, the entries need not be.
+	added := make(map[int64]bool)
+	shifts := append([]rotang.ShiftEntry{}, stored...)
+	for _, e := range entries {
+		if shiftIndex(stored, e.StartTime) >= 0 || added[e.StartTime.UnixNano()] {
+			return status.Errorf(codes.AlreadyExists, "shift starting: %v already exists for rota: %q", e.StartTime, rota)
+		}
+		added[e.StartTime.UnixNano()] = true
+		shifts = append(shifts, copyShift(&e))
+	}
+	sort.SliceStable(shifts, func(i, j int) bool {
+		return shifts[i].StartTime.Before(shifts[j].StartTime)
+	})
+	s.shifts[rota] = shifts
+	return nil
+}
+
+// AllShifts returns all shifts for the rota sorted by StartTime.
+func (s *Store) AllShifts(ctx context.Context, rota string) ([]rotang.ShiftEntry, error) {
+	return s.ShiftsFromTo(ctx, rota, time.Time{}, time.Time{})
+}
+
+// ShiftsFromTo returns the shifts ending after from and starting before to.
+// A zero to returns all shifts ending after from.
+func (s *Store) ShiftsFromTo(ctx context.Context, rota string, from, to time.Time) ([]rotang.ShiftEntry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var res []rotang.ShiftEntry
+	for _, e := range s.shifts[rota] {
+		if !to.IsZero() && !e.StartTime.Before(to) {
+			break
+		}
+		if !e.EndTime.After(from) {
+			continue
+		}
+		res = append(res, copyShift(&e))
+	}
+	if len(res) == 0 {
+		return nil, status.Errorf(codes.NotFound, "no shifts found for rota: %q", rota)
+	}
+	return res, nil
+}
+
+// Shift returns the shift starting at start.
+func (s *Store) Shift(ctx context.Context, rota string, start time.Time) (*rotang.ShiftEntry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	shifts := s.shifts[rota]
+	idx := shiftIndex(shifts, start)
+	if idx < 0 {
+		return nil, status.Errorf(codes.NotFound, "shift starting: %v not found for rota: %q", start, rota)
+	}
+	res := copyShift(&shifts[idx])
+	return &res, nil
+}
+
+// Oncall returns the shift in progress at the at time.
+func (s *Store) Oncall(ctx context.Context, at time.Time, rota string) (*rotang.ShiftEntry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, e := range s.shifts[rota] {
+		if e.StartTime.After(at) {
+			break
+		}
+		if e.EndTime.After(at) {
+			res := copyShift(&e)
+			return &res, nil
+		}
+	}
+	return nil, status.Errorf(codes.NotFound, "nobody oncall for rota: %q at: %v", rota, at)
+}
+
+// DeleteAllShifts removes all shifts for the rota.
+func (s *Store) DeleteAllShifts(ctx context.Context, rota string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.shifts, rota)
+	return nil
+}
+
+// DeleteShift removes the shift starting at start.
+func (s *Store) DeleteShift(ctx context.Context, rota string, start time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	shifts := s.shifts[rota]
+	idx := shiftIndex(shifts, start)
+	if idx < 0 {
+		return status.Errorf(codes.NotFound, "shift starting: %v not found for rota: %q", start, rota)
+	}
+	s.shifts[rota] = append(shifts[:idx:idx], shifts[idx+1:]...)
+	return nil
+}
+
+// UpdateShift replaces the shift with the same StartTime.
+func (s *Store) UpdateShift(ctx context.Context, rota string, shift *rotang.ShiftEntry) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	shifts := s.shifts[rota]
+	idx := shiftIndex(shifts, shift.StartTime)
+	if idx < 0 {
+		return status.Errorf(codes.NotFound, "shift starting: %v not found for rota: %q", shift.StartTime, rota)
+	}
+	shifts[idx] = copyShift(shift)
+	return nil
+}
+
+// shiftIndex returns the index of the shift starting at start, -1 if not found.
+func shiftIndex(shifts []rotang.ShiftEntry, start time.Time) int {
+	idx := sort.Search(len(shifts), func(i int) bool {
+		return !shifts[i].StartTime.Before(start)
+	})
+	if idx < len(shifts) && shifts[idx].StartTime.Equal(start) {
+		return idx
+	}
+	return -1
+}
+
+// storedMember returns a copy of the member as it's kept in the store.
+func storedMember(m *rotang.Member) rotang.Member {
+	res := copyMember(m)
+	res.TZ = location(&m.TZ)
+	return res
+}
+
+// storedConfiguration returns a copy of the configuration as it's kept in the store.
+func storedConfiguration(c *rotang.Configuration) rotang.Configuration {
+	res := copyConfiguration(c)
+	// An empty rotation TZ is kept empty, the handlers pick the default time zone.
+	if c.Config.Shifts.TZ.String() != "" {
+		res.Config.Shifts.TZ = location(&c.Config.Shifts.TZ)
+	}
+	return res
+}
+
+// location normalizes a time.Location the same way a persistent store does by only keeping the
+// name of the location around. This turns an empty location into UTC.
+func location(loc *time.Location) time.Location {
+	res, err := time.LoadLocation(loc.String())
+	if err != nil {
+		return *loc
+	}
+	return *res
+}
+
+// The copy functions below make sure callers can't modify stored values through shared slices.
+
+func copyMember(m *rotang.Member) rotang.Member {
+	res := *m
+	res.OOO = append([]rotang.OOO(nil), m.OOO...)
+	res.Preferences = append([]rotang.Preference(nil), m.Preferences...)
+	res.Tags = append([]string(nil), m.Tags...)
+	res.Unavailable = append([]rotang.Unavailable(nil), m.Unavailable...)
+	for i := range res.Unavailable {
+		res.Unavailable[i].Weekdays = append([]time.Weekday(nil), m.Unavailable[i].Weekdays...)
+	}
+	return res
+}
+
+func copyConfiguration(c *rotang.Configuration) rotang.Configuration {
+	res := *c
+	res.Config.Owners = append([]string(nil), c.Config.Owners...)
+	res.Config.Shifts.Shifts = append([]rotang.Shift(nil), c.Config.Shifts.Shifts...)
+	for i := range res.Config.Shifts.Shifts {
+		res.Config.Shifts.Shifts[i].Coverage = append([]string(nil), c.Config.Shifts.Shifts[i].Coverage...)
+	}
+	res.Config.Shifts.Modifiers = append([]string(nil), c.Config.Shifts.Modifiers...)
+	res.Config.Shifts.Roles = append([]rotang.Role(nil), c.Config.Shifts.Roles...)
+	res.Config.Shifts.Trainees = append([]rotang.Trainee(nil), c.Config.Shifts.Trainees...)
+	res.Members = append([]rotang.ShiftMember(nil), c.Members...)
+	return res
+}
+
+func copyShift(s *rotang.ShiftEntry) rotang.ShiftEntry {
+	res := *s
+	res.OnCall = append([]rotang.ShiftMember(nil), s.OnCall...)
+	return res
+}
@@ -0,0 +1,319 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+	rotang "github.com/miekg/rota"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var midnight = time.Date(2006, 8, 2, 0, 0, 0, 0, time.UTC)
+
+const fullDay = 24 * time.Hour
+
+func testShifts() []rotang.ShiftEntry {
+	var res []rotang.ShiftEntry
+	for i, m := range []string{"a@a.com", "b@b.com", "c@c.com"} {
+		res = append(res, rotang.ShiftEntry{
+			Name:      "MTV all day",
+			OnCall:    []rotang.ShiftMember{{Email: m, ShiftName: "MTV all day"}},
+			StartTime: midnight.Add(time.Duration(i) * fullDay),
+			EndTime:   midnight.Add(time.Duration(i+1) * fullDay),
+		})
+	}
+	return res
+}
+
+func TestMembers(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+
+	m := rotang.Member{
+		Name:  "Test Namesson",
+		Email: "test@test.com",
+		OOO: []rotang.OOO{
+			{Start: midnight, Duration: fullDay, Comment: "Vacation"},
+		},
+	}
+	if err := s.CreateMember(ctx, &m); err != nil {
+		t.Fatalf("CreateMember() failed: %v", err)
+	}
+	if err := s.CreateMember(ctx, &m); status.Code(err) != codes.AlreadyExists {
+		t.Fatalf("CreateMember() = %v, want code: %v", err, codes.AlreadyExists)
+	}
+
+	// Changes made by the caller should not leak into the store.
+	m.OOO[0].Comment = "Changed"
+	got, err := s.Member(ctx, m.Email)
+	if err != nil {
+		t.Fatalf("Member(_, %q) failed: %v", m.Email, err)
+	}
+	if got.OOO[0].Comment != "Vacation" {
+		t.Errorf("Member(_, %q) OOO comment = %q, want: %q", m.Email, got.OOO[0].Comment, "Vacation")
+	}
+
+	got.Name = "Updated Namesson"
+	if err := s.UpdateMember(ctx, got); err != nil {
+		t.Fatalf("UpdateMember() failed: %v", err)
+	}
+	all, err := s.AllMembers(ctx)
+	if err != nil {
+		t.Fatalf("AllMembers() failed: %v", err)
+	}
+	if diff := pretty.Compare([]rotang.Member{*got}, all); diff != "" {
+		t.Errorf("AllMembers() differ -want +got, %s", diff)
+	}
+
+	if err := s.DeleteMember(ctx, m.Email); err != nil {
+		t.Fatalf("DeleteMember() failed: %v", err)
+	}
+	if _, err := s.Member(ctx, m.Email); status.Code(err) != codes.NotFound {
+		t.Errorf("Member(_, %q) = %v, want code: %v", m.Email, err, codes.NotFound)
+	}
+	if err := s.UpdateMember(ctx, &m); status.Code(err) != codes.NotFound {
+		t.Errorf("UpdateMember() = %v, want code: %v", err, codes.NotFound)
+	}
+}
+
+func TestRotaConfig(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+
+	cfg := rotang.Configuration{
+		Config: rotang.Config{
+			Name:   "Test Rota",
+			Owners: []string{"owner@owner.com"},
+			Shifts: rotang.ShiftConfig{
+				TZ: *time.UTC,
+			},
+		},
+		Members: []rotang.ShiftMember{
+			{Email: "a@a.com", ShiftName: "MTV all day"},
+		},
+	}
+
+	if err := s.CreateRotaConfig(ctx, nil); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("CreateRotaConfig(ctx, nil) = %v, want code: %v", err, codes.InvalidArgument)
+	}
+	if err := s.CreateRotaConfig(ctx, &cfg); status.Code(err) != codes.NotFound {
+		t.Fatalf("CreateRotaConfig() = %v, want code: %v", err, codes.NotFound)
+	}
+	for _, e := range []string{"a@a.com", "b@b.com"} {
+		if err := s.CreateMember(ctx, &rotang.Member{Email: e}); err != nil {
+			t.Fatalf("CreateMember() failed: %v", err)
+		}
+	}
+	if err := s.CreateRotaConfig(ctx, &cfg); err != nil {
+		t.Fatalf("CreateRotaConfig() failed: %v", err)
+	}
+	if err := s.CreateRotaConfig(ctx, &cfg); status.Code(err) != codes.AlreadyExists {
+		t.Fatalf("CreateRotaConfig() = %v, want code: %v", err, codes.AlreadyExists)
+	}
+
+	if err := s.AddRotaMember(ctx, cfg.Config.Name, &rotang.ShiftMember{Email: "b@b.com", ShiftName: "MTV all day"}); err != nil {
+		t.Fatalf("AddRotaMember() failed: %v", err)
+	}
+	rotas, err := s.MemberOf(ctx, "b@b.com")
+	if err != nil {
+		t.Fatalf("MemberOf() failed: %v", err)
+	}
+	if diff := pretty.Compare([]string{cfg.Config.Name}, rotas); diff != "" {
+		t.Errorf("MemberOf() differ -want +got, %s", diff)
+	}
+	if err := s.DeleteRotaMember(ctx, cfg.Config.Name, "b@b.com"); err != nil {
+		t.Fatalf("DeleteRotaMember() failed: %v", err)
+	}
+
+	if err := s.EnableRota(ctx, cfg.Config.Name); err != nil {
+		t.Fatalf("EnableRota() failed: %v", err)
+	}
+	enabled, err := s.RotaEnabled(ctx, cfg.Config.Name)
+	if err != nil {
+		t.Fatalf("RotaEnabled() failed: %v", err)
+	}
+	if !enabled {
+		t.Errorf("RotaEnabled() = %t, want: %t", enabled, true)
+	}
+
+	cfg.Config.Enabled = true
+	got, err := s.RotaConfig(ctx, "")
+	if err != nil {
+		t.Fatalf("RotaConfig() failed: %v", err)
+	}
+	if diff := pretty.Compare([]*rotang.Configuration{&cfg}, got); diff != "" {
+		t.Errorf("RotaConfig() differ -want +got, %s", diff)
+	}
+
+	if err := s.DeleteRotaConfig(ctx, cfg.Config.Name); err != nil {
+		t.Fatalf("DeleteRotaConfig() failed: %v", err)
+	}
+	if _, err := s.RotaConfig(ctx, ""); status.Code(err) != codes.NotFound {
+		t.Errorf("RotaConfig() = %v, want code: %v", err, codes.NotFound)
+	}
+}
+
+func TestShiftsFromTo(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+	shifts := testShifts()
+	if err := s.AddShifts(ctx, "Test Rota", shifts); err != nil {
+		t.Fatalf("AddShifts() failed: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+		fail     bool
+		want     []rotang.ShiftEntry
+	}{{
+		name: "All shifts",
+		want: shifts,
+	}, {
+		name: "Open ended",
+		from: midnight.Add(fullDay + time.Hour),
+		want: shifts[1:],
+	}, {
+		name: "Shift in progress",
+		from: midnight.Add(4 * time.Hour),
+		to:   midnight.Add(fullDay),
+		want: shifts[:1],
+	}, {
+		name: "From equal to EndTime",
+		from: midnight.Add(fullDay),
+		to:   midnight.Add(2 * fullDay),
+		want: shifts[1:2],
+	}, {
+		name: "After all shifts",
+		from: midnight.Add(3 * fullDay),
+		fail: true,
+	}}
+
+	for _, tst := range tests {
+		got, err := s.ShiftsFromTo(ctx, "Test Rota", tst.from, tst.to)
+		if got, want := (err != nil), tst.fail; got != want {
+			t.Errorf("%s: s.ShiftsFromTo() = %t want: %t, err: %v", tst.name, got, want, err)
+			continue
+		}
+		if diff := pretty.Compare(tst.want, got); diff != "" {
+			t.Errorf("%s: s.ShiftsFromTo() differ -want +got, %s", tst.name, diff)
+		}
+	}
+}
+
+func TestShifts(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+	shifts := testShifts()
+	// Added out of order to check the store keeps them sorted.
+	if err := s.AddShifts(ctx, "Test Rota", []rotang.ShiftEntry{shifts[2], shifts[0]}); err != nil {
+		t.Fatalf("AddShifts() failed: %v", err)
+	}
+	if err := s.AddShifts(ctx, "Test Rota", shifts[1:2]); err != nil {
+		t.Fatalf("AddShifts() failed: %v", err)
+	}
+	if err := s.AddShifts(ctx, "Test Rota", shifts[:1]); status.Code(err) != codes.AlreadyExists {
+		t.Fatalf("AddShifts() = %v, want code: %v", err, codes.AlreadyExists)
+	}
+	// An unsorted batch with a duplicate is refused as a whole.
+	later := shifts[2]
+	later.StartTime, later.EndTime = midnight.Add(4*fullDay), midnight.Add(5*fullDay)
+	latest := later
+	latest.StartTime, latest.EndTime = midnight.Add(5*fullDay), midnight.Add(6*fullDay)
+	if err := s.AddShifts(ctx, "Test Rota", []rotang.ShiftEntry{latest, later, later}); status.Code(err) != codes.AlreadyExists {
+		t.Fatalf("AddShifts() with a duplicate = %v, want code: %v", err, codes.AlreadyExists)
+	}
+	all, err := s.AllShifts(ctx, "Test Rota")
+	if err != nil {
+		t.Fatalf("AllShifts() failed: %v", err)
+	}
+	if diff := pretty.Compare(shifts, all); diff != "" {
+		t.Errorf("AllShifts() differ -want +got, %s", diff)
+	}
+
+	oc, err := s.Oncall(ctx, midnight.Add(fullDay+time.Hour), "Test Rota")
+	if err != nil {
+		t.Fatalf("Oncall() failed: %v", err)
+	}
+	if diff := pretty.Compare(shifts[1], oc); diff != "" {
+		t.Errorf("Oncall() differ -want +got, %s", diff)
+	}
+	if _, err := s.Oncall(ctx, midnight.Add(-time.Hour), "Test Rota"); status.Code(err) != codes.NotFound {
+		t.Errorf("Oncall() = %v, want code: %v", err, codes.NotFound)
+	}
+
+	updated := shifts[1]
+	updated.OnCall = []rotang.ShiftMember{{Email: "d@d.com", ShiftName: "MTV all day"}}
+	updated.EvtID = "1234"
+	if err := s.UpdateShift(ctx, "Test Rota", &updated); err != nil {
+		t.Fatalf("UpdateShift() failed: %v", err)
+	}
+	got, err := s.Shift(ctx, "Test Rota", updated.StartTime)
+	if err != nil {
+		t.Fatalf("Shift() failed: %v", err)
+	}
+	if diff := pretty.Compare(updated, got); diff != "" {
+		t.Errorf("Shift() differ -want +got, %s", diff)
+	}
+
+	if err := s.DeleteShift(ctx, "Test Rota", updated.StartTime); err != nil {
+		t.Fatalf("DeleteShift() failed: %v", err)
+	}
+	if _, err := s.Shift(ctx, "Test Rota", updated.StartTime); status.Code(err) != codes.NotFound {
+		t.Errorf("Shift() = %v, want code: %v", err, codes.NotFound)
+	}
+	if err := s.UpdateShift(ctx, "Test Rota", &updated); status.Code(err) != codes.NotFound {
+		t.Errorf("UpdateShift() = %v, want code: %v", err, codes.NotFound)
+	}
+
+	if err := s.DeleteAllShifts(ctx, "Test Rota"); err != nil {
+		t.Fatalf("DeleteAllShifts() failed: %v", err)
+	}
+	if _, err := s.AllShifts(ctx, "Test Rota"); status.Code(err) != codes.NotFound {
+		t.Errorf("AllShifts() = %v, want code: %v", err, codes.NotFound)
+	}
+}
+
+func TestCanceledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s := New()
+	if err := s.CreateMember(ctx, &rotang.Member{Email: "a@a.com"}); err == nil {
+		t.Errorf("CreateMember() succeeded with a canceled context")
+	}
+	if _, err := s.AllShifts(ctx, "Test Rota"); err == nil {
+		t.Errorf("AllShifts() succeeded with a canceled context")
+	}
+}
+
+func TestNilArguments(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+
+	tests := []struct {
+		name string
+		call func() error
+	}{{
+		name: "CreateMember",
+		call: func() error { return s.CreateMember(ctx, nil) },
+	}, {
+		name: "UpdateMember",
+		call: func() error { return s.UpdateMember(ctx, nil) },
+	}, {
+		name: "CreateRotaConfig",
+		call: func() error { return s.CreateRotaConfig(ctx, nil) },
+	}, {
+		name: "UpdateRotaConfig",
+		call: func() error { return s.UpdateRotaConfig(ctx, nil) },
+	},
+	}
+
+	for _, tst := range tests {
+		if err := tst.call(); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("%s(ctx, nil) = %v, want code: %v", tst.name, err, codes.InvalidArgument)
+		}
+	}
+}
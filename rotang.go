@@ -6,8 +6,15 @@
 package rotang
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
 	"strings"
 	"time"
+
+	"go.chromium.org/gae/service/mail"
+	"go.chromium.org/luci/server/router"
+	"golang.org/x/oauth2"
 )
 
 // Rota represents a named rotation and it's shift entries.
@@ -34,13 +41,18 @@ type Config struct {
 	Shifts           ShiftConfig
 	Expiration       int
 	Enabled          bool
+	// Calendar is the ID of the calendar used to store shift events.
+	Calendar string
+	// TokenID identifies the OAuth2 token used to access the Calendar.
+	TokenID string
+	// CalDAV is the URL of the CalDAV collection used to store shift events.
+	CalDAV string `json:",omitempty"`
 }
 
 // ShiftConfig holds the Shift configuration.
 type ShiftConfig struct {
 	// StartTime represents the start-time of the first shift.
-	// Only the Time of day is considered.
-	// Defaults to 00:00 PDT.
+	// Only the Time of day is considered, as wall clock time in TZ.
 	StartTime time.Time
 	// Length sets the number of days a shift lasts.
 	Length int
@@ -55,9 +67,89 @@ type ShiftConfig struct {
 	// Modifiers is used to modify shifts produced by the Generator.
 	Modifiers []string
 	// TZ TimeZone used.
+	// Shift start times, days and weekends are calculated in this time zone. The handlers use
+	// America/Los_Angeles for rotations stored without one.
 	TZ time.Location
 	// FullDayEvents creates FullDayEvents in the rotation calendar.
 	FullDayEvents bool
+	// Holidays used by the HolidaySkip modifier. Either the name of a built-in
+	// region, eg. "NL", or the name of an iCalendar (.ics) or CSV (.csv) file in algo.HolidayDir.
+	Holidays string
+	// Seed seeds the random choices of the Generator, when 0 a new seed is used every time.
+	Seed int64
+	// MinRest is the minimum time between the end of a shift and the next shift of the same member.
+	MinRest time.Duration
+	// MaxConsecutive is the maximum number of shifts in a row for the same member, 0 for no maximum.
+	MaxConsecutive int
+	// Roles are given, in order, to the members of a shift, eg. [primary, secondary].
+	// Members beyond the listed roles get no role. The Generator rotates members through the roles.
+	Roles []Role
+	// Trainees are new members shadowing experienced members before going oncall alone, see algo.Shadow.
+	Trainees []Trainee
+	// HourWeights weigh the hours oncall for the FairHours generator.
+	HourWeights HourWeights
+	// MaxOverlap allows members to be scheduled for shifts they are out of office or unavailable for
+	// less than MaxOverlap in total. With 0 any overlap keeps a member from being scheduled.
+	MaxOverlap time.Duration
+	// Trace makes the Generator record why members were scheduled or skipped in ShiftEntry.Trace.
+	// It is set per request and never stored.
+	Trace bool `json:"-"`
+}
+
+// HourWeights are multipliers for hours oncall in the rotation time zone, 0 counts as 1.
+// When more than one applies to an hour the largest is used.
+type HourWeights struct {
+	Weekend float64
+	// Night hours are between algo.NightStart and algo.NightEnd.
+	Night   float64
+	Holiday float64
+}
+
+// Location returns the rotation time zone, UTC if not set.
+func (s *ShiftConfig) Location() *time.Location {
+	if s.TZ.String() == "" {
+		return time.UTC
+	}
+	loc := s.TZ
+	return &loc
+}
+
+// MarshalJSON encodes the ShiftConfig with TZ as the name of the time zone,
+// a time.Location does not survive JSON encoding as is.
+func (s ShiftConfig) MarshalJSON() ([]byte, error) {
+	type shiftConfig ShiftConfig
+	return json.Marshal(&struct {
+		*shiftConfig
+		TZ string
+	}{
+		shiftConfig: (*shiftConfig)(&s),
+		TZ:          s.TZ.String(),
+	})
+}
+
+// UnmarshalJSON decodes a ShiftConfig with TZ set to the name of a time zone.
+// A TZ that is not a string, as written by earlier versions, is ignored.
+func (s *ShiftConfig) UnmarshalJSON(b []byte) error {
+	type shiftConfig ShiftConfig
+	aux := struct {
+		*shiftConfig
+		TZ json.RawMessage
+	}{
+		shiftConfig: (*shiftConfig)(s),
+	}
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+	var name string
+	if len(aux.TZ) == 0 || json.Unmarshal(aux.TZ, &name) != nil {
+		return nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return err
+	}
+	s.TZ = *loc
+	return nil
 }
 
 // Shift represents a shift in a 24h rotation.
@@ -66,6 +158,8 @@ type Shift struct {
 	Name string
 	// Duration is the duration of the shift.
 	Duration time.Duration
+	// Coverage lists the tags, see Member.Tags, that at least one member oncall for the shift must have.
+	Coverage []string `json:",omitempty"`
 }
 
 // ShiftEntry represents one scheduled shift.
@@ -79,8 +173,44 @@ type ShiftEntry struct {
 	// Comment is an optional comment where the rota algo
 	// can add some extra information.
 	Comment string
+	// EvtID is the ID of the calendar event for this shift.
+	EvtID string
+	// Seed is the seed the Generator used for this shift. Generating with
+	// the same seed, members and previous shifts gives the same shifts.
+	Seed int64
+	// Trace holds the candidates the Generator considered for this shift,
+	// only set when ShiftConfig.Trace is.
+	Trace []Decision `json:",omitempty"`
 }
 
+// Decision records why a member was, or was not, scheduled for a shift.
+type Decision struct {
+	Email  string
+	Reason Reason
+	// Weight is the fairness weight of the member if the Generator uses one,
+	// members with a lower weight are scheduled first.
+	Weight int `json:",omitempty"`
+}
+
+// Reason explains a Decision.
+type Reason string
+
+// Reasons for scheduling or skipping a member.
+const (
+	Scheduled                  Reason = "scheduled"
+	ScheduledAgainstPreference Reason = "scheduled against preference"
+	SkippedOOO                 Reason = "out of office"
+	SkippedUnavailable         Reason = "unavailable"
+	SkippedPreference          Reason = "preference"
+	SkippedScheduled           Reason = "already scheduled"
+	SkippedRest                Reason = "minimum rest"
+	SkippedConsecutive         Reason = "maximum consecutive shifts"
+	SkippedCoverage            Reason = "needed for coverage"
+	Shadowing                  Reason = "shadowing"
+	// Available members could have been scheduled, but others were picked.
+	Available Reason = "available"
+)
+
 func (s ShiftEntry) String() string {
 	b := ""
 	b += s.Name + ":\n"
@@ -114,10 +244,45 @@ type Email struct {
 type ShiftMember struct {
 	Email     string
 	ShiftName string
+	// Role is the role of the member in a ShiftEntry, empty for rotations without roles.
+	Role Role `json:",omitempty"`
+	// Mentor is the member a trainee shadows in a ShiftEntry.
+	Mentor string `json:",omitempty"`
+}
+
+// Trainee is a member doing shadow shifts.
+type Trainee struct {
+	Email string
+	// ShadowShifts is the number of shifts to shadow before going oncall alone.
+	ShadowShifts int
 }
 
 func (s ShiftMember) String() string {
-	return s.Email
+	if s.Role == "" {
+		return s.Email
+	}
+	return s.Email + " (" + string(s.Role) + ")"
+}
+
+// Role is the role of a member within a shift.
+type Role string
+
+// Roles within a shift.
+const (
+	Primary   Role = "primary"
+	Secondary Role = "secondary"
+	Shadow    Role = "shadow"
+)
+
+// MemberRole returns the role of the member in the shift, empty if the member has none or
+// is not oncall.
+func (s ShiftEntry) MemberRole(email string) Role {
+	for _, o := range s.OnCall {
+		if o.Email == email {
+			return o.Role
+		}
+	}
+	return ""
 }
 
 // Member represents one member of a rotation.
@@ -127,6 +292,12 @@ type Member struct {
 	TZ          time.Location
 	OOO         []OOO
 	Preferences []Preference
+	// Tags are the skills of the member, eg. "network" or "db", see Shift.Coverage.
+	Tags []string `json:",omitempty"`
+	// Unavailable lists the recurring periods the member is not available, eg. Fridays after 14:00.
+	Unavailable []Unavailable `json:",omitempty"`
+	// Calendar is the path or http(s) URL of an iCalendar feed to import OOO entries from.
+	Calendar string `json:",omitempty"`
 }
 
 // OOO contains one Out-of-Office event.
@@ -134,6 +305,30 @@ type OOO struct {
 	Start    time.Time
 	Duration time.Duration
 	Comment  string
+	Type     OOOType `json:",omitempty"`
+	// Synced is set for entries imported from the Member.Calendar, these are replaced on every import.
+	Synced bool `json:",omitempty"`
+}
+
+// OOOType is the kind of Out-of-Office event.
+type OOOType string
+
+// Types of Out-of-Office events.
+const (
+	Vacation   OOOType = "vacation"
+	Sick       OOOType = "sick"
+	Conference OOOType = "conference"
+)
+
+// Unavailable is a weekly recurring period a member is not available.
+// Part-time members working Monday to Wednesday are unavailable all day Thursday to Sunday.
+type Unavailable struct {
+	// Weekdays the period is on, every day if empty.
+	Weekdays []time.Weekday `json:",omitempty"`
+	// From and To are the start and end of the period as the time since midnight in the member's time zone.
+	// A To of 0 is the end of the day.
+	From time.Duration
+	To   time.Duration
 }
 
 // Preference is used for Members to signal shift preferences.
@@ -172,5 +367,113 @@ type Info struct {
 	ShiftConfig ShiftConfig
 	ShiftEntry  ShiftEntry
 	Member      Member
-	MemberURL   string
+	// Role is the role of the Member in the ShiftEntry.
+	Role      Role
+	MemberURL string
+}
+
+// MemberStorer defines the store interface for rotation members.
+//
+// Member and DeleteMember return an error with code NotFound if the member does
+// not exist, CreateMember returns an error with code AlreadyExists if it does.
+type MemberStorer interface {
+	CreateMember(ctx context.Context, member *Member) error
+	Member(ctx context.Context, email string) (*Member, error)
+	AllMembers(ctx context.Context) ([]Member, error)
+	DeleteMember(ctx context.Context, email string) error
+	UpdateMember(ctx context.Context, member *Member) error
+}
+
+// ShiftStorer defines the store interface for shift entries.
+// Shifts are identified by the rota name and their StartTime.
+//
+// Lookups return an error with code NotFound when there are no matching shifts.
+type ShiftStorer interface {
+	// AddShifts adds shifts to the rota, an error with code AlreadyExists
+	// is returned if a shift with the same StartTime is already stored.
+	AddShifts(ctx context.Context, rota string, entries []ShiftEntry) error
+	// AllShifts returns all shifts for the rota sorted by StartTime.
+	AllShifts(ctx context.Context, rota string) ([]ShiftEntry, error)
+	// ShiftsFromTo returns shifts ending after from and starting before to.
+	// A zero to means open-ended and returns all shifts from the from time.
+	ShiftsFromTo(ctx context.Context, rota string, from, to time.Time) ([]ShiftEntry, error)
+	// Shift returns the shift starting at start.
+	Shift(ctx context.Context, rota string, start time.Time) (*ShiftEntry, error)
+	// Oncall returns the shift in progress at the at time.
+	Oncall(ctx context.Context, at time.Time, rota string) (*ShiftEntry, error)
+	DeleteAllShifts(ctx context.Context, rota string) error
+	DeleteShift(ctx context.Context, rota string, start time.Time) error
+	UpdateShift(ctx context.Context, rota string, shift *ShiftEntry) error
+}
+
+// ConfigStorer defines the store interface for rotation configurations.
+//
+// Operations on a rota that does not exist return an error with code NotFound,
+// CreateRotaConfig returns an error with code AlreadyExists if the rota exists.
+type ConfigStorer interface {
+	CreateRotaConfig(ctx context.Context, rotation *Configuration) error
+	UpdateRotaConfig(ctx context.Context, rotation *Configuration) error
+	// RotaConfig returns the named configuration, an empty name returns all configurations.
+	RotaConfig(ctx context.Context, name string) ([]*Configuration, error)
+	DeleteRotaConfig(ctx context.Context, name string) error
+	AddRotaMember(ctx context.Context, rota string, member *ShiftMember) error
+	DeleteRotaMember(ctx context.Context, rota, email string) error
+	EnableRota(ctx context.Context, rota string) error
+	DisableRota(ctx context.Context, rota string) error
+	RotaEnabled(ctx context.Context, rota string) (bool, error)
+	// MemberOf returns the names of the rotations the member is part of.
+	MemberOf(ctx context.Context, email string) ([]string, error)
+}
+
+// TokenStorer is used to store OAuth2 tokens.
+type TokenStorer interface {
+	CreateToken(ctx context.Context, id, config string, token *oauth2.Token) error
+	Token(ctx context.Context, id string) (*oauth2.Token, error)
+	// Client returns a HTTP client using the stored token and config.
+	Client(ctx *router.Context, id string) (*http.Client, error)
+	DeleteToken(ctx context.Context, id string) error
+}
+
+// Calenderer is used to manage calendar events for shifts.
+type Calenderer interface {
+	// CreateEvent creates calendar events for the shifts, the returned shifts have their EvtID set.
+	// Events are only written to the calendar when updateCal is true.
+	CreateEvent(ctx *router.Context, cfg *Configuration, shifts []ShiftEntry, updateCal bool) ([]ShiftEntry, error)
+	UpdateEvent(ctx *router.Context, cfg *Configuration, updated *ShiftEntry) (*ShiftEntry, error)
+	DeleteEvent(ctx *router.Context, cfg *Configuration, shift *ShiftEntry) error
+	// Event returns the calendar event matching the shift, NotFound if it does not exist.
+	Event(ctx *router.Context, cfg *Configuration, shift *ShiftEntry) (*ShiftEntry, error)
+	Events(ctx *router.Context, cfg *Configuration, from, to time.Time) ([]ShiftEntry, error)
+	// TrooperOncall and TrooperShifts read the legacy trooper calendar, matching event summaries with match.
+	TrooperOncall(ctx *router.Context, calendarID, match string, at time.Time) ([]string, error)
+	TrooperShifts(ctx *router.Context, calendarID, match string, from, to time.Time) ([]ShiftEntry, error)
+}
+
+// MailSender is used to send e-mails.
+type MailSender interface {
+	Send(ctx context.Context, msg *mail.Message) error
+}
+
+// User is an authenticated user.
+type User struct {
+	Email string
+	Name  string
+	// Groups holds the groups the user is known to be part of, eg. as forwarded by an SSO proxy.
+	Groups []string
+}
+
+// Authenticator identifies the user making a request.
+type Authenticator interface {
+	// User returns the user making the request, nil if the request is not authenticated.
+	User(ctx *router.Context) (*User, error)
+	// Login asks an unauthenticated user to authenticate, eg. by redirecting to a login page.
+	Login(ctx *router.Context)
+}
+
+// Authorizer decides what an authenticated user is allowed to do.
+type Authorizer interface {
+	// IsAdmin is true if the user can manage all rotations.
+	IsAdmin(ctx *router.Context, usr *User) (bool, error)
+	// IsMember is true if the user is part of group.
+	IsMember(ctx *router.Context, usr *User, group string) (bool, error)
 }